@@ -0,0 +1,157 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// LoadCandlesCSV loads a recorded candle series from a CSV file, keeping only the
+// candles opened in the [from, to] range (a zero time means no bound).
+//
+//     Each record must be in the form time,open,high,low,close,volume where time is
+//     either a unix timestamp (seconds or milliseconds) or a RFC3339 date.
+//     An optional header line is skipped. The close time of the candles is inferred
+//     from the smallest time difference between two consecutive candles.
+func LoadCandlesCSV(path string, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadCandlesCSV(file, from, to)
+}
+
+// ReadCandlesCSV reads a recorded candle series in CSV format, see LoadCandlesCSV.
+func ReadCandlesCSV(r io.Reader, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 6
+	reader.TrimLeadingSpace = true
+
+	ret := make([]environment.CandleStick, 0)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		openTime, err := parseCandleTime(record[0])
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if !from.IsZero() && openTime.Before(from) || !to.IsZero() && openTime.After(to) {
+			continue
+		}
+
+		var values [5]decimal.Decimal
+		for i := range values {
+			values[i], err = decimal.NewFromString(record[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+		}
+
+		ret = append(ret, environment.CandleStick{
+			Open:     values[0],
+			High:     values[1],
+			Low:      values[2],
+			Close:    values[3],
+			Volume:   values[4],
+			OpenTime: openTime,
+		})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].OpenTime.Before(ret[j].OpenTime)
+	})
+
+	var period time.Duration
+	for i := 1; i < len(ret); i++ {
+		step := ret[i].OpenTime.Sub(ret[i-1].OpenTime)
+		if step > 0 && (period == 0 || step < period) {
+			period = step
+		}
+	}
+	for i := range ret {
+		ret[i].CloseTime = ret[i].OpenTime
+		if period > 0 {
+			ret[i].CloseTime = ret[i].OpenTime.Add(period - time.Millisecond)
+		}
+	}
+
+	return ret, nil
+}
+
+// SaveCandlesCSV saves a candle series to a CSV file, in the format read by LoadCandlesCSV.
+func SaveCandlesCSV(path string, candles []environment.CandleStick) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteCandlesCSV(file, candles); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCandlesCSV writes a candle series in CSV format, with a header line and RFC3339 dates.
+func WriteCandlesCSV(w io.Writer, candles []environment.CandleStick) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "open", "high", "low", "close", "volume"})
+	for _, candle := range candles {
+		writer.Write([]string{
+			candle.OpenTime.UTC().Format(time.RFC3339),
+			candle.Open.String(),
+			candle.High.String(),
+			candle.Low.String(),
+			candle.Close.String(),
+			candle.Volume.String(),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseCandleTime parses a unix timestamp (seconds or milliseconds) or a RFC3339 date.
+func parseCandleTime(value string) (time.Time, error) {
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Parse(time.RFC3339, value)
+	}
+	if timestamp > 1e12 {
+		return time.Unix(0, timestamp*int64(time.Millisecond)).UTC(), nil
+	}
+	return time.Unix(timestamp, 0).UTC(), nil
+}
@@ -0,0 +1,152 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package backtest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/saniales/golang-crypto-trading-bot/strategies"
+	"github.com/shopspring/decimal"
+)
+
+// Backtest represents the run of an interval strategy against a recorded candle series.
+type Backtest struct {
+	Strategy        strategies.IntervalStrategy // The strategy to test.
+	Market          *environment.Market         // The market the candles belong to.
+	Candles         []environment.CandleStick   // The recorded candle series, sorted by time.
+	InitialBalances map[string]decimal.Decimal  // The balances of the simulated exchange at start.
+	MakerFee        float64                     // The fee of maker trades, as a fraction of the total.
+	TakerFee        float64                     // The fee of taker trades, as a fraction of the total.
+	Slippage        decimal.Decimal             // The extra slippage of taker trades, as a fraction of the price.
+}
+
+// Run executes the strategy over the whole candle series and returns the results report.
+//
+//     The simulated clock moves one candle at a time and OnUpdate is called once every
+//     Interval of simulated time, orders are routed through an ExchangeWrapperSimulator.
+//     As in IntervalStrategy.Apply, the run stops at the first error.
+func (bt Backtest) Run() (*Report, error) {
+	if len(bt.Candles) == 0 {
+		return nil, errors.New("Cannot backtest without candles")
+	}
+	model := bt.Strategy.Model
+	if model.OnUpdate == nil {
+		return nil, errors.New("OnUpdate func cannot be empty")
+	}
+
+	history := NewHistoricalWrapper(bt.Market, bt.Candles)
+	history.SetFees(bt.MakerFee, bt.TakerFee)
+	simulator := exchanges.NewExchangeWrapperSimulator(history, copyBalances(bt.InitialBalances))
+	simulator.SetSlippage(bt.Slippage)
+	simulator.SetClock(history)
+	wrappers := []exchanges.ExchangeWrapper{simulator}
+	markets := []*environment.Market{bt.Market}
+
+	report := &Report{
+		Strategy:        bt.Strategy.Name(),
+		Market:          bt.Market,
+		From:            history.Now(),
+		InitialBalances: copyBalances(bt.InitialBalances),
+		InitialEquity:   bt.equity(simulator, history.CurrentCandle().Close),
+		MaxDrawdown:     decimal.Zero,
+	}
+	peak := report.InitialEquity
+
+	var err error
+	if model.Setup != nil {
+		err = model.Setup(context.Background(), wrappers, markets)
+		bt.handleError(err)
+	}
+
+	nextUpdate := history.Now()
+	for err == nil {
+		if !history.Now().Before(nextUpdate) {
+			err = model.OnUpdate(context.Background(), wrappers, markets)
+			bt.handleError(err)
+			report.Updates++
+			for bt.Strategy.Interval > 0 && !nextUpdate.After(history.Now()) {
+				nextUpdate = nextUpdate.Add(bt.Strategy.Interval)
+			}
+		}
+
+		equity := bt.equity(simulator, history.CurrentCandle().Close)
+		if equity.GreaterThan(peak) {
+			peak = equity
+		} else if peak.IsPositive() {
+			drawdown := peak.Sub(equity).Div(peak)
+			if drawdown.GreaterThan(report.MaxDrawdown) {
+				report.MaxDrawdown = drawdown
+			}
+		}
+
+		if err != nil || !history.Advance() {
+			break
+		}
+	}
+	report.Err = err
+
+	if model.TearDown != nil {
+		bt.handleError(model.TearDown(context.Background(), wrappers, markets))
+	}
+
+	report.To = history.Now()
+	report.Candles = history.current + 1
+	report.Orders = simulator.OrderHistory()
+	report.Fees = decimal.Zero
+	for _, fill := range simulator.Fills() {
+		report.Fees = report.Fees.Add(fill.Fee)
+	}
+	report.FinalBalances = make(map[string]decimal.Decimal)
+	for currency := range report.InitialBalances {
+		balance, _ := simulator.GetBalance(currency)
+		report.FinalBalances[currency] = *balance
+	}
+	for _, currency := range []string{bt.Market.BaseCurrency, bt.Market.MarketCurrency} {
+		balance, _ := simulator.GetBalance(currency)
+		report.FinalBalances[currency] = *balance
+	}
+	report.FinalEquity = bt.equity(simulator, history.CurrentCandle().Close)
+
+	return report, nil
+}
+
+// equity gets the value of the market balances in quote currency (MarketCurrency) at the specified price,
+// including the balances reserved by open orders.
+func (bt Backtest) equity(wrapper *exchanges.ExchangeWrapperSimulator, price decimal.Decimal) decimal.Decimal {
+	baseBalance, _ := wrapper.GetBalance(bt.Market.BaseCurrency)
+	quoteBalance, _ := wrapper.GetBalance(bt.Market.MarketCurrency)
+	base := baseBalance.Add(wrapper.GetReservedBalance(bt.Market.BaseCurrency))
+	quote := quoteBalance.Add(wrapper.GetReservedBalance(bt.Market.MarketCurrency))
+	return quote.Add(base.Mul(price))
+}
+
+// handleError forwards an error to the OnError func of the strategy, if any.
+func (bt Backtest) handleError(err error) {
+	if err != nil && bt.Strategy.Model.OnError != nil {
+		bt.Strategy.Model.OnError(err)
+	}
+}
+
+func copyBalances(balances map[string]decimal.Decimal) map[string]decimal.Decimal {
+	ret := make(map[string]decimal.Decimal, len(balances))
+	for currency, balance := range balances {
+		ret[currency] = balance
+	}
+	return ret
+}
@@ -0,0 +1,157 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package backtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/saniales/golang-crypto-trading-bot/strategies"
+	"github.com/shopspring/decimal"
+)
+
+// errStrategy is the error returned by the failing strategies of the tests.
+var errStrategy = errors.New("strategy failure")
+
+// minuteCandles creates candles of one minute from their close prices, starting at the specified time.
+func minuteCandles(start time.Time, closes ...int64) []environment.CandleStick {
+	ret := make([]environment.CandleStick, len(closes))
+	for i, value := range closes {
+		price := decimal.NewFromInt(value)
+		openTime := start.Add(time.Duration(i) * time.Minute)
+		ret[i] = environment.CandleStick{
+			High:      price,
+			Open:      price,
+			Close:     price,
+			Low:       price,
+			Volume:    decimal.NewFromInt(10),
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(time.Minute - time.Millisecond),
+		}
+	}
+	return ret
+}
+
+func TestBacktestRun(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	market := &environment.Market{Name: "ETH-BTC", BaseCurrency: "ETH", MarketCurrency: "BTC"}
+	tests := []struct {
+		name         string
+		actions      []string // Action of each update: "buy", "sell" (1 ETH at market), "fail" or nothing.
+		wantUpdates  int
+		wantCandles  int
+		wantOrders   int
+		wantEquity   string
+		wantFees     string
+		wantDrawdown string
+		wantErr      error
+	}{
+		{"no orders", nil, 2, 4, 0, "1000", "0", "0.0000", nil},
+		{"buy and sell", []string{"buy", "sell"}, 2, 4, 2, "989.62", "0.38", "0.0200", nil},
+		{"buy and hold", []string{"buy"}, 2, 4, 1, "1019.8", "0.2", "0.0198", nil},
+		{"failing strategy", []string{"buy", "fail"}, 2, 3, 1, "989.8", "0.2", "0.0198", errStrategy},
+	}
+
+	for _, test := range tests {
+		updates := 0
+		var handled error
+		strategy := strategies.IntervalStrategy{
+			Model: strategies.StrategyModel{
+				Name: test.name,
+				OnUpdate: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+					updates++
+					if updates > len(test.actions) {
+						return nil
+					}
+					var err error
+					switch test.actions[updates-1] {
+					case "buy":
+						_, err = wrappers[0].BuyMarket(markets[0], decimal.NewFromInt(1))
+					case "sell":
+						_, err = wrappers[0].SellMarket(markets[0], decimal.NewFromInt(1))
+					case "fail":
+						err = errStrategy
+					}
+					return err
+				},
+				OnError: func(err error) {
+					handled = err
+				},
+			},
+			Interval: 2 * time.Minute,
+		}
+		report, err := Backtest{
+			Strategy:        strategy,
+			Market:          market,
+			Candles:         minuteCandles(start, 100, 110, 90, 120),
+			InitialBalances: map[string]decimal.Decimal{"BTC": decimal.NewFromInt(1000)},
+			TakerFee:        0.002,
+		}.Run()
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if report.Updates != test.wantUpdates || report.Candles != test.wantCandles || len(report.Orders) != test.wantOrders {
+			t.Errorf("%s: %d updates, %d candles, %d orders, want %d, %d, %d", test.name,
+				report.Updates, report.Candles, len(report.Orders), test.wantUpdates, test.wantCandles, test.wantOrders)
+		}
+		if !report.InitialEquity.Equal(decimal.NewFromInt(1000)) || !report.FinalEquity.Equal(decimal.RequireFromString(test.wantEquity)) {
+			t.Errorf("%s: equity from %s to %s, want from 1000 to %s", test.name, report.InitialEquity, report.FinalEquity, test.wantEquity)
+		}
+		if !report.Fees.Equal(decimal.RequireFromString(test.wantFees)) {
+			t.Errorf("%s: fees %s, want %s", test.name, report.Fees, test.wantFees)
+		}
+		if got := report.MaxDrawdown.StringFixed(4); got != test.wantDrawdown {
+			t.Errorf("%s: max drawdown %s, want %s", test.name, got, test.wantDrawdown)
+		}
+		if !errors.Is(report.Err, test.wantErr) || !errors.Is(handled, test.wantErr) {
+			t.Errorf("%s: stopped by %v (handled %v), want %v", test.name, report.Err, handled, test.wantErr)
+		}
+		if want := start.Add(time.Duration(test.wantCandles) * time.Minute); !report.To.Equal(want) {
+			t.Errorf("%s: run until %s, want %s", test.name, report.To, want)
+		}
+	}
+}
+
+func TestBacktestInvalid(t *testing.T) {
+	market := &environment.Market{Name: "ETH-BTC", BaseCurrency: "ETH", MarketCurrency: "BTC"}
+	onUpdate := func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		return nil
+	}
+	tests := []struct {
+		name     string
+		backtest Backtest
+	}{
+		{"no candles", Backtest{
+			Strategy: strategies.IntervalStrategy{Model: strategies.StrategyModel{OnUpdate: onUpdate}},
+			Market:   market,
+		}},
+		{"no OnUpdate", Backtest{
+			Market:  market,
+			Candles: minuteCandles(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), 100),
+		}},
+	}
+
+	for _, test := range tests {
+		if _, err := test.backtest.Run(); err == nil {
+			t.Errorf("%s: got no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// Report represents the results of a backtest run.
+type Report struct {
+	Strategy        string                     // The name of the tested strategy.
+	Market          *environment.Market        // The tested market.
+	From            time.Time                  // The time of the first processed candle.
+	To              time.Time                  // The time of the last processed candle.
+	Candles         int                        // The number of processed candles.
+	Updates         int                        // The number of OnUpdate calls.
+	Orders          []*environment.OrderInfo   // The orders placed during the run, in placement order.
+	InitialBalances map[string]decimal.Decimal // The balances at start.
+	FinalBalances   map[string]decimal.Decimal // The balances at the end of the run.
+	InitialEquity   decimal.Decimal            // The value of the market balances at start, in quote currency.
+	FinalEquity     decimal.Decimal            // The value of the market balances at the end, in quote currency.
+	MaxDrawdown     decimal.Decimal            // The maximum loss from a peak of equity, as a fraction of the peak.
+	Fees            decimal.Decimal            // The trading fees paid, in quote currency.
+	Err             error                      // The error which stopped the run, if any.
+}
+
+// Profit returns the difference between final and initial equity.
+func (report Report) Profit() decimal.Decimal {
+	return report.FinalEquity.Sub(report.InitialEquity)
+}
+
+// Return returns the profit as a percentage of the initial equity.
+func (report Report) Return() decimal.Decimal {
+	if report.InitialEquity.IsZero() {
+		return decimal.Zero
+	}
+	return report.Profit().Div(report.InitialEquity).Mul(decimal.NewFromInt(100))
+}
+
+// String returns the string representation of the object.
+func (report Report) String() string {
+	var buys, sells int
+	for _, order := range report.Orders {
+		if order.Side == environment.Bid {
+			buys++
+		} else {
+			sells++
+		}
+	}
+
+	ret := fmt.Sprintln("Strategy:       ", report.Strategy)
+	ret += fmt.Sprintln("Market:         ", report.Market.Name)
+	ret += fmt.Sprintf("Period:          %s -> %s (%d candles)\n", report.From.Format(time.RFC3339), report.To.Format(time.RFC3339), report.Candles)
+	ret += fmt.Sprintln("Updates:        ", report.Updates)
+	ret += fmt.Sprintf("Orders:          %d (%d buys, %d sells)\n", len(report.Orders), buys, sells)
+	ret += fmt.Sprintln("Fees paid:      ", report.Fees, report.Market.MarketCurrency)
+	ret += fmt.Sprintln("Initial equity: ", report.InitialEquity, report.Market.MarketCurrency)
+	ret += fmt.Sprintln("Final equity:   ", report.FinalEquity, report.Market.MarketCurrency)
+	ret += fmt.Sprintf("Return:          %s%%\n", report.Return().StringFixed(2))
+	ret += fmt.Sprintf("Max drawdown:    %s%%\n", report.MaxDrawdown.Mul(decimal.NewFromInt(100)).StringFixed(2))
+
+	currencies := make([]string, 0, len(report.FinalBalances))
+	for currency := range report.FinalBalances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	ret += fmt.Sprintln("Final balances:")
+	for _, currency := range currencies {
+		ret += fmt.Sprintf("  %s: %s (initial %s)\n", currency, report.FinalBalances[currency], report.InitialBalances[currency])
+	}
+
+	if report.Err != nil {
+		ret += fmt.Sprintln("Stopped on error:", report.Err)
+	}
+	return strings.TrimSpace(ret)
+}
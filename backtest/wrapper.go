@@ -0,0 +1,336 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package backtest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// historicalLiquidity is the quantity available at the close price of the current candle.
+var historicalLiquidity = decimal.New(1, 15)
+
+// errOrdersNotSupported is returned by the order related functions of HistoricalWrapper,
+// orders must be routed through an ExchangeWrapperSimulator.
+var errOrdersNotSupported = fmt.Errorf("%w: orders on historical data, use an ExchangeWrapperSimulator", exchanges.ErrNotSupported)
+
+// HistoricalWrapper is an ExchangeWrapper serving a recorded candle series of a single market,
+// exposing only the data known at the current simulated time.
+type HistoricalWrapper struct {
+	market   *environment.Market
+	candles  []environment.CandleStick
+	current  int
+	makerFee float64 // Fee of maker trades, as a fraction of the total.
+	takerFee float64 // Fee of taker trades, as a fraction of the total.
+}
+
+// NewHistoricalWrapper creates a new wrapper serving the specified candles for a market.
+//
+//     The market is bound to the wrapper, candles must be sorted by time.
+func NewHistoricalWrapper(market *environment.Market, candles []environment.CandleStick) *HistoricalWrapper {
+	wrapper := &HistoricalWrapper{
+		market:  market,
+		candles: candles,
+	}
+	if market.ExchangeNames == nil {
+		market.ExchangeNames = make(map[string]string)
+	}
+	market.ExchangeNames[wrapper.Name()] = market.Name
+	return wrapper
+}
+
+// Name gets the name of the exchange.
+func (wrapper *HistoricalWrapper) Name() string {
+	return "backtest"
+}
+
+// String returns a string representation of the object.
+func (wrapper *HistoricalWrapper) String() string {
+	return wrapper.Name()
+}
+
+// Capabilities gets the features supported by the exchange: orders must be routed through an ExchangeWrapperSimulator.
+func (wrapper *HistoricalWrapper) Capabilities() exchanges.Capabilities {
+	return exchanges.Capabilities{
+		Candles:   true,
+		OrderBook: true,
+	}
+}
+
+// Advance moves the simulated time to the next candle, returns false when the series is over.
+func (wrapper *HistoricalWrapper) Advance() bool {
+	if wrapper.current+1 >= len(wrapper.candles) {
+		return false
+	}
+	wrapper.current++
+	return true
+}
+
+// CurrentCandle gets the candle at the current simulated time.
+func (wrapper *HistoricalWrapper) CurrentCandle() environment.CandleStick {
+	return wrapper.candles[wrapper.current]
+}
+
+// CurrentBar gets the candle at the current simulated time, whose whole price range
+// is used by an ExchangeWrapperSimulator to fill the limit orders placed before it.
+func (wrapper *HistoricalWrapper) CurrentBar(market *environment.Market) (environment.CandleStick, error) {
+	if err := wrapper.checkMarket(market); err != nil {
+		return environment.CandleStick{}, err
+	}
+	return wrapper.CurrentCandle(), nil
+}
+
+// Now gets the current simulated time: the end of the current candle, when its final values are known.
+func (wrapper *HistoricalWrapper) Now() time.Time {
+	candle := wrapper.CurrentCandle()
+	if !candle.CloseTime.IsZero() {
+		return candle.CloseTime.Add(time.Millisecond)
+	}
+	if wrapper.current+1 < len(wrapper.candles) {
+		return wrapper.candles[wrapper.current+1].OpenTime
+	}
+	return candle.OpenTime
+}
+
+// Sleep does not move the simulated time, which is moved only by Advance so that every candle
+// goes through the engine: the delays within a candle (e.g. the latency of the simulator) are not simulated.
+func (wrapper *HistoricalWrapper) Sleep(d time.Duration) {
+}
+
+// After returns a channel holding the current simulated time, which is not moved (see Sleep).
+func (wrapper *HistoricalWrapper) After(d time.Duration) <-chan time.Time {
+	ret := make(chan time.Time, 1)
+	ret <- wrapper.Now()
+	return ret
+}
+
+// checkMarket returns an error if the market is not the one served by the wrapper.
+func (wrapper *HistoricalWrapper) checkMarket(market *environment.Market) error {
+	if market.Name != wrapper.market.Name {
+		return errors.New("No historical data for market " + market.Name)
+	}
+	return nil
+}
+
+// GetMarkets gets all the markets info.
+func (wrapper *HistoricalWrapper) GetMarkets() ([]*environment.Market, error) {
+	return []*environment.Market{wrapper.market}, nil
+}
+
+// GetListPriceChangeStats is not supported on historical data.
+func (wrapper *HistoricalWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
+	return nil, fmt.Errorf("%w: price change stats on historical data", exchanges.ErrNotSupported)
+}
+
+// GetCandles gets the candles recorded up to the current simulated time, the current one being closed.
+//
+//     Intervals coarser than the one of the recorded series are resampled from it,
+//     finer ones are not supported.
+func (wrapper *HistoricalWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	if err := wrapper.checkMarket(market); err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.CandleStick, wrapper.current+1)
+	copy(ret, wrapper.candles)
+
+	first := ret[0]
+	if first.CloseTime.IsZero() {
+		return ret, nil
+	}
+
+	recorded := first.CloseTime.Sub(first.OpenTime) + time.Millisecond
+	step := interval.Duration()
+	switch {
+	case step == recorded:
+		return ret, nil
+	case step > recorded && step%recorded == 0:
+		return environment.ResampleCandles(ret, interval)
+	default:
+		return nil, fmt.Errorf("%w: %s candles on %s", exchanges.ErrUnsupportedInterval, interval, wrapper.Name())
+	}
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range, up to the current simulated time.
+func (wrapper *HistoricalWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	candles, err := wrapper.GetCandles(market, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.CandleStick, 0, len(candles))
+	for _, candle := range candles {
+		if !candle.OpenTime.Before(from) && candle.OpenTime.Before(to) {
+			ret = append(ret, candle)
+		}
+	}
+	return ret, nil
+}
+
+// GetTradingRules gets the constraints on the orders of a market: there are none on historical data.
+func (wrapper *HistoricalWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return environment.TradingRules{}, nil
+}
+
+// GetMarketSummary gets the market summary at the current simulated time, using the last 24 hours of candles.
+func (wrapper *HistoricalWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
+	if err := wrapper.checkMarket(market); err != nil {
+		return nil, err
+	}
+
+	last := wrapper.CurrentCandle()
+	summary := &environment.MarketSummary{
+		High:   last.High,
+		Low:    last.Low,
+		Volume: decimal.Zero,
+		Ask:    last.Close,
+		Bid:    last.Close,
+		Last:   last.Close,
+	}
+
+	since := last.OpenTime.Add(-24 * time.Hour)
+	for i := wrapper.current; i >= 0 && wrapper.candles[i].OpenTime.After(since); i-- {
+		candle := wrapper.candles[i]
+		if candle.High.GreaterThan(summary.High) {
+			summary.High = candle.High
+		}
+		if candle.Low.LessThan(summary.Low) {
+			summary.Low = candle.Low
+		}
+		summary.Volume = summary.Volume.Add(candle.Volume)
+	}
+
+	return summary, nil
+}
+
+// GetOrderBook gets an order book with unlimited liquidity at the close price of the current candle,
+// which is the last price at the current simulated time.
+func (wrapper *HistoricalWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	if err := wrapper.checkMarket(market); err != nil {
+		return nil, err
+	}
+
+	last := wrapper.CurrentCandle()
+	order := environment.Order{
+		Value:     last.Close,
+		Quantity:  historicalLiquidity,
+		Timestamp: wrapper.Now(),
+	}
+	return &environment.OrderBook{
+		Asks: []environment.Order{order},
+		Bids: []environment.Order{order},
+	}, nil
+}
+
+// GetRecentTrades is not supported on historical data.
+func (wrapper *HistoricalWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, fmt.Errorf("%w: public trades on historical data", exchanges.ErrNotSupported)
+}
+
+// BuyLimit is not supported on historical data.
+func (wrapper *HistoricalWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// SellLimit is not supported on historical data.
+func (wrapper *HistoricalWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// BuyMarket is not supported on historical data.
+func (wrapper *HistoricalWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// SellMarket is not supported on historical data.
+func (wrapper *HistoricalWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// CancelOrder is not supported on historical data.
+func (wrapper *HistoricalWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	return errOrdersNotSupported
+}
+
+// GetOrder is not supported on historical data.
+func (wrapper *HistoricalWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return nil, errOrdersNotSupported
+}
+
+// GetOpenOrders is not supported on historical data.
+func (wrapper *HistoricalWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return nil, errOrdersNotSupported
+}
+
+// SetFees sets the trading fees of maker and taker trades, as fractions of the total (e.g. 0.001 for 0.1%).
+func (wrapper *HistoricalWrapper) SetFees(makerFee float64, takerFee float64) {
+	wrapper.makerFee = makerFee
+	wrapper.takerFee = takerFee
+}
+
+// CalculateTradingFees calculates the trading fees for an order on a specified market.
+func (wrapper *HistoricalWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType exchanges.TradeType) decimal.Decimal {
+	var feePercentage float64
+	if orderType == exchanges.MakerTrade {
+		feePercentage = wrapper.makerFee
+	} else if orderType == exchanges.TakerTrade {
+		feePercentage = wrapper.takerFee
+	} else {
+		panic("Unknown trade type")
+	}
+
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
+}
+
+// CalculateWithdrawFees calculates the withdrawal fees on a specified market.
+func (wrapper *HistoricalWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, fmt.Errorf("%w: withdraw fees on historical data", exchanges.ErrNotSupported)
+}
+
+// GetBalance is not supported on historical data.
+func (wrapper *HistoricalWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
+	return nil, fmt.Errorf("%w: balances on historical data", exchanges.ErrNotSupported)
+}
+
+// GetDepositAddress gets the deposit address for the specified coin on the exchange.
+func (wrapper *HistoricalWrapper) GetDepositAddress(coinTicker string) (string, bool) {
+	return "", false
+}
+
+// FeedConnect connects to the feed of the exchange.
+func (wrapper *HistoricalWrapper) FeedConnect(markets []*environment.Market) error {
+	return exchanges.ErrWebsocketNotSupported
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *HistoricalWrapper) FeedDisconnect() error {
+	return exchanges.ErrWebsocketNotSupported
+}
+
+// SubscribeTrades is not supported on historical data.
+func (wrapper *HistoricalWrapper) SubscribeTrades(market *environment.Market) (*exchanges.TradeSubscription, error) {
+	return nil, exchanges.ErrWebsocketNotSupported
+}
+
+// Withdraw is not supported on historical data.
+func (wrapper *HistoricalWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return fmt.Errorf("%w: withdrawals on historical data", exchanges.ErrNotSupported)
+}
@@ -32,11 +32,26 @@ func InitExchange(exchangeConfig environment.ExchangeConfig, simulatedMode bool,
 		return nil
 	}
 
+	if feedWrapper, ok := exch.(exchanges.FeedConfigurable); ok {
+		feedWrapper.SetFeedSettings(exchanges.FeedSettings{
+			MaxAge:   exchangeConfig.FeedMaxAge,
+			Fallback: exchangeConfig.FeedFallback,
+		})
+	}
+
+	if exchangeConfig.Timeout > 0 {
+		exch = exchanges.NewContextWrapper(exch, exchangeConfig.Timeout)
+	}
+	exch = exchanges.NewRateLimitWrapper(exch, exchangeConfig.RateLimit)
+
 	if simulatedMode {
 		if fakeBalances == nil {
 			return nil
 		}
-		exch = exchanges.NewExchangeWrapperSimulator(exch, fakeBalances)
+		simulator := exchanges.NewExchangeWrapperSimulator(exch, fakeBalances)
+		simulator.SetLatency(exchangeConfig.FakeLatency)
+		simulator.SetSlippage(exchangeConfig.FakeSlippage)
+		exch = simulator
 	}
 
 	return exch
@@ -0,0 +1,144 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock represents a source of time.
+type Clock interface {
+	Now() time.Time                         // Gets the current time.
+	Sleep(d time.Duration)                  // Waits for the specified duration to pass.
+	After(d time.Duration) <-chan time.Time // Sends the current time once the specified duration has passed.
+}
+
+// Real is the clock backed by the wall clock.
+type Real struct{}
+
+// Now gets the current wall clock time.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep pauses the current goroutine for the specified duration.
+func (Real) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel.
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fake is a clock which moves only when advanced manually.
+type Fake struct {
+	mutex   *sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter represents a goroutine waiting for the fake clock to reach a deadline.
+type fakeWaiter struct {
+	deadline time.Time
+	channel  chan time.Time
+}
+
+// NewFake creates a new fake clock, stopped at the specified time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{
+		mutex: &sync.Mutex{},
+		now:   now,
+	}
+}
+
+// Now gets the current time of the fake clock.
+func (fake *Fake) Now() time.Time {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return fake.now
+}
+
+// Sleep blocks until the fake clock has been advanced by the specified duration.
+func (fake *Fake) Sleep(d time.Duration) {
+	<-fake.After(d)
+}
+
+// After sends the time of the fake clock on the returned channel once it has been advanced
+// by the specified duration.
+func (fake *Fake) After(d time.Duration) <-chan time.Time {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	channel := make(chan time.Time, 1)
+	if d <= 0 {
+		channel <- fake.now
+		return channel
+	}
+	fake.waiters = append(fake.waiters, fakeWaiter{
+		deadline: fake.now.Add(d),
+		channel:  channel,
+	})
+	return channel
+}
+
+// Advance moves the fake clock forward by the specified duration, waking up
+// the goroutines whose deadline has been reached.
+func (fake *Fake) Advance(d time.Duration) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.setTime(fake.now.Add(d))
+}
+
+// Set moves the fake clock to the specified time, waking up the goroutines whose deadline has been reached.
+//
+//     The clock never moves backwards, earlier times are ignored.
+func (fake *Fake) Set(t time.Time) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.setTime(t)
+}
+
+// Waiters gets the number of goroutines waiting for the fake clock to be advanced,
+// useful to synchronize with the code under test before advancing.
+func (fake *Fake) Waiters() int {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return len(fake.waiters)
+}
+
+// setTime moves the clock forward and notifies the expired waiters, in deadline order.
+func (fake *Fake) setTime(t time.Time) {
+	if t.After(fake.now) {
+		fake.now = t
+	}
+
+	sort.SliceStable(fake.waiters, func(i, j int) bool {
+		return fake.waiters[i].deadline.Before(fake.waiters[j].deadline)
+	})
+	expired := 0
+	for expired < len(fake.waiters) && !fake.waiters[expired].deadline.After(fake.now) {
+		fake.waiters[expired].channel <- fake.now
+		expired++
+	}
+	fake.waiters = fake.waiters[expired:]
+}
@@ -0,0 +1,101 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		wait    time.Duration
+		move    func(fake *Fake)
+		fired   bool
+		wantNow time.Time
+	}{
+		{"no wait", 0, func(fake *Fake) {}, true, start},
+		{"not advanced", time.Minute, func(fake *Fake) {}, false, start},
+		{"advanced less", time.Minute, func(fake *Fake) { fake.Advance(59 * time.Second) }, false, start.Add(59 * time.Second)},
+		{"advanced to the deadline", time.Minute, func(fake *Fake) { fake.Advance(time.Minute) }, true, start.Add(time.Minute)},
+		{"advanced in steps", time.Minute, func(fake *Fake) {
+			fake.Advance(30 * time.Second)
+			fake.Advance(30 * time.Second)
+		}, true, start.Add(time.Minute)},
+		{"set past the deadline", time.Minute, func(fake *Fake) { fake.Set(start.Add(time.Hour)) }, true, start.Add(time.Hour)},
+		{"set backwards", time.Minute, func(fake *Fake) { fake.Set(start.Add(-time.Hour)) }, false, start},
+	}
+
+	for _, test := range tests {
+		fake := NewFake(start)
+		channel := fake.After(test.wait)
+		test.move(fake)
+
+		select {
+		case got := <-channel:
+			if !test.fired {
+				t.Errorf("%s: fired at %s, want not fired", test.name, got)
+			} else if !got.Equal(test.wantNow) {
+				t.Errorf("%s: fired at %s, want %s", test.name, got, test.wantNow)
+			}
+		default:
+			if test.fired {
+				t.Errorf("%s: not fired, want fired", test.name)
+			}
+		}
+		if now := fake.Now(); !now.Equal(test.wantNow) {
+			t.Errorf("%s: Now() = %s, want %s", test.name, now, test.wantNow)
+		}
+	}
+}
+
+func TestFakeWaitersOrder(t *testing.T) {
+	fake := NewFake(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+	late := fake.After(2 * time.Second)
+	early := fake.After(time.Second)
+	if waiters := fake.Waiters(); waiters != 2 {
+		t.Fatalf("Waiters() = %d, want 2", waiters)
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case <-early:
+	default:
+		t.Error("the earlier deadline has not fired")
+	}
+	select {
+	case <-late:
+		t.Error("the later deadline has fired too early")
+	default:
+	}
+	if waiters := fake.Waiters(); waiters != 1 {
+		t.Errorf("Waiters() = %d, want 1", waiters)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		fake.Sleep(time.Second)
+		close(done)
+	}()
+	for fake.Waiters() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Second)
+	<-late
+	<-done
+}
@@ -0,0 +1,163 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/backtest"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/store"
+	"github.com/saniales/golang-crypto-trading-bot/strategies"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+// backtestCmd represents the backtest command
+var backtestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Tests a strategy against historical data",
+	Long: `Runs an interval strategy against a recorded candle series, using a simulated clock and a simulated exchange,
+	then prints a results report.`,
+	Run: executeBacktestCommand,
+}
+
+func init() {
+	RootCmd.AddCommand(backtestCmd)
+
+	backtestCmd.Flags().StringVar(&backtestFlags.Strategy, "strategy", "", "name of the strategy to test")
+	backtestCmd.Flags().StringVar(&backtestFlags.Market, "market", "", "market of the candles, in the form BASE-QUOTE (e.g. ETH-BTC)")
+	backtestCmd.Flags().StringVar(&backtestFlags.From, "from", "", "start of the test period (YYYY-MM-DD or RFC3339), defaults to the first candle")
+	backtestCmd.Flags().StringVar(&backtestFlags.To, "to", "", "end of the test period (YYYY-MM-DD or RFC3339), defaults to the last candle")
+	backtestCmd.Flags().StringVar(&backtestFlags.DataFile, "data", "", "CSV file containing the candles (time,open,high,low,close,volume)")
+	backtestCmd.Flags().StringVar(&backtestFlags.StoreDir, "store", "", "directory of the market data store containing the candles, instead of a data file")
+	backtestCmd.Flags().StringVar(&backtestFlags.Exchange, "exchange", "", "exchange of the candles in the market data store")
+	backtestCmd.Flags().StringVar(&backtestFlags.Interval, "interval", "1h", "interval of the candles in the market data store")
+	backtestCmd.Flags().StringToStringVar(&backtestFlags.Balances, "balance", nil, "initial balances of the simulated exchange (e.g. BTC=1,ETH=0)")
+	backtestCmd.Flags().Float64Var(&backtestFlags.MakerFee, "maker-fee", 0, "fee of maker trades, as a fraction of the total (e.g. 0.001)")
+	backtestCmd.Flags().Float64Var(&backtestFlags.TakerFee, "taker-fee", 0, "fee of taker trades, as a fraction of the total (e.g. 0.001)")
+	backtestCmd.Flags().Float64Var(&backtestFlags.Slippage, "slippage", 0, "extra slippage of taker trades, as a fraction of the price (e.g. 0.0005)")
+	backtestCmd.MarkFlagRequired("strategy")
+	backtestCmd.MarkFlagRequired("market")
+}
+
+func executeBacktestCommand(cmd *cobra.Command, args []string) {
+	s, exists := strategies.GetStrategy(backtestFlags.Strategy)
+	if !exists {
+		fmt.Printf("Strategy %s does not exist\n", backtestFlags.Strategy)
+		return
+	}
+	strategy, isInterval := s.(strategies.IntervalStrategy)
+	if !isInterval {
+		fmt.Printf("Strategy %s is not an interval strategy, cannot backtest it\n", backtestFlags.Strategy)
+		return
+	}
+
+	baseCurrency, marketCurrency, err := environment.ParseMarketName(backtestFlags.Market)
+	if err != nil {
+		fmt.Println("Market must be in the form BASE-QUOTE (e.g. ETH-BTC)")
+		return
+	}
+	market := &environment.Market{
+		Name:           backtestFlags.Market,
+		BaseCurrency:   baseCurrency,
+		MarketCurrency: marketCurrency,
+	}
+
+	from, err := parseBacktestDate(backtestFlags.From)
+	if err != nil {
+		fmt.Println("Invalid start date:", err)
+		return
+	}
+	to, err := parseBacktestDate(backtestFlags.To)
+	if err != nil {
+		fmt.Println("Invalid end date:", err)
+		return
+	}
+
+	balances := make(map[string]decimal.Decimal, len(backtestFlags.Balances))
+	for currency, amount := range backtestFlags.Balances {
+		balances[currency], err = decimal.NewFromString(amount)
+		if err != nil {
+			fmt.Printf("Invalid %s balance: %s\n", currency, err)
+			return
+		}
+	}
+
+	fmt.Print("Loading candles ... ")
+	candles, err := loadBacktestCandles(market, from, to)
+	if err != nil {
+		fmt.Print("Cannot load candles")
+		if GlobalFlags.Verbose > 0 {
+			fmt.Printf(": %s", err.Error())
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Println("DONE")
+
+	fmt.Println("Running backtest ... ")
+	report, err := backtest.Backtest{
+		Strategy:        strategy,
+		Market:          market,
+		Candles:         candles,
+		InitialBalances: balances,
+		MakerFee:        backtestFlags.MakerFee,
+		TakerFee:        backtestFlags.TakerFee,
+		Slippage:        decimal.NewFromFloat(backtestFlags.Slippage),
+	}.Run()
+	if err != nil {
+		fmt.Println("Cannot run backtest:", err)
+		return
+	}
+	fmt.Println(report)
+}
+
+// loadBacktestCandles loads the candles to test from the data file or the market data store.
+func loadBacktestCandles(market *environment.Market, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	if backtestFlags.StoreDir == "" {
+		if backtestFlags.DataFile == "" {
+			return nil, errors.New("Please specify a data file or a market data store")
+		}
+		return backtest.LoadCandlesCSV(backtestFlags.DataFile, from, to)
+	}
+
+	interval, err := environment.ParseInterval(backtestFlags.Interval)
+	if err != nil {
+		return nil, err
+	}
+	marketData, err := store.Open(backtestFlags.StoreDir)
+	if err != nil {
+		return nil, err
+	}
+	if !to.IsZero() {
+		to = to.Add(time.Nanosecond) // the end of the test period is included.
+	}
+	return marketData.Candles(backtestFlags.Exchange, market, interval, from, to)
+}
+
+// parseBacktestDate parses a date in the YYYY-MM-DD or RFC3339 format, the empty string means no date.
+func parseBacktestDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,184 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/backtest"
+	helpers "github.com/saniales/golang-crypto-trading-bot/bot_helpers"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/saniales/golang-crypto-trading-bot/store"
+	"github.com/spf13/cobra"
+)
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Downloads historical candles from an exchange",
+	Long: `Downloads the candles of a market in a time range from an exchange, paging through the exchange API limits,
+	then saves them to a CSV file or to a market data store, which can be used by the backtest command.`,
+	Run: executeDownloadCommand,
+}
+
+func init() {
+	RootCmd.AddCommand(downloadCmd)
+
+	downloadCmd.Flags().StringVar(&downloadFlags.Exchange, "exchange", "", "name of the exchange (e.g. binance)")
+	downloadCmd.Flags().StringVar(&downloadFlags.Market, "market", "", "market of the candles, in the form BASE-QUOTE (e.g. ETH-BTC)")
+	downloadCmd.Flags().StringVar(&downloadFlags.ExchangeMarket, "exchange-market", "", "name of the market on the exchange (e.g. ETHBTC), defaults to the one in the configuration file or the one listed by the exchange")
+	downloadCmd.Flags().StringVar(&downloadFlags.Interval, "interval", "1h", "interval of the candles (e.g. 5m, 1h, 1d)")
+	downloadCmd.Flags().StringVar(&downloadFlags.From, "from", "", "start of the time range (YYYY-MM-DD or RFC3339)")
+	downloadCmd.Flags().StringVar(&downloadFlags.To, "to", "", "end of the time range (YYYY-MM-DD or RFC3339), defaults to now")
+	downloadCmd.Flags().StringVarP(&downloadFlags.OutputFile, "output", "o", "", "CSV file to write the candles to")
+	downloadCmd.Flags().StringVar(&downloadFlags.StoreDir, "store", "", "directory of the market data store to save the candles to")
+	downloadCmd.MarkFlagRequired("exchange")
+	downloadCmd.MarkFlagRequired("market")
+	downloadCmd.MarkFlagRequired("from")
+}
+
+func executeDownloadCommand(cmd *cobra.Command, args []string) {
+	if downloadFlags.OutputFile == "" && downloadFlags.StoreDir == "" {
+		fmt.Println("Please specify where to save the candles using --output or --store")
+		return
+	}
+
+	baseCurrency, marketCurrency, err := environment.ParseMarketName(downloadFlags.Market)
+	if err != nil {
+		fmt.Println("Market must be in the form BASE-QUOTE (e.g. ETH-BTC)")
+		return
+	}
+
+	interval, err := environment.ParseInterval(downloadFlags.Interval)
+	if err != nil {
+		fmt.Println("Invalid interval:", err)
+		return
+	}
+	from, err := parseBacktestDate(downloadFlags.From)
+	if err != nil {
+		fmt.Println("Invalid start date:", err)
+		return
+	}
+	to, err := parseBacktestDate(downloadFlags.To)
+	if err != nil {
+		fmt.Println("Invalid end date:", err)
+		return
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	// the configuration file is optional, public data can be downloaded without keys.
+	exchangeConfig := environment.ExchangeConfig{ExchangeName: downloadFlags.Exchange}
+	exchangeMarket := downloadFlags.ExchangeMarket
+	if err := initConfigs(); err == nil {
+		for _, config := range BotConfig.ExchangeConfigs {
+			if config.ExchangeName == downloadFlags.Exchange {
+				exchangeConfig = config
+			}
+		}
+		if exchangeMarket == "" {
+			exchangeMarket = configuredMarketName(downloadFlags.Market, downloadFlags.Exchange)
+		}
+	}
+
+	fmt.Print("Getting exchange info ... ")
+	wrapper := helpers.InitExchange(exchangeConfig, false, nil, map[string]string{})
+	if wrapper == nil {
+		fmt.Printf("Exchange %s is not supported\n", downloadFlags.Exchange)
+		return
+	}
+	if !wrapper.Capabilities().Candles {
+		fmt.Printf("Exchange %s does not provide candles\n", downloadFlags.Exchange)
+		return
+	}
+	if exchangeMarket == "" {
+		exchangeMarket, err = exchanges.NativeSymbol(wrapper, downloadFlags.Market)
+		if err != nil {
+			fmt.Printf("Cannot find the name of %s on %s, please specify it using --exchange-market\n", downloadFlags.Market, downloadFlags.Exchange)
+			if GlobalFlags.Verbose > 0 {
+				fmt.Println(err)
+			}
+			return
+		}
+	}
+	fmt.Println("DONE")
+
+	market := &environment.Market{
+		Name:           downloadFlags.Market,
+		BaseCurrency:   baseCurrency,
+		MarketCurrency: marketCurrency,
+		ExchangeNames: map[string]string{
+			wrapper.Name(): exchangeMarket,
+		},
+	}
+
+	fmt.Print("Downloading candles ... ")
+	candles, err := wrapper.GetCandlesRange(market, interval, from, to)
+	if err != nil {
+		fmt.Print("Cannot download candles")
+		if GlobalFlags.Verbose > 0 {
+			fmt.Printf(": %s", err.Error())
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Println("DONE")
+
+	if downloadFlags.OutputFile != "" {
+		fmt.Print("Saving candles ... ")
+		if err := backtest.SaveCandlesCSV(downloadFlags.OutputFile, candles); err != nil {
+			fmt.Println("Cannot save candles:", err)
+			return
+		}
+		fmt.Println("DONE")
+		fmt.Printf("Saved %d candles to %s\n", len(candles), downloadFlags.OutputFile)
+	}
+
+	if downloadFlags.StoreDir != "" {
+		fmt.Print("Storing candles ... ")
+		marketData, err := store.Open(downloadFlags.StoreDir)
+		if err == nil {
+			err = marketData.AppendCandles(wrapper.Name(), market, interval, candles)
+		}
+		if err != nil {
+			fmt.Println("Cannot store candles:", err)
+			return
+		}
+		fmt.Println("DONE")
+		fmt.Printf("Stored %d candles to %s\n", len(candles), downloadFlags.StoreDir)
+	}
+}
+
+// configuredMarketName gets the name of a market on an exchange from the strategies configuration,
+// the empty string if not configured.
+func configuredMarketName(market string, exchange string) string {
+	for _, strategyConf := range BotConfig.Strategies {
+		for _, mkt := range strategyConf.Markets {
+			if mkt.Name != market {
+				continue
+			}
+			for _, exName := range mkt.Exchanges {
+				if exName.Name == exchange {
+					return exName.MarketName
+				}
+			}
+		}
+	}
+	return ""
+}
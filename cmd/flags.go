@@ -15,6 +15,8 @@
 
 package bot
 
+import "time"
+
 //GlobalFlags provides flag definitions valid for the whole system.
 var GlobalFlags struct {
 	Verbose    int    //Tells the program to print everything to screen (used multiple times for better verbosity).
@@ -39,5 +41,53 @@ var initFlags struct {
 
 // startFlags provdes flag definition for start command.
 var startFlags struct {
-	Simulate bool
+	Simulate     bool
+	CancelOrders bool
+}
+
+// downloadFlags provdes flag definition for download command.
+var downloadFlags struct {
+	Exchange       string
+	Market         string
+	ExchangeMarket string
+	Interval       string
+	From           string
+	To             string
+	OutputFile     string
+	StoreDir       string
+}
+
+// recordFlags provdes flag definition for record command.
+var recordFlags struct {
+	OutputDir string
+	Rotation  time.Duration
+}
+
+// replayFlags provdes flag definition for replay command.
+var replayFlags struct {
+	Strategy string
+	Records  string
+	Exchange string
+	From     string
+	To       string
+	Speed    float64
+	Balances map[string]string
+	MakerFee float64
+	TakerFee float64
+}
+
+// backtestFlags provdes flag definition for backtest command.
+var backtestFlags struct {
+	Strategy string
+	Market   string
+	From     string
+	To       string
+	DataFile string
+	StoreDir string
+	Exchange string
+	Interval string
+	Balances map[string]string
+	MakerFee float64
+	TakerFee float64
+	Slippage float64
 }
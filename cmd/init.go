@@ -138,14 +138,19 @@ func generateInitFile() {
 		for {
 			var tmpMarketConf environment.MarketConfig
 			fmt.Println("Please Enter Market Name using short notation " +
-				"(e.g. BTC-ETH for a Bitcoin-Ethereum market).")
+				"(BASE-QUOTE, e.g. ETH-BTC for Ethereum priced in Bitcoin).")
 			fmt.Scanln(&tmpMarketConf.Name)
 			for _, ex := range configs.ExchangeConfigs {
 				var exMarketName string
-				fmt.Printf("Please Enter %s exchange market ticker, or leave empty to skip this exchange\n", ex.ExchangeName)
+				fmt.Printf("Please Enter %s exchange market ticker, \"auto\" to use the one listed by the exchange, or leave empty to skip this exchange\n", ex.ExchangeName)
 				fmt.Scanln(&exMarketName)
 
-				if exMarketName != "" {
+				if exMarketName == "auto" {
+					tmpMarketConf.Exchanges = append(tmpMarketConf.Exchanges, environment.ExchangeBindingsConfig{
+						Name: ex.ExchangeName,
+					})
+					fmt.Printf("Exchange %s CONFIGURED with the Market Name listed by the exchange\n", ex.ExchangeName)
+				} else if exMarketName != "" {
 					tmpMarketConf.Exchanges = append(tmpMarketConf.Exchanges, environment.ExchangeBindingsConfig{
 						Name:       ex.ExchangeName,
 						MarketName: exMarketName,
@@ -0,0 +1,132 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	helpers "github.com/saniales/golang-crypto-trading-bot/bot_helpers"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/saniales/golang-crypto-trading-bot/recorder"
+	"github.com/spf13/cobra"
+)
+
+// recordCmd represents the record command
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Records the market data feeds of the configured markets",
+	Long: `Subscribes to the feeds of the markets in the configuration file on every exchange supporting them,
+	then continuously writes every ticker, order book update and trade to rotating files, until interrupted.`,
+	Run: executeRecordCommand,
+}
+
+func init() {
+	RootCmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().StringVarP(&recordFlags.OutputDir, "output", "o", "records", "directory to write the recorded events to")
+	recordCmd.Flags().DurationVar(&recordFlags.Rotation, "rotate", time.Hour, "period after which a new file is started")
+}
+
+func executeRecordCommand(cmd *cobra.Command, args []string) {
+	fmt.Print("Getting configurations ... ")
+	if err := initConfigs(); err != nil {
+		fmt.Println("Cannot read from configuration file, please create or replace the current one using gobot init")
+		return
+	}
+	fmt.Println("DONE")
+
+	rec, err := recorder.NewRecorder(recordFlags.OutputDir, recordFlags.Rotation)
+	if err != nil {
+		fmt.Println("Cannot create the output directory:", err)
+		return
+	}
+	defer rec.Close()
+
+	fmt.Println("Connecting to feeds ... ")
+	var connected []exchanges.ExchangeWrapper
+	for _, config := range BotConfig.ExchangeConfigs {
+		wrapper := helpers.InitExchange(config, false, nil, map[string]string{})
+		if wrapper == nil {
+			fmt.Printf("  %s: exchange not supported\n", config.ExchangeName)
+			continue
+		}
+
+		if !wrapper.Capabilities().Websocket {
+			fmt.Printf("  %s: exchange does not support feeds\n", wrapper.Name())
+			continue
+		}
+
+		markets := recordedMarkets(config.ExchangeName)
+		if len(markets) == 0 {
+			continue
+		}
+		for _, market := range markets {
+			exchanges.AddFeedListener(market, rec.Listener(wrapper.Name()))
+		}
+
+		if err := wrapper.FeedConnect(markets); err != nil {
+			fmt.Printf("  %s: cannot connect to feed: %s\n", wrapper.Name(), err)
+			continue
+		}
+		fmt.Printf("  %s: recording %d markets\n", wrapper.Name(), len(markets))
+		connected = append(connected, wrapper)
+	}
+	if len(connected) == 0 {
+		fmt.Println("No feed to record")
+		return
+	}
+
+	fmt.Println("Recording to", recordFlags.OutputDir, "(press Ctrl+C to stop) ... ")
+	<-shutdownContext().Done()
+	for _, wrapper := range connected {
+		wrapper.FeedDisconnect()
+	}
+	fmt.Println("EXIT, good bye :)")
+}
+
+// recordedMarkets gets the markets of the strategies configuration available on an exchange.
+//
+//     Each exchange gets its own markets, so that the feed listeners know the exchange of the updates.
+func recordedMarkets(exchange string) []*environment.Market {
+	var ret []*environment.Market
+	added := make(map[string]bool)
+	for _, strategyConf := range BotConfig.Strategies {
+		for _, mkt := range strategyConf.Markets {
+			for _, exName := range mkt.Exchanges {
+				if exName.Name != exchange || added[mkt.Name] {
+					continue
+				}
+				added[mkt.Name] = true
+
+				baseCurrency, marketCurrency, err := environment.ParseMarketName(mkt.Name)
+				if err != nil {
+					continue
+				}
+				ret = append(ret, &environment.Market{
+					Name:           mkt.Name,
+					BaseCurrency:   baseCurrency,
+					MarketCurrency: marketCurrency,
+					ExchangeNames: map[string]string{
+						exName.Name: exName.MarketName,
+					},
+				})
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,124 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package bot
+
+import (
+	"fmt"
+
+	"github.com/saniales/golang-crypto-trading-bot/recorder"
+	"github.com/saniales/golang-crypto-trading-bot/strategies"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-runs a strategy against a recorded session",
+	Long: `Runs an interval strategy against the market data recorded by the record command, using a virtual clock
+	and a simulated exchange, then prints the orders and the final balances.`,
+	Run: executeReplayCommand,
+}
+
+func init() {
+	RootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().StringVar(&replayFlags.Strategy, "strategy", "", "name of the strategy to run")
+	replayCmd.Flags().StringVar(&replayFlags.Records, "records", "records", "recorded events file or directory")
+	replayCmd.Flags().StringVar(&replayFlags.Exchange, "exchange", "", "name of the recorded exchange")
+	replayCmd.Flags().StringVar(&replayFlags.From, "from", "", "start of the replay (YYYY-MM-DD or RFC3339), defaults to the first event")
+	replayCmd.Flags().StringVar(&replayFlags.To, "to", "", "end of the replay (YYYY-MM-DD or RFC3339), defaults to the last event")
+	replayCmd.Flags().Float64Var(&replayFlags.Speed, "speed", 0, "speed of the replay: 1 for real time, N for N times faster, 0 for as fast as possible")
+	replayCmd.Flags().StringToStringVar(&replayFlags.Balances, "balance", nil, "initial balances of the simulated exchange (e.g. BTC=1,ETH=0)")
+	replayCmd.Flags().Float64Var(&replayFlags.MakerFee, "maker-fee", 0, "fee of maker trades, as a fraction of the total (e.g. 0.001)")
+	replayCmd.Flags().Float64Var(&replayFlags.TakerFee, "taker-fee", 0, "fee of taker trades, as a fraction of the total (e.g. 0.001)")
+	replayCmd.MarkFlagRequired("strategy")
+	replayCmd.MarkFlagRequired("exchange")
+}
+
+func executeReplayCommand(cmd *cobra.Command, args []string) {
+	s, exists := strategies.GetStrategy(replayFlags.Strategy)
+	if !exists {
+		fmt.Printf("Strategy %s does not exist\n", replayFlags.Strategy)
+		return
+	}
+	strategy, isInterval := s.(strategies.IntervalStrategy)
+	if !isInterval {
+		fmt.Printf("Strategy %s is not an interval strategy, cannot replay it\n", replayFlags.Strategy)
+		return
+	}
+
+	from, err := parseBacktestDate(replayFlags.From)
+	if err != nil {
+		fmt.Println("Invalid start date:", err)
+		return
+	}
+	to, err := parseBacktestDate(replayFlags.To)
+	if err != nil {
+		fmt.Println("Invalid end date:", err)
+		return
+	}
+
+	balances := make(map[string]decimal.Decimal, len(replayFlags.Balances))
+	for currency, amount := range replayFlags.Balances {
+		balances[currency], err = decimal.NewFromString(amount)
+		if err != nil {
+			fmt.Printf("Invalid %s balance: %s\n", currency, err)
+			return
+		}
+	}
+
+	fmt.Print("Loading events ... ")
+	events, err := recorder.LoadEvents(replayFlags.Records, from, to)
+	if err != nil {
+		fmt.Print("Cannot load recorded events")
+		if GlobalFlags.Verbose > 0 {
+			fmt.Printf(": %s", err.Error())
+		}
+		fmt.Println()
+		return
+	}
+	fmt.Println("DONE")
+
+	replay := recorder.NewReplayWrapper(replayFlags.Exchange, events)
+	if replay.Done() {
+		fmt.Printf("No events recorded for %s\n", replayFlags.Exchange)
+		return
+	}
+	replay.SetSpeed(replayFlags.Speed)
+	replay.SetFees(replayFlags.MakerFee, replayFlags.TakerFee)
+	markets, _ := replay.GetMarkets()
+
+	fmt.Println("Replaying from", replay.Now().Format("2006-01-02 15:04:05"), "... ")
+	simulator, err := replay.Run(strategy, markets, balances)
+	if err != nil {
+		fmt.Println("Replay stopped:", err)
+	}
+	if simulator == nil {
+		return
+	}
+	fmt.Println("Replay ended at", replay.Now().Format("2006-01-02 15:04:05"))
+
+	fmt.Println("Orders:")
+	for _, order := range simulator.OrderHistory() {
+		fmt.Println("  ", order)
+	}
+	fmt.Println("Final Balances:")
+	for currency := range balances {
+		balance, _ := simulator.GetBalance(currency)
+		fmt.Printf("   %s: %s\n", currency, balance)
+	}
+}
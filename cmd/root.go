@@ -16,9 +16,12 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
@@ -37,6 +40,24 @@ var RootCmd = &cobra.Command{
 
 var signals chan os.Signal
 
+// shutdown contains the cancel func of the context of the running command, if it supports graceful shutdown.
+var shutdown = struct {
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+}{}
+
+// shutdownContext returns a context which is done when CTRL-C (or SIGTERM) is received,
+// enabling the graceful shutdown of the running command.
+//
+//     Without it, the command exits immediately. A second CTRL-C always forces the exit.
+func shutdownContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdown.mutex.Lock()
+	shutdown.cancel = cancel
+	shutdown.mutex.Unlock()
+	return ctx
+}
+
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -46,13 +67,22 @@ func Execute() {
 }
 
 func init() {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, os.Interrupt)
+	signals = make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-signals
 		signal.Stop(signals)
 		fmt.Println()
+
+		shutdown.mutex.Lock()
+		cancel := shutdown.cancel
+		shutdown.mutex.Unlock()
+		if cancel != nil {
+			fmt.Println("CTRL-C command received. Shutting down (press CTRL-C again to force exit)...")
+			cancel()
+			return
+		}
 		fmt.Println("CTRL-C command received. Exiting...")
 		os.Exit(0)
 	}()
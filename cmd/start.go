@@ -16,6 +16,7 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -43,6 +44,7 @@ func init() {
 	RootCmd.AddCommand(startCmd)
 
 	startCmd.Flags().BoolVarP(&startFlags.Simulate, "simulate", "s", false, "Simulates the trades instead of actually doing them")
+	startCmd.Flags().BoolVar(&startFlags.CancelOrders, "cancel-orders", false, "Cancels the open orders on the traded markets before exiting")
 }
 
 func initConfigs() error {
@@ -76,35 +78,177 @@ func executeStartCommand(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println("DONE")
 
-	fmt.Print("Getting markets cold info ...")
+	fmt.Print("Getting markets cold info ... ")
+	listings := listExchangeMarkets(wrappers)
+	var markets []*environment.Market
+	var refused []string
 	for _, strategyConf := range BotConfig.Strategies {
-		mkts := make([]*environment.Market, len(strategyConf.Markets))
-		for i, mkt := range strategyConf.Markets {
-			currencies := strings.SplitN(mkt.Name, "-", 2)
-			mkts[i] = &environment.Market{
-				Name:           mkt.Name,
-				BaseCurrency:   currencies[0],
-				MarketCurrency: currencies[1],
+		mkts := make([]*environment.Market, 0, len(strategyConf.Markets))
+		for _, mkt := range strategyConf.Markets {
+			market, problems := bindMarket(mkt, listings)
+			for _, problem := range problems {
+				fmt.Printf("\n  WARNING %s: %s", mkt.Name, problem)
 			}
-
-			mkts[i].ExchangeNames = make(map[string]string, len(wrappers))
-
-			for _, exName := range mkt.Exchanges {
-				mkts[i].ExchangeNames[exName.Name] = exName.MarketName
+			if market == nil || len(market.ExchangeNames) == 0 {
+				fmt.Printf("\n  WARNING %s: no binding resolved, market skipped", mkt.Name)
+				continue
 			}
+			mkts = append(mkts, market)
 		}
+		if len(mkts) == 0 && len(strategyConf.Markets) > 0 {
+			refused = append(refused, strategyConf.Strategy)
+			continue
+		}
+		markets = append(markets, mkts...)
 		err := strategies.MatchWithMarkets(strategyConf.Strategy, mkts)
 		if err != nil {
-			fmt.Println("Cannot add tactic : ", err)
+			fmt.Print("\n  Cannot add tactic : ", err)
 		}
 	}
 	fmt.Println("DONE")
+	for _, strategy := range refused {
+		fmt.Printf("Cannot add tactic %s : none of its markets is resolved\n", strategy)
+	}
 
 	fmt.Println("Starting bot ... ")
-	executeBotLoop(wrappers)
+	executeBotLoop(shutdownContext(), wrappers)
+
+	fmt.Print("Closing feeds ... ")
+	for _, wrapper := range wrappers {
+		if wrapper == nil || !wrapper.Capabilities().Websocket {
+			continue
+		}
+		if err := wrapper.FeedDisconnect(); err != nil && GlobalFlags.Verbose > 0 {
+			fmt.Printf("\n  %s: %s", wrapper.Name(), err)
+		}
+	}
+	fmt.Println("DONE")
+
+	if startFlags.CancelOrders {
+		fmt.Print("Cancelling open orders ... ")
+		for i, wrapper := range wrappers {
+			if wrapper == nil || !wrapper.Capabilities().LimitOrders {
+				continue
+			}
+			for _, market := range markets {
+				if _, exists := market.ExchangeNames[BotConfig.ExchangeConfigs[i].ExchangeName]; !exists {
+					continue
+				}
+				if err := cancelOpenOrders(wrapper, market); err != nil && GlobalFlags.Verbose > 0 {
+					fmt.Printf("\n  %s %s: %s", wrapper.Name(), market.Name, err)
+				}
+			}
+		}
+		fmt.Println("DONE")
+	}
 	fmt.Println("EXIT, good bye :)")
 }
 
-func executeBotLoop(wrappers []exchanges.ExchangeWrapper) {
-	strategies.ApplyAllStrategies(wrappers)
+// executeBotLoop applies the strategies until the context is done and all of them are torn down.
+func executeBotLoop(ctx context.Context, wrappers []exchanges.ExchangeWrapper) {
+	strategies.ApplyAllStrategies(ctx, wrappers)
+}
+
+// marketListing contains the markets listed by an exchange: the native symbols by canonical market name.
+type marketListing map[string]string
+
+// listExchangeMarkets gets the markets listed by the configured exchanges, by exchange name.
+//
+//     The exchanges whose markets cannot be listed are missing.
+func listExchangeMarkets(wrappers []exchanges.ExchangeWrapper) map[string]marketListing {
+	listings := make(map[string]marketListing, len(wrappers))
+	for i, wrapper := range wrappers {
+		if wrapper == nil {
+			continue
+		}
+		exchangeName := BotConfig.ExchangeConfigs[i].ExchangeName
+		listedMarkets, err := wrapper.GetMarkets()
+		if err != nil {
+			fmt.Printf("\n  WARNING %s: cannot list markets, bindings will not be resolved nor checked", exchangeName)
+			if GlobalFlags.Verbose > 0 {
+				fmt.Printf(" (%s)", err)
+			}
+			continue
+		}
+
+		listing := make(marketListing, len(listedMarkets))
+		for _, market := range listedMarkets {
+			for _, symbol := range market.ExchangeNames {
+				listing[strings.ToUpper(market.Name)] = symbol
+			}
+		}
+		listings[exchangeName] = listing
+	}
+	return listings
+}
+
+// lists checks if a native symbol is listed.
+func (listing marketListing) lists(symbol string) bool {
+	for _, listed := range listing {
+		if listed == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// bindMarket creates a configured market, resolving its names on the bound exchanges using their listings,
+// or binding it to every exchange listing it when no binding is configured.
+//
+//     The bindings having problems are left out of the market, nil is returned if the market name is invalid.
+func bindMarket(conf environment.MarketConfig, listings map[string]marketListing) (*environment.Market, []string) {
+	baseCurrency, marketCurrency, err := environment.ParseMarketName(conf.Name)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	market := &environment.Market{
+		Name:           conf.Name,
+		BaseCurrency:   baseCurrency,
+		MarketCurrency: marketCurrency,
+		ExchangeNames:  make(map[string]string, len(conf.Exchanges)),
+	}
+	canonicalName := environment.MarketName(baseCurrency, marketCurrency)
+
+	bindings := conf.Exchanges
+	if len(bindings) == 0 {
+		for i := range BotConfig.ExchangeConfigs {
+			exchangeName := BotConfig.ExchangeConfigs[i].ExchangeName
+			if _, listed := listings[exchangeName][canonicalName]; listed {
+				bindings = append(bindings, environment.ExchangeBindingsConfig{Name: exchangeName})
+			}
+		}
+		if len(bindings) == 0 {
+			return market, []string{"not listed by any configured exchange"}
+		}
+	}
+
+	var problems []string
+	for _, binding := range bindings {
+		listing, listed := listings[binding.Name]
+		switch {
+		case binding.MarketName != "" && listed && !listing.lists(binding.MarketName):
+			problems = append(problems, fmt.Sprintf("%s is not listed by %s, binding skipped", binding.MarketName, binding.Name))
+		case binding.MarketName != "":
+			market.ExchangeNames[binding.Name] = binding.MarketName
+		case listing[canonicalName] != "":
+			market.ExchangeNames[binding.Name] = listing[canonicalName]
+		default:
+			problems = append(problems, fmt.Sprintf("cannot resolve the market name on %s, binding skipped", binding.Name))
+		}
+	}
+	return market, problems
+}
+
+// cancelOpenOrders cancels the open orders of a market on a wrapper.
+func cancelOpenOrders(wrapper exchanges.ExchangeWrapper, market *environment.Market) error {
+	orders, err := wrapper.GetOpenOrders(market)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if err := wrapper.CancelOrder(market, order.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package environment
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -14,6 +15,10 @@ type CandleStick struct {
 	Close  decimal.Decimal //Represents the last value of the candle period.
 	Low    decimal.Decimal //Represents the lowest value obtained during candle period.
 	Volume decimal.Decimal //Represents the volume of trades during the candle period.
+
+	OpenTime   time.Time //Represents the time the candle period starts.
+	CloseTime  time.Time //Represents the last instant of the candle period.
+	TradeCount int64     //[optional] Represents the number of trades during the candle period.
 }
 
 // String returns the string representation of the object.
@@ -27,10 +32,13 @@ func (cs CandleStick) String() string {
 		color = "Neutral"
 	}
 	ret := fmt.Sprintln(color, "Candle")
+	ret += fmt.Sprintln("Open Time:", cs.OpenTime.Format(time.RFC3339))
+	ret += fmt.Sprintln("Close Time:", cs.CloseTime.Format(time.RFC3339))
 	ret += fmt.Sprintln("High:", cs.High)
 	ret += fmt.Sprintln("Open:", cs.Open)
 	ret += fmt.Sprintln("Close:", cs.Close)
 	ret += fmt.Sprintln("Low:", cs.Low)
 	ret += fmt.Sprintln("Volume:", cs.Volume)
+	ret += fmt.Sprintln("Trades:", cs.TradeCount)
 	return strings.TrimSpace(ret)
 }
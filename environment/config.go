@@ -16,6 +16,8 @@
 package environment
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
@@ -27,7 +29,24 @@ type ExchangeConfig struct {
 	PublicKey        string                     `yaml:"public_key"`        // Represents the public key used to connect to Exchange API.
 	SecretKey        string                     `yaml:"secret_key"`        // Represents the secret key used to connect to Exchange API.
 	DepositAddresses map[string]string          `yaml:"deposit_addresses"` // Represents the bindings between coins and deposit address on the exchange.
+	Timeout          time.Duration              `yaml:"timeout"`           // Maximum duration of each call to the exchange API (e.g. 10s), no limit if zero.
+	RateLimit        RateLimitConfig            `yaml:"rate_limit"`        // Represents the request budget of the exchange API and the retry policy of the calls, defaults of the exchange if empty.
+	FeedMaxAge       time.Duration              `yaml:"feed_max_age"`      // Maximum age of the data got from the websocket feed (e.g. 30s), no limit if zero.
+	FeedFallback     bool                       `yaml:"feed_fallback"`     // If true, the data missing from the websocket feed or older than the maximum age is got from the REST API instead of failing.
 	FakeBalances     map[string]decimal.Decimal `yaml:"fake_balances"`     // Used only in simulation mode, fake starting balance [coin:balance].
+	FakeLatency      time.Duration              `yaml:"fake_latency"`      // Used only in simulation mode, delay before executing each order (e.g. 200ms).
+	FakeSlippage     decimal.Decimal            `yaml:"fake_slippage"`     // Used only in simulation mode, extra slippage of taker fills as a fraction of the price (e.g. 0.001).
+}
+
+// RateLimitConfig represents the request budget of an exchange API and the retry policy of the calls to it.
+//
+//     The zero fields take the default values of the exchange.
+type RateLimitConfig struct {
+	Weight     int           `yaml:"weight"`      // Represents the maximum weight of the calls in each interval, no limit if negative.
+	Interval   time.Duration `yaml:"interval"`    // Represents the interval over which the budget is refilled (e.g. 1m).
+	Retries    int           `yaml:"retries"`     // Represents the maximum retries of the idempotent calls failing with a transient error, no retries if negative.
+	Backoff    time.Duration `yaml:"backoff"`     // Represents the delay before the first retry (e.g. 500ms), doubled at each retry.
+	MaxBackoff time.Duration `yaml:"max_backoff"` // Represents the maximum delay between retries (e.g. 10s).
 }
 
 // StrategyConfig contains where a strategy will be applied in the specified exchange.
@@ -44,8 +63,8 @@ type MarketConfig struct {
 
 // ExchangeBindingsConfig represents the binding of market names between bot notation and exchange ticker.
 type ExchangeBindingsConfig struct {
-	Name       string `yaml:"exchange"`    // Represents the name of the exchange.
-	MarketName string `yaml:"market_name"` // Represents the name of the market as seen from the exchange.
+	Name       string `yaml:"exchange"`              // Represents the name of the exchange.
+	MarketName string `yaml:"market_name,omitempty"` // Represents the name of the market as seen from the exchange, resolved from the markets listed by the exchange if empty.
 }
 
 // TelegramConfig represents telegram notification channel config
@@ -0,0 +1,124 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+//Interval represents the period of a candle (e.g. 1m, 4h, 1d).
+type Interval string
+
+//Supported candle intervals.
+const (
+	Interval1m  Interval = "1m"
+	Interval3m  Interval = "3m"
+	Interval5m  Interval = "5m"
+	Interval15m Interval = "15m"
+	Interval30m Interval = "30m"
+	Interval1h  Interval = "1h"
+	Interval2h  Interval = "2h"
+	Interval4h  Interval = "4h"
+	Interval6h  Interval = "6h"
+	Interval8h  Interval = "8h"
+	Interval12h Interval = "12h"
+	Interval1d  Interval = "1d"
+	Interval3d  Interval = "3d"
+	Interval1w  Interval = "1w"
+)
+
+// intervalDurations contains the duration of every supported interval.
+var intervalDurations = map[Interval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval3m:  3 * time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval30m: 30 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval2h:  2 * time.Hour,
+	Interval4h:  4 * time.Hour,
+	Interval6h:  6 * time.Hour,
+	Interval8h:  8 * time.Hour,
+	Interval12h: 12 * time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval3d:  3 * 24 * time.Hour,
+	Interval1w:  7 * 24 * time.Hour,
+}
+
+// ParseInterval parses an interval from its string representation (e.g. "15m").
+func ParseInterval(s string) (Interval, error) {
+	interval := Interval(s)
+	if !interval.IsValid() {
+		return "", fmt.Errorf("Unknown candle interval %q", s)
+	}
+	return interval, nil
+}
+
+// IsValid returns true if the interval is a supported one.
+func (interval Interval) IsValid() bool {
+	_, valid := intervalDurations[interval]
+	return valid
+}
+
+// Duration gets the duration of the interval, 0 if the interval is not supported.
+func (interval Interval) Duration() time.Duration {
+	return intervalDurations[interval]
+}
+
+// String returns the string representation of the object.
+func (interval Interval) String() string {
+	return string(interval)
+}
+
+// ResampleCandles aggregates candles into candles of a coarser interval, aligned to the
+// interval boundaries in UTC.
+//
+//     Candles must be sorted and have the open time, the first and the last resulting candles
+//     can cover only a part of their period.
+func ResampleCandles(candles []CandleStick, interval Interval) ([]CandleStick, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return nil, fmt.Errorf("Unknown candle interval %q", interval)
+	}
+
+	ret := make([]CandleStick, 0, len(candles))
+	for _, candle := range candles {
+		if candle.OpenTime.IsZero() {
+			return nil, errors.New("Cannot resample candles without open time")
+		}
+
+		openTime := candle.OpenTime.Truncate(step)
+		last := len(ret) - 1
+		if last < 0 || !ret[last].OpenTime.Equal(openTime) {
+			candle.OpenTime = openTime
+			candle.CloseTime = openTime.Add(step - time.Millisecond)
+			ret = append(ret, candle)
+			continue
+		}
+
+		ret[last].High = decimal.Max(ret[last].High, candle.High)
+		ret[last].Low = decimal.Min(ret[last].Low, candle.Low)
+		ret[last].Close = candle.Close
+		ret[last].Volume = ret[last].Volume.Add(candle.Volume)
+		ret[last].TradeCount += candle.TradeCount
+	}
+
+	return ret, nil
+}
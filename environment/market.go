@@ -18,6 +18,7 @@ package environment
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/shopspring/decimal"
 )
@@ -33,15 +34,30 @@ type Ticker struct {
 //Market represents the environment the bot is trading in.
 type Market struct {
 	Name           string            `json:"name,required"`            //Represents the name of the market as defined in general (e.g. ETH-BTC).
-	BaseCurrency   string            `json:"baseCurrency,omitempty"`   //Represents the base currency of the market.
-	MarketCurrency string            `json:"marketCurrency,omitempty"` //Represents the currency to exchange by using base currency.
+	BaseCurrency   string            `json:"baseCurrency,omitempty"`   //Represents the base currency of the market, the asset bought and sold (e.g. ETH in ETH-BTC).
+	MarketCurrency string            `json:"marketCurrency,omitempty"` //Represents the quote currency of the market, prices and fees are expressed in (e.g. BTC in ETH-BTC).
 	ExchangeNames  map[string]string `json:"-"`                        // Represents the various names of the market on various exchanges.
+	Rules          TradingRules      `json:"rules"`                    //Represents the trading rules of the market, as got from the exchange.
 }
 
 func (m Market) String() string {
 	return fmt.Sprintf("%s-%s", m.BaseCurrency, m.MarketCurrency)
 }
 
+//MarketName gets the canonical name of a market (BASE-QUOTE, e.g. ETH-BTC) from its currencies.
+func MarketName(baseCurrency string, marketCurrency string) string {
+	return strings.ToUpper(baseCurrency) + "-" + strings.ToUpper(marketCurrency)
+}
+
+//ParseMarketName gets the currencies of a market from its canonical name (BASE-QUOTE, e.g. ETH-BTC).
+func ParseMarketName(name string) (baseCurrency string, marketCurrency string, err error) {
+	currencies := strings.SplitN(name, "-", 2)
+	if len(currencies) != 2 || currencies[0] == "" || currencies[1] == "" {
+		return "", "", fmt.Errorf("Invalid market name %q: must be in the form BASE-QUOTE", name)
+	}
+	return currencies[0], currencies[1], nil
+}
+
 //MarketSummary represents the summary data of a market.
 type MarketSummary struct {
 	High   decimal.Decimal `json:"high,required"`   //Represents the 24 hours maximum peak of this market.
@@ -0,0 +1,41 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package environment
+
+import "testing"
+
+func TestParseMarketName(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantBase   string
+		wantMarket string
+		wantErr    bool
+	}{
+		{"ETH-BTC", "ETH", "BTC", false},
+		{"BTC-USDT", "BTC", "USDT", false},
+		{"ETHBTC", "", "", true},
+		{"-BTC", "", "", true},
+		{"ETH-", "", "", true},
+	}
+
+	for _, test := range tests {
+		base, market, err := ParseMarketName(test.name)
+		if base != test.wantBase || market != test.wantMarket || (err != nil) != test.wantErr {
+			t.Errorf("ParseMarketName(%s) = %s, %s, %v, want %s, %s, error %v", test.name, base, market, err,
+				test.wantBase, test.wantMarket, test.wantErr)
+		}
+	}
+}
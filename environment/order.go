@@ -58,3 +58,95 @@ type Order struct {
 func (order Order) Total() decimal.Decimal {
 	return order.Quantity.Mul(order.Value)
 }
+
+//Trade represents a public trade executed on a market.
+type Trade struct {
+	ID        string          `json:"id,omitempty"` //[optional] Trade ID as seen in exchange archives.
+	Price     decimal.Decimal `json:"price"`        //Price of the trade.
+	Quantity  decimal.Decimal `json:"quantity"`     //Quantity of coins traded.
+	Side      OrderType       `json:"side"`         //Side of the taker: Bid (1) for a buy, Ask (0) for a sell.
+	Timestamp time.Time       `json:"timestamp"`    //The time of the trade (as got from the exchange).
+}
+
+//OrderStatus is an enum {Unknown, Open, PartiallyFilled, Filled, Cancelled, Rejected}
+type OrderStatus int16
+
+const (
+	//OrderStatusUnknown Represents an order whose state cannot be determined.
+	OrderStatusUnknown OrderStatus = iota
+	//OrderStatusOpen Represents an order resting on the book, not yet filled.
+	OrderStatusOpen
+	//OrderStatusPartiallyFilled Represents an order resting on the book, partially filled.
+	OrderStatusPartiallyFilled
+	//OrderStatusFilled Represents a completely filled order.
+	OrderStatusFilled
+	//OrderStatusCancelled Represents an order cancelled before being completely filled.
+	OrderStatusCancelled
+	//OrderStatusRejected Represents an order rejected (or expired) by the exchange.
+	OrderStatusRejected
+)
+
+// String returns the string representation of the object.
+func (status OrderStatus) String() string {
+	switch status {
+	case OrderStatusOpen:
+		return "Open"
+	case OrderStatusPartiallyFilled:
+		return "PartiallyFilled"
+	case OrderStatusFilled:
+		return "Filled"
+	case OrderStatusCancelled:
+		return "Cancelled"
+	case OrderStatusRejected:
+		return "Rejected"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsOpen returns true if the order is still resting on the book.
+func (status OrderStatus) IsOpen() bool {
+	return status == OrderStatusOpen || status == OrderStatusPartiallyFilled
+}
+
+//OrderInfo represents the state of an order placed on an exchange.
+type OrderInfo struct {
+	ID               string          //Order ID, as returned by the order placing functions.
+	Market           *Market         //Market where the order has been placed.
+	Side             OrderType       //Ask for a sell order, Bid for a buy order.
+	Status           OrderStatus     //Current status of the order.
+	Price            decimal.Decimal //Limit price of the order (zero for market orders).
+	Quantity         decimal.Decimal //Requested quantity of the order.
+	FilledQuantity   decimal.Decimal //Quantity already executed.
+	AverageFillPrice decimal.Decimal //Average price of the executed quantity.
+	Fee              decimal.Decimal //Fees paid for the executed quantity.
+	FeeCurrency      string          //[optional] Currency in which fees have been paid.
+	Timestamp        time.Time       //[optional] The creation time of the order (as got from the exchange).
+}
+
+//RemainingQuantity returns the quantity still to be executed.
+func (info OrderInfo) RemainingQuantity() decimal.Decimal {
+	return info.Quantity.Sub(info.FilledQuantity)
+}
+
+// String returns the string representation of the object.
+func (info OrderInfo) String() string {
+	side := "BUY"
+	if info.Side == Ask {
+		side = "SELL"
+	}
+	return fmt.Sprintf("%s %s %s@%s [%s] filled=%s avg=%s fee=%s %s",
+		info.ID, side, info.Quantity, info.Price, info.Status,
+		info.FilledQuantity, info.AverageFillPrice, info.Fee, info.FeeCurrency)
+}
+
+//OrderFill represents a single execution of an order.
+type OrderFill struct {
+	OrderID     string          //ID of the executed order.
+	Price       decimal.Decimal //Price of the execution.
+	Quantity    decimal.Decimal //Quantity executed.
+	Fee         decimal.Decimal //Fee paid for the execution.
+	FeeCurrency string          //Currency in which the fee has been paid.
+	Maker       bool            //True if the order was resting on the book, false if it took liquidity.
+	Timestamp   time.Time       //The time of the execution.
+}
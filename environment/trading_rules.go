@@ -0,0 +1,79 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package environment
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidOrder is the error representing when an order cannot be rounded to a valid one
+// for the trading rules of its market.
+var ErrInvalidOrder = errors.New("Invalid order")
+
+// TradingRules represents the constraints of an exchange on the orders of a market.
+//
+//     Zero values represent constraints not known or not enforced by the exchange.
+type TradingRules struct {
+	TickSize    decimal.Decimal `json:"tickSize"`    //Represents the step of the limit price.
+	StepSize    decimal.Decimal `json:"stepSize"`    //Represents the step of the quantity.
+	MinQuantity decimal.Decimal `json:"minQuantity"` //Represents the minimum quantity of an order.
+	MaxQuantity decimal.Decimal `json:"maxQuantity"` //Represents the maximum quantity of an order.
+	MinNotional decimal.Decimal `json:"minNotional"` //Represents the minimum value (quantity * price) of an order.
+}
+
+// RoundOrder rounds an order to the nearest valid one not worse than it, or explains why it cannot be placed.
+//
+//     The side is Bid for a buy order and Ask for a sell order: the price is rounded to a tick down
+//     for a buy and up for a sell, so that the limit is never crossed, while the quantity is always
+//     rounded down to a step. Use a zero price for market orders, whose notional value cannot be checked.
+func (rules TradingRules) RoundOrder(side OrderType, quantity decimal.Decimal, price decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	if !quantity.IsPositive() {
+		return quantity, price, fmt.Errorf("%w: quantity must be > 0", ErrInvalidOrder)
+	}
+	if rules.StepSize.IsPositive() {
+		quantity = quantity.Div(rules.StepSize).Floor().Mul(rules.StepSize)
+		if !quantity.IsPositive() {
+			return quantity, price, fmt.Errorf("%w: quantity is lower than the step size %s", ErrInvalidOrder, rules.StepSize)
+		}
+	}
+	if rules.TickSize.IsPositive() && price.IsPositive() {
+		ticks := price.Div(rules.TickSize)
+		if side == Ask {
+			ticks = ticks.Ceil()
+		} else {
+			ticks = ticks.Floor()
+		}
+		price = ticks.Mul(rules.TickSize)
+		if !price.IsPositive() {
+			return quantity, price, fmt.Errorf("%w: price is lower than the tick size %s", ErrInvalidOrder, rules.TickSize)
+		}
+	}
+
+	if quantity.LessThan(rules.MinQuantity) {
+		return quantity, price, fmt.Errorf("%w: quantity %s is lower than the minimum %s", ErrInvalidOrder, quantity, rules.MinQuantity)
+	}
+	if rules.MaxQuantity.IsPositive() && quantity.GreaterThan(rules.MaxQuantity) {
+		return quantity, price, fmt.Errorf("%w: quantity %s is greater than the maximum %s", ErrInvalidOrder, quantity, rules.MaxQuantity)
+	}
+	if price.IsPositive() && quantity.Mul(price).LessThan(rules.MinNotional) {
+		return quantity, price, fmt.Errorf("%w: value %s is lower than the minimum %s", ErrInvalidOrder, quantity.Mul(price), rules.MinNotional)
+	}
+
+	return quantity, price, nil
+}
@@ -16,6 +16,7 @@
 package examples
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -43,7 +44,7 @@ var telegram_enabled = true
 var Watch5Sec = strategies.IntervalStrategy{
 	Model: strategies.StrategyModel{
 		Name: "Watch5Sec",
-		Setup: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		Setup: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
 			chatGroup = &tb.Chat{
 				ID: bot.BotConfig.TelegramConfig.GroupID,
 			}
@@ -60,7 +61,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 			}
 			return nil
 		},
-		OnUpdate: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		OnUpdate: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
 			wr := wrappers[0]
 			for i, mk := range markets {
 				mkSummary, err := wr.GetMarketSummary(markets[i])
@@ -76,7 +77,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 					return err
 				}
 
-				candle, err := wr.GetCandles(mk, "1d")
+				candle, err := wr.GetCandles(mk, environment.Interval1d)
 				if err != nil {
 					return err
 				}
@@ -146,7 +147,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 			}
 			logrus.Info("Top Gainers:")
 			for _, pc := range prChange.GetTopGainersByMarket(5, "BUSD") {
-				action, err := EvaluateSymbol(wr, pc, "4h")
+				action, err := EvaluateSymbol(wr, pc, environment.Interval4h)
 				if err != nil {
 					return nil
 				}
@@ -154,7 +155,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 			}
 			logrus.Info("Top Losers:")
 			for _, pc := range prChange.GetTopLosersByMarket(5, "BUSD") {
-				action, err := EvaluateSymbol(wr, pc, "4h")
+				action, err := EvaluateSymbol(wr, pc, environment.Interval4h)
 				if err != nil {
 					return nil
 				}
@@ -165,7 +166,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 		OnError: func(err error) {
 			fmt.Println(err)
 		},
-		TearDown: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		TearDown: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
 			fmt.Println("Watch5Sec exited")
 			return nil
 		},
@@ -173,7 +174,7 @@ var Watch5Sec = strategies.IntervalStrategy{
 	Interval: time.Minute * 1,
 }
 
-func EvaluateSymbol(wr exchanges.ExchangeWrapper, symbol environment.PriceChangeStat, interval string) (string, error) {
+func EvaluateSymbol(wr exchanges.ExchangeWrapper, symbol environment.PriceChangeStat, interval environment.Interval) (string, error) {
 	action := "NOTHING"
 	candle, err := wr.GetCandles(&symbol.Market, interval)
 	if err != nil {
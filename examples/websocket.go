@@ -16,6 +16,8 @@
 package examples
 
 import (
+	"context"
+
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/saniales/golang-crypto-trading-bot/exchanges"
 	"github.com/saniales/golang-crypto-trading-bot/strategies"
@@ -26,7 +28,7 @@ import (
 var Websocket = strategies.WebsocketStrategy{
 	Model: strategies.StrategyModel{
 		Name: "Websocket",
-		Setup: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		Setup: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
 			for _, wrapper := range wrappers {
 				err := wrapper.FeedConnect(markets)
 				if err == exchanges.ErrWebsocketNotSupported || err == nil {
@@ -36,11 +38,18 @@ var Websocket = strategies.WebsocketStrategy{
 			}
 			return nil
 		},
-		OnUpdate: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		OnUpdate: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
 			// do something
 			return nil
 		},
-		TearDown: func(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+		TearDown: func(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) error {
+			for _, wrapper := range wrappers {
+				err := wrapper.FeedDisconnect()
+				if err == exchanges.ErrWebsocketNotSupported || err == nil {
+					continue
+				}
+				return err
+			}
 			return nil
 		},
 		OnError: func(err error) {
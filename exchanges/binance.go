@@ -19,7 +19,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
+	"sync"
+	"time"
 
 	"github.com/adshao/go-binance/v2"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
@@ -33,18 +34,26 @@ type BinanceWrapper struct {
 	summaries        *SummaryCache
 	candles          *CandlesCache
 	orderbook        *OrderbookCache
+	tradingRules     *TradingRulesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
 	depositAddresses map[string]string
 	websocketOn      bool
+	feedStop         chan struct{} // Closed to stop the websocket connections of the feed.
+	feedSettings     FeedSettings
 }
 
 // NewBinanceWrapper creates a generic wrapper of the binance API.
 func NewBinanceWrapper(publicKey string, secretKey string, depositAddresses map[string]string) ExchangeWrapper {
+	meter := newRequestMeter()
 	client := binance.NewClient(publicKey, secretKey)
+	client.HTTPClient = meter.httpClient()
 	return &BinanceWrapper{
 		api:              client,
 		summaries:        NewSummaryCache(),
 		candles:          NewCandlesCache(),
 		orderbook:        NewOrderbookCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            meter,
 		depositAddresses: depositAddresses,
 		websocketOn:      false,
 	}
@@ -59,9 +68,39 @@ func (wrapper *BinanceWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *BinanceWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// SetFeedSettings sets how the data got from the websocket feed is served.
+func (wrapper *BinanceWrapper) SetFeedSettings(settings FeedSettings) {
+	wrapper.feedSettings = settings
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *BinanceWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		Websocket:        true,
+		Candles:          true,
+		OrderBook:        true,
+		Trades:           true,
+		LimitOrders:      true,
+		MarketOrders:     true,
+		Balance:          true,
+		Withdraw:         true,
+		PriceChangeStats: true,
+	}
+}
+
 // GetMarkets Gets all the markets info.
 func (wrapper *BinanceWrapper) GetMarkets() ([]*environment.Market, error) {
-	binanceExchangeInfo, err := wrapper.api.NewExchangeInfoService().Do(context.Background())
+	return wrapper.GetMarketsContext(context.Background())
+}
+
+// GetMarketsContext Gets all the markets info, until the context is done.
+func (wrapper *BinanceWrapper) GetMarketsContext(ctx context.Context) ([]*environment.Market, error) {
+	binanceExchangeInfo, err := wrapper.api.NewExchangeInfoService().Do(ctx)
 
 	if err != nil {
 		return nil, err
@@ -70,38 +109,65 @@ func (wrapper *BinanceWrapper) GetMarkets() ([]*environment.Market, error) {
 	ret := make([]*environment.Market, len(binanceExchangeInfo.Symbols))
 
 	for i, market := range binanceExchangeInfo.Symbols {
-		ret[i] = &environment.Market{
-			Name:           market.Symbol,
-			BaseCurrency:   market.BaseAsset,
-			MarketCurrency: market.QuoteAsset,
-		}
+		ret[i] = listedMarket(wrapper, market.Symbol, market.BaseAsset, market.QuoteAsset)
+		ret[i].Rules = convertBinanceTradingRules(market)
+		wrapper.tradingRules.Set(market.Symbol, ret[i].Rules)
 	}
+	registerMarkets(wrapper, ret)
 
 	return ret, nil
 }
 
-func getCurrencyMap(symbol string) (paramsMap map[string]string) {
+// convertBinanceTradingRules converts the filters of a binance symbol to environment.TradingRules.
+func convertBinanceTradingRules(symbol binance.Symbol) environment.TradingRules {
+	var rules environment.TradingRules
+	if filter := symbol.PriceFilter(); filter != nil {
+		rules.TickSize, _ = decimal.NewFromString(filter.TickSize)
+	}
+	if filter := symbol.LotSizeFilter(); filter != nil {
+		rules.StepSize, _ = decimal.NewFromString(filter.StepSize)
+		rules.MinQuantity, _ = decimal.NewFromString(filter.MinQuantity)
+		rules.MaxQuantity, _ = decimal.NewFromString(filter.MaxQuantity)
+	}
+	if filter := symbol.MinNotionalFilter(); filter != nil {
+		rules.MinNotional, _ = decimal.NewFromString(filter.MinNotional)
+	}
+	return rules
+}
 
-	var compRegEx = regexp.MustCompile(`^(?P<BaseCurrency>.*)(?P<MarketCurrency>BUSD|USDT|BTC|BNB|ETH|DOGE|SHIB)$`)
-	match := compRegEx.FindStringSubmatch(symbol)
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *BinanceWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.getTradingRules(context.Background(), market)
+}
 
-	paramsMap = make(map[string]string)
-	for i, name := range compRegEx.SubexpNames() {
-		if i > 0 && i <= len(match) {
-			paramsMap[name] = match[i]
+// getTradingRules gets the constraints of the exchange on the orders of a market, until the context is done.
+func (wrapper *BinanceWrapper) getTradingRules(ctx context.Context, market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(ctx, "GetMarkets"); err != nil {
+			return err
 		}
-	}
-	// if len(paramsMap) == 0 {
-	// 	logrus.Warnf("Failed to parse symbol %s", symbol)
-	// }
-	return paramsMap
+		_, err := wrapper.GetMarketsContext(ctx)
+		return err
+	})
 }
 
+// GetListPriceChangeStats gets the price change statistics of the markets.
 func (wrapper *BinanceWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	listPriceChange, err := wrapper.api.NewListPriceChangeStatsService().Do(context.Background())
+	return wrapper.GetListPriceChangeStatsContext(context.Background())
+}
+
+// GetListPriceChangeStatsContext gets the price change statistics of the markets, until the context is done.
+func (wrapper *BinanceWrapper) GetListPriceChangeStatsContext(ctx context.Context) (environment.ListPriceChangeStats, error) {
+	listPriceChange, err := wrapper.api.NewListPriceChangeStatsService().Do(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if !marketsListed(wrapper) {
+		if _, err := wrapper.GetMarketsContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	ret := make(environment.ListPriceChangeStats, len(listPriceChange))
 	for i, pc := range listPriceChange {
 		lastPrice, err := decimal.NewFromString(pc.LastPrice)
@@ -128,7 +194,13 @@ func (wrapper *BinanceWrapper) GetListPriceChangeStats() (environment.ListPriceC
 		if err != nil {
 			return nil, err
 		}
-		currencyMap := getCurrencyMap(pc.Symbol)
+		market := environment.Market{
+			Name:          pc.Symbol,
+			ExchangeNames: map[string]string{wrapper.Name(): pc.Symbol},
+		}
+		if listed, err := ListedMarket(wrapper, pc.Symbol); err == nil {
+			market = *listed
+		}
 		ret[i] = environment.PriceChangeStat{
 			Symbol:             pc.Symbol,
 			PriceChange:        priceChange,
@@ -137,12 +209,7 @@ func (wrapper *BinanceWrapper) GetListPriceChangeStats() (environment.ListPriceC
 			WeightedAvgPrice:   weightedAvgPrice,
 			Volume:             volume,
 			QuoteVolume:        quoteVolume,
-			Market: environment.Market{
-				Name:           pc.Symbol,
-				BaseCurrency:   currencyMap["BaseCurrency"],
-				MarketCurrency: currencyMap["MarketCurrency"],
-				ExchangeNames:  map[string]string{wrapper.Name(): pc.Symbol},
-			},
+			Market:             market,
 		}
 	}
 
@@ -151,8 +218,17 @@ func (wrapper *BinanceWrapper) GetListPriceChangeStats() (environment.ListPriceC
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
 func (wrapper *BinanceWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
-	if !wrapper.websocketOn {
-		orderbook, _, err := wrapper.orderbookFromREST(market)
+	return wrapper.GetOrderBookContext(context.Background(), market)
+}
+
+// GetOrderBookContext gets the order(ASK + BID) book of a market, until the context is done.
+func (wrapper *BinanceWrapper) GetOrderBookContext(ctx context.Context, market *environment.Market) (*environment.OrderBook, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.orderbook.Updated(market))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
+		orderbook, _, err := wrapper.orderbookFromREST(ctx, market, 0)
 		if err != nil {
 			return nil, err
 		}
@@ -169,8 +245,14 @@ func (wrapper *BinanceWrapper) GetOrderBook(market *environment.Market) (*enviro
 	return orderbook, nil
 }
 
-func (wrapper *BinanceWrapper) orderbookFromREST(market *environment.Market) (*environment.OrderBook, int64, error) {
-	binanceOrderBook, err := wrapper.api.NewDepthService().Symbol(MarketNameFor(market, wrapper)).Do(context.Background())
+// orderbookFromREST gets the order book of a market from the REST API, with the ID of its last update,
+// limited to the specified number of levels per side (default of the exchange if zero).
+func (wrapper *BinanceWrapper) orderbookFromREST(ctx context.Context, market *environment.Market, limit int) (*environment.OrderBook, int64, error) {
+	service := wrapper.api.NewDepthService().Symbol(MarketNameFor(market, wrapper))
+	if limit > 0 {
+		service = service.Limit(limit)
+	}
+	binanceOrderBook, err := service.Do(ctx)
 	if err != nil {
 		return nil, -1, err
 	}
@@ -214,9 +296,63 @@ func (wrapper *BinanceWrapper) orderbookFromREST(market *environment.Market) (*e
 	return &orderBook, binanceOrderBook.LastUpdateID, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *BinanceWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return wrapper.GetRecentTradesContext(context.Background(), market)
+}
+
+// GetRecentTradesContext gets the last public trades of a market, oldest first, until the context is done.
+func (wrapper *BinanceWrapper) GetRecentTradesContext(ctx context.Context, market *environment.Market) ([]environment.Trade, error) {
+	binanceTrades, err := wrapper.api.NewRecentTradesService().Symbol(MarketNameFor(market, wrapper)).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.Trade, len(binanceTrades))
+	for i, trade := range binanceTrades {
+		price, err := decimal.NewFromString(trade.Price)
+		if err != nil {
+			return nil, err
+		}
+		quantity, err := decimal.NewFromString(trade.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		side := environment.Bid
+		if trade.IsBuyerMaker {
+			side = environment.Ask
+		}
+
+		ret[i] = environment.Trade{
+			ID:        fmt.Sprint(trade.ID),
+			Price:     price,
+			Quantity:  quantity,
+			Side:      side,
+			Timestamp: time.Unix(0, trade.Time*int64(time.Millisecond)),
+		}
+	}
+
+	return ret, nil
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *BinanceWrapper) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeLimit).Side(binance.SideTypeBuy).Symbol(MarketNameFor(market, wrapper)).Price(fmt.Sprint(limit)).Quantity(fmt.Sprint(amount)).Do(context.Background())
+func (wrapper *BinanceWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.BuyLimitContext(context.Background(), market, amount, limit)
+}
+
+// BuyLimitContext performs a limit buy action, until the context is done.
+func (wrapper *BinanceWrapper) BuyLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	rules, err := wrapper.getTradingRules(ctx, market)
+	if err != nil {
+		return "", err
+	}
+	amount, limit, err = rules.RoundOrder(environment.Bid, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeLimit).Side(binance.SideTypeBuy).Symbol(MarketNameFor(market, wrapper)).Price(limit.String()).Quantity(amount.String()).Do(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -224,8 +360,22 @@ func (wrapper *BinanceWrapper) BuyLimit(market *environment.Market, amount float
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *BinanceWrapper) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeLimit).Side(binance.SideTypeSell).Symbol(MarketNameFor(market, wrapper)).Price(fmt.Sprint(limit)).Quantity(fmt.Sprint(amount)).Do(context.Background())
+func (wrapper *BinanceWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.SellLimitContext(context.Background(), market, amount, limit)
+}
+
+// SellLimitContext performs a limit sell action, until the context is done.
+func (wrapper *BinanceWrapper) SellLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	rules, err := wrapper.getTradingRules(ctx, market)
+	if err != nil {
+		return "", err
+	}
+	amount, limit, err = rules.RoundOrder(environment.Ask, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeLimit).Side(binance.SideTypeSell).Symbol(MarketNameFor(market, wrapper)).Price(limit.String()).Quantity(amount.String()).Do(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -233,8 +383,22 @@ func (wrapper *BinanceWrapper) SellLimit(market *environment.Market, amount floa
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *BinanceWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeMarket).Side(binance.SideTypeBuy).Symbol(MarketNameFor(market, wrapper)).Quantity(fmt.Sprint(amount)).Do(context.Background())
+func (wrapper *BinanceWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.BuyMarketContext(context.Background(), market, amount)
+}
+
+// BuyMarketContext performs a market buy action, until the context is done.
+func (wrapper *BinanceWrapper) BuyMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	rules, err := wrapper.getTradingRules(ctx, market)
+	if err != nil {
+		return "", err
+	}
+	amount, _, err = rules.RoundOrder(environment.Bid, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
+	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeMarket).Side(binance.SideTypeBuy).Symbol(MarketNameFor(market, wrapper)).Quantity(amount.String()).Do(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -243,14 +407,145 @@ func (wrapper *BinanceWrapper) BuyMarket(market *environment.Market, amount floa
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *BinanceWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeMarket).Side(binance.SideTypeSell).Symbol(MarketNameFor(market, wrapper)).Quantity(fmt.Sprint(amount)).Do(context.Background())
+func (wrapper *BinanceWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.SellMarketContext(context.Background(), market, amount)
+}
+
+// SellMarketContext performs a market sell action, until the context is done.
+func (wrapper *BinanceWrapper) SellMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	rules, err := wrapper.getTradingRules(ctx, market)
+	if err != nil {
+		return "", err
+	}
+	amount, _, err = rules.RoundOrder(environment.Ask, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
+	orderNumber, err := wrapper.api.NewCreateOrderService().Type(binance.OrderTypeMarket).Side(binance.SideTypeSell).Symbol(MarketNameFor(market, wrapper)).Quantity(amount.String()).Do(ctx)
 	if err != nil {
 		return "", err
 	}
 	return orderNumber.ClientOrderID, nil
 }
 
+// CancelOrder cancels an open order.
+func (wrapper *BinanceWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	return wrapper.CancelOrderContext(context.Background(), market, orderID)
+}
+
+// CancelOrderContext cancels an open order, until the context is done.
+func (wrapper *BinanceWrapper) CancelOrderContext(ctx context.Context, market *environment.Market, orderID string) error {
+	_, err := wrapper.api.NewCancelOrderService().Symbol(MarketNameFor(market, wrapper)).OrigClientOrderID(orderID).Do(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *BinanceWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return wrapper.GetOrderContext(context.Background(), market, orderID)
+}
+
+// GetOrderContext gets the current status of an order, until the context is done.
+func (wrapper *BinanceWrapper) GetOrderContext(ctx context.Context, market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	binanceOrder, err := wrapper.api.NewGetOrderService().Symbol(MarketNameFor(market, wrapper)).OrigClientOrderID(orderID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := convertFromBinanceOrder(market, binanceOrder)
+	if ret.FilledQuantity.IsZero() {
+		return ret, nil
+	}
+
+	// fees are only reported in the trades generated by the order.
+	if err := wrapper.meter.request(ctx, "GetOrderTrades"); err != nil {
+		return nil, err
+	}
+	binanceTrades, err := wrapper.api.NewListTradesService().Symbol(MarketNameFor(market, wrapper)).StartTime(binanceOrder.Time).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, trade := range binanceTrades {
+		if trade.OrderID != binanceOrder.OrderID {
+			continue
+		}
+		commission, _ := decimal.NewFromString(trade.Commission)
+		ret.Fee = ret.Fee.Add(commission)
+		ret.FeeCurrency = trade.CommissionAsset
+	}
+
+	return ret, nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *BinanceWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return wrapper.GetOpenOrdersContext(context.Background(), market)
+}
+
+// GetOpenOrdersContext gets the orders of the user still open on a market, until the context is done.
+func (wrapper *BinanceWrapper) GetOpenOrdersContext(ctx context.Context, market *environment.Market) ([]*environment.OrderInfo, error) {
+	binanceOrders, err := wrapper.api.NewListOpenOrdersService().Symbol(MarketNameFor(market, wrapper)).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, len(binanceOrders))
+	for i, binanceOrder := range binanceOrders {
+		ret[i] = convertFromBinanceOrder(market, binanceOrder)
+	}
+
+	return ret, nil
+}
+
+// convertFromBinanceOrder converts a binance order to a environment.OrderInfo.
+func convertFromBinanceOrder(market *environment.Market, binanceOrder *binance.Order) *environment.OrderInfo {
+	price, _ := decimal.NewFromString(binanceOrder.Price)
+	quantity, _ := decimal.NewFromString(binanceOrder.OrigQuantity)
+	filled, _ := decimal.NewFromString(binanceOrder.ExecutedQuantity)
+	quoteFilled, _ := decimal.NewFromString(binanceOrder.CummulativeQuoteQuantity)
+
+	averagePrice := decimal.Zero
+	if !filled.IsZero() {
+		averagePrice = quoteFilled.Div(filled)
+	}
+
+	side := environment.Bid
+	if binanceOrder.Side == binance.SideTypeSell {
+		side = environment.Ask
+	}
+
+	var status environment.OrderStatus
+	switch binanceOrder.Status {
+	case binance.OrderStatusTypeNew, binance.OrderStatusTypePendingCancel:
+		status = environment.OrderStatusOpen
+	case binance.OrderStatusTypePartiallyFilled:
+		status = environment.OrderStatusPartiallyFilled
+	case binance.OrderStatusTypeFilled:
+		status = environment.OrderStatusFilled
+	case binance.OrderStatusTypeCanceled:
+		status = environment.OrderStatusCancelled
+	case binance.OrderStatusTypeRejected, binance.OrderStatusTypeExpired:
+		status = environment.OrderStatusRejected
+	default:
+		status = environment.OrderStatusUnknown
+	}
+
+	return &environment.OrderInfo{
+		ID:               binanceOrder.ClientOrderID,
+		Market:           market,
+		Side:             side,
+		Status:           status,
+		Price:            price,
+		Quantity:         quantity,
+		FilledQuantity:   filled,
+		AverageFillPrice: averagePrice,
+		Timestamp:        time.Unix(0, binanceOrder.Time*int64(time.Millisecond)),
+	}
+}
+
 // GetTicker gets the updated ticker for a market.
 func (wrapper *BinanceWrapper) GetTicker(market *environment.Market) (*environment.Ticker, error) {
 	binanceTicker, err := wrapper.api.NewListBookTickersService().Symbol(MarketNameFor(market, wrapper)).Do(context.Background())
@@ -270,8 +565,17 @@ func (wrapper *BinanceWrapper) GetTicker(market *environment.Market) (*environme
 
 // GetMarketSummary gets the current market summary.
 func (wrapper *BinanceWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
-	if !wrapper.websocketOn {
-		binanceSummary, err := wrapper.api.NewListPriceChangeStatsService().Symbol(MarketNameFor(market, wrapper)).Do(context.Background())
+	return wrapper.GetMarketSummaryContext(context.Background(), market)
+}
+
+// GetMarketSummaryContext gets the current market summary, until the context is done.
+func (wrapper *BinanceWrapper) GetMarketSummaryContext(ctx context.Context, market *environment.Market) (*environment.MarketSummary, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.summaries.Updated(market))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
+		binanceSummary, err := wrapper.api.NewListPriceChangeStatsService().Symbol(MarketNameFor(market, wrapper)).Do(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -300,36 +604,62 @@ func (wrapper *BinanceWrapper) GetMarketSummary(market *environment.Market) (*en
 	return ret, nil
 }
 
+// binanceIntervals contains the codes of the candle intervals supported by the exchange.
+var binanceIntervals = map[environment.Interval]string{
+	environment.Interval1m:  "1m",
+	environment.Interval3m:  "3m",
+	environment.Interval5m:  "5m",
+	environment.Interval15m: "15m",
+	environment.Interval30m: "30m",
+	environment.Interval1h:  "1h",
+	environment.Interval2h:  "2h",
+	environment.Interval4h:  "4h",
+	environment.Interval6h:  "6h",
+	environment.Interval8h:  "8h",
+	environment.Interval12h: "12h",
+	environment.Interval1d:  "1d",
+	environment.Interval3d:  "3d",
+	environment.Interval1w:  "1w",
+}
+
 // GetCandles gets the candle data from the exchange.
-func (wrapper *BinanceWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	if !wrapper.websocketOn {
-		binanceCandles, err := wrapper.api.NewKlinesService().Symbol(MarketNameFor(market, wrapper)).Interval(interval).Do(context.Background())
+func (wrapper *BinanceWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesContext(context.Background(), market, interval)
+}
+
+// GetCandlesContext gets the candle data from the exchange, until the context is done.
+func (wrapper *BinanceWrapper) GetCandlesContext(ctx context.Context, market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.candles.Updated(market, interval))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
+		source, err := sourceInterval(wrapper, binanceIntervals, interval)
 		if err != nil {
 			return nil, err
 		}
 
-		ret := make([]environment.CandleStick, len(binanceCandles))
+		binanceCandles, err := wrapper.api.NewKlinesService().Symbol(MarketNameFor(market, wrapper)).Interval(binanceIntervals[source]).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
 
+		ret := make([]environment.CandleStick, len(binanceCandles))
 		for i, binanceCandle := range binanceCandles {
-			high, _ := decimal.NewFromString(binanceCandle.High)
-			open, _ := decimal.NewFromString(binanceCandle.Open)
-			close, _ := decimal.NewFromString(binanceCandle.Close)
-			low, _ := decimal.NewFromString(binanceCandle.Low)
-			volume, _ := decimal.NewFromString(binanceCandle.Volume)
+			ret[i] = convertBinanceKline(binanceCandle)
+		}
 
-			ret[i] = environment.CandleStick{
-				High:   high,
-				Open:   open,
-				Close:  close,
-				Low:    low,
-				Volume: volume,
+		if source != interval {
+			ret, err = environment.ResampleCandles(ret, interval)
+			if err != nil {
+				return nil, err
 			}
 		}
 
-		wrapper.candles.Set(market, ret)
+		wrapper.candles.Set(market, interval, ret)
 	}
 
-	ret, candleLoaded := wrapper.candles.Get(market)
+	ret, candleLoaded := wrapper.candles.Get(market, interval)
 	if !candleLoaded {
 		return nil, errors.New("No candle data yet")
 	}
@@ -337,9 +667,76 @@ func (wrapper *BinanceWrapper) GetCandles(market *environment.Market, interval s
 	return ret, nil
 }
 
+// binanceKlinesLimit is the maximum number of candles returned by a single klines request.
+const binanceKlinesLimit = 1000
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *BinanceWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesRangeContext(context.Background(), market, interval, from, to)
+}
+
+// GetCandlesRangeContext gets the candles opening in the [from, to) time range from the exchange, until the context is done.
+func (wrapper *BinanceWrapper) GetCandlesRangeContext(ctx context.Context, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	source, err := sourceInterval(wrapper, binanceIntervals, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []environment.CandleStick
+	start := rangeStart(source, interval, from)
+	for page := 0; start.Before(to); page++ {
+		if page > 0 {
+			if err := wrapper.meter.request(ctx, "GetCandlesRange"); err != nil {
+				return nil, err
+			}
+		}
+		binanceCandles, err := wrapper.api.NewKlinesService().Symbol(MarketNameFor(market, wrapper)).Interval(binanceIntervals[source]).
+			StartTime(start.UnixNano() / int64(time.Millisecond)).EndTime(to.UnixNano()/int64(time.Millisecond) - 1).
+			Limit(binanceKlinesLimit).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, binanceCandle := range binanceCandles {
+			ret = append(ret, convertBinanceKline(binanceCandle))
+		}
+		if len(binanceCandles) < binanceKlinesLimit {
+			break
+		}
+		start = ret[len(ret)-1].OpenTime.Add(source.Duration())
+	}
+
+	return candlesInRange(ret, source, interval, from, to)
+}
+
+// convertBinanceKline converts a kline got from the exchange into a candle.
+func convertBinanceKline(binanceCandle *binance.Kline) environment.CandleStick {
+	high, _ := decimal.NewFromString(binanceCandle.High)
+	open, _ := decimal.NewFromString(binanceCandle.Open)
+	close, _ := decimal.NewFromString(binanceCandle.Close)
+	low, _ := decimal.NewFromString(binanceCandle.Low)
+	volume, _ := decimal.NewFromString(binanceCandle.Volume)
+
+	return environment.CandleStick{
+		High:       high,
+		Open:       open,
+		Close:      close,
+		Low:        low,
+		Volume:     volume,
+		OpenTime:   time.Unix(0, binanceCandle.OpenTime*int64(time.Millisecond)),
+		CloseTime:  time.Unix(0, binanceCandle.CloseTime*int64(time.Millisecond)),
+		TradeCount: binanceCandle.TradeNum,
+	}
+}
+
 // GetBalance gets the balance of the user of the specified currency.
 func (wrapper *BinanceWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
-	binanceAccount, err := wrapper.api.NewGetAccountService().Do(context.Background())
+	return wrapper.GetBalanceContext(context.Background(), symbol)
+}
+
+// GetBalanceContext gets the balance of the user of the specified currency, until the context is done.
+func (wrapper *BinanceWrapper) GetBalanceContext(ctx context.Context, symbol string) (*decimal.Decimal, error) {
+	binanceAccount, err := wrapper.api.NewGetAccountService().Do(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +763,7 @@ func (wrapper *BinanceWrapper) GetDepositAddress(coinTicker string) (string, boo
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Binance fees are currently hardcoded.
-func (wrapper *BinanceWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *BinanceWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0010
@@ -376,107 +773,231 @@ func (wrapper *BinanceWrapper) CalculateTradingFees(market *environment.Market,
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *BinanceWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *BinanceWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the exchange.
+//
+//     The lost websocket connections are opened again with exponential backoff, until the feed is disconnected.
 func (wrapper *BinanceWrapper) FeedConnect(markets []*environment.Market) error {
+	if wrapper.feedStop == nil {
+		wrapper.feedStop = make(chan struct{})
+	}
 	for _, m := range markets {
 		err := wrapper.subscribeMarketSummaryFeed(m)
 		if err != nil {
 			return err
 		}
-		wrapper.subscribeOrderbookFeed(m)
+		err = wrapper.subscribeOrderbookFeed(m)
+		if err != nil {
+			return err
+		}
+		err = wrapper.subscribeTradeFeed(m)
+		if err != nil {
+			return err
+		}
 	}
 	wrapper.websocketOn = true
 
 	return nil
 }
 
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *BinanceWrapper) FeedDisconnect() error {
+	if wrapper.feedStop == nil {
+		return nil
+	}
+	wrapper.websocketOn = false
+	close(wrapper.feedStop)
+	wrapper.feedStop = nil
+
+	return nil
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed,
+// which must be connected to the market to receive them.
+func (wrapper *BinanceWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return subscribeTrades(market), nil
+}
+
+// binanceConnection converts the channels of a websocket connection of the exchange into a feed connection.
+func binanceConnection(doneC chan struct{}, stopC chan struct{}) feedConnection {
+	return feedConnection{
+		done: doneC,
+		close: func() {
+			close(stopC)
+		},
+	}
+}
+
+// logFeedError logs the error which made a websocket connection of the feed fail.
+func (wrapper *BinanceWrapper) logFeedError(err error) {
+	logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+}
+
 // SubscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
 func (wrapper *BinanceWrapper) subscribeMarketSummaryFeed(market *environment.Market) error {
-	_, _, err := binance.WsMarketStatServe(MarketNameFor(market, wrapper), func(event *binance.WsMarketStatEvent) {
-		high, _ := decimal.NewFromString(event.HighPrice)
-		low, _ := decimal.NewFromString(event.LowPrice)
-		ask, _ := decimal.NewFromString(event.AskPrice)
-		bid, _ := decimal.NewFromString(event.BidPrice)
-		last, _ := decimal.NewFromString(event.LastPrice)
-		volume, _ := decimal.NewFromString(event.BaseVolume)
+	return superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		doneC, stopC, err := binance.WsMarketStatServe(MarketNameFor(market, wrapper), func(event *binance.WsMarketStatEvent) {
+			high, _ := decimal.NewFromString(event.HighPrice)
+			low, _ := decimal.NewFromString(event.LowPrice)
+			ask, _ := decimal.NewFromString(event.AskPrice)
+			bid, _ := decimal.NewFromString(event.BidPrice)
+			last, _ := decimal.NewFromString(event.LastPrice)
+			volume, _ := decimal.NewFromString(event.BaseVolume)
+
+			wrapper.summaries.Set(market, &environment.MarketSummary{
+				High:   high,
+				Low:    low,
+				Ask:    ask,
+				Bid:    bid,
+				Last:   last,
+				Volume: volume,
+			})
+		}, wrapper.logFeedError)
+		if err != nil {
+			return feedConnection{}, err
+		}
+		return binanceConnection(doneC, stopC), nil
+	})
+}
 
-		wrapper.summaries.Set(market, &environment.MarketSummary{
-			High:   high,
-			Low:    low,
-			Ask:    ask,
-			Bid:    bid,
-			Last:   last,
-			Volume: volume,
-		})
-	}, func(error) {})
-	if err != nil {
-		return err
-	}
-	return nil
+// subscribeTradeFeed subscribes to the Trade Feed service, notifying the trades to the feed listeners.
+func (wrapper *BinanceWrapper) subscribeTradeFeed(market *environment.Market) error {
+	return superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		doneC, stopC, err := binance.WsTradeServe(MarketNameFor(market, wrapper), func(event *binance.WsTradeEvent) {
+			price, _ := decimal.NewFromString(event.Price)
+			quantity, _ := decimal.NewFromString(event.Quantity)
+
+			side := environment.Bid
+			if event.IsBuyerMaker {
+				side = environment.Ask
+			}
+
+			notifyTrade(market, environment.Trade{
+				ID:        fmt.Sprint(event.TradeID),
+				Price:     price,
+				Quantity:  quantity,
+				Side:      side,
+				Timestamp: time.Unix(0, event.TradeTime*int64(time.Millisecond)),
+			})
+		}, wrapper.logFeedError)
+		if err != nil {
+			return feedConnection{}, err
+		}
+		return binanceConnection(doneC, stopC), nil
+	})
 }
 
-func (wrapper *BinanceWrapper) subscribeOrderbookFeed(market *environment.Market) {
-	go func() {
-		for {
-			_, lastUpdateID, err := wrapper.orderbookFromREST(market)
+const (
+	binanceBookSnapshotLimit = 1000 // Number of levels per side of the snapshots the local order books are loaded from.
+	binanceBookBufferLimit   = 1000 // Maximum number of diffs buffered while a snapshot is loaded, the oldest are dropped.
+)
+
+// subscribeOrderbookFeed subscribes to the Diff Depth Feed service, maintaining a local order book of the market
+// loaded again from a snapshot got from the REST API each time some updates are missed.
+//
+//     The snapshot is loaded in background, buffering the diffs received meanwhile to apply them on top of it.
+//     Binance does not send checksums, the update IDs of the diffs are validated instead.
+func (wrapper *BinanceWrapper) subscribeOrderbookFeed(market *environment.Market) error {
+	return superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		book := NewL2OrderBook(nil)
+		mutex := &sync.Mutex{}
+		var buffered []BookDiff // Diffs received while the book is out of sync.
+		var resyncing bool
+		var nextResync time.Time
+
+		resync := func() {
+			snapshot, lastUpdateID, err := wrapper.orderbookFromREST(context.Background(), market, binanceBookSnapshotLimit)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			resyncing = false
 			if err != nil {
-				logrus.Error(err)
+				nextResync = now().Add(feedMinBackoff)
+				wrapper.logFeedError(err)
 				return
 			}
-			// 24 hours max
-			currentUpdateID := lastUpdateID
 
-			done, _, _ := binance.WsPartialDepthServe(MarketNameFor(market, wrapper), "20", func(event *binance.WsPartialDepthEvent) {
-				if event.LastUpdateID <= currentUpdateID { // this update is more recent than the latest fetched
-					return
+			book.Reset(*snapshot, lastUpdateID)
+			for _, diff := range buffered {
+				if err := book.Apply(diff); err != nil {
+					wrapper.logFeedError(err)
+					break
 				}
+			}
+			buffered = nil
+			if book.Synced() {
+				orderbook := book.Top(0)
+				wrapper.orderbook.Set(market, &orderbook)
+			}
+		}
 
-				var orderbook environment.OrderBook
-
-				orderbook.Asks = make([]environment.Order, len(event.Asks))
-				orderbook.Bids = make([]environment.Order, len(event.Bids))
-
-				for i, ask := range event.Asks {
-					price, _ := decimal.NewFromString(ask.Price)
-					quantity, _ := decimal.NewFromString(ask.Quantity)
-					newOrder := environment.Order{
-						Value:    price,
-						Quantity: quantity,
-					}
-					orderbook.Asks[i] = newOrder
-				}
+		doneC, stopC, err := binance.WsDepthServe(MarketNameFor(market, wrapper), func(event *binance.WsDepthEvent) {
+			diff := BookDiff{
+				FirstUpdateID: event.FirstUpdateID,
+				LastUpdateID:  event.UpdateID,
+				Asks:          make([]environment.Order, len(event.Asks)),
+				Bids:          make([]environment.Order, len(event.Bids)),
+			}
+			for i, ask := range event.Asks {
+				diff.Asks[i] = binanceLevel(ask.Price, ask.Quantity)
+			}
+			for i, bid := range event.Bids {
+				diff.Bids[i] = binanceLevel(bid.Price, bid.Quantity)
+			}
 
-				for i, bid := range event.Bids {
-					price, _ := decimal.NewFromString(bid.Price)
-					quantity, _ := decimal.NewFromString(bid.Quantity)
-					newOrder := environment.Order{
-						Value:    price,
-						Quantity: quantity,
-					}
-					orderbook.Bids[i] = newOrder
+			mutex.Lock()
+			defer mutex.Unlock()
+			if book.Synced() {
+				err := book.Apply(diff)
+				if err == nil {
+					orderbook := book.Top(0)
+					wrapper.orderbook.Set(market, &orderbook)
+					return
 				}
+				wrapper.logFeedError(err)
+			}
 
-				wrapper.orderbook.Set(market, &orderbook)
-			}, func(err error) {
-				logrus.Error(err)
-			})
-
-			<-done
+			buffered = append(buffered, diff)
+			if len(buffered) > binanceBookBufferLimit {
+				buffered = buffered[1:]
+			}
+			if !resyncing && !now().Before(nextResync) {
+				resyncing = true
+				go resync()
+			}
+		}, wrapper.logFeedError)
+		if err != nil {
+			return feedConnection{}, err
 		}
-	}()
+		return binanceConnection(doneC, stopC), nil
+	})
+}
+
+// binanceLevel converts a price level of an order book got from the exchange.
+func binanceLevel(price string, quantity string) environment.Order {
+	value, _ := decimal.NewFromString(price)
+	size, _ := decimal.NewFromString(quantity)
+	return environment.Order{
+		Value:    value,
+		Quantity: size,
+	}
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *BinanceWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	_, err := wrapper.api.NewCreateWithdrawService().Address(destinationAddress).Asset(coinTicker).Amount(fmt.Sprint(amount)).Do(context.Background())
+func (wrapper *BinanceWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return wrapper.WithdrawContext(context.Background(), destinationAddress, coinTicker, amount)
+}
+
+// WithdrawContext performs a withdraw operation from the exchange to a destination address, until the context is done.
+func (wrapper *BinanceWrapper) WithdrawContext(ctx context.Context, destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.api.NewCreateWithdrawService().Address(destinationAddress).Asset(coinTicker).Amount(amount.String()).Do(ctx)
 	if err != nil {
 		return err
 	}
@@ -1,13 +1,19 @@
 package exchanges
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"math"
-	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
 
 	bitfinex "github.com/bitfinexcom/bitfinex-api-go/v1"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
@@ -20,7 +26,12 @@ type BitfinexWrapper struct {
 	unsubscribeChannels map[string]chan bool
 	summaries           *SummaryCache
 	orderbook           *OrderbookCache
+	tradingRules        *TradingRulesCache
+	meter               *requestMeter // Meter of the requests made within the calls.
 	depositAddresses    map[string]string
+	feedStop            chan struct{}         // Closed to stop the websocket connection of the feed.
+	feedMarkets         []*environment.Market // Markets subscribed by the websocket connection of the feed.
+	feedSettings        FeedSettings
 }
 
 // NewBitfinexWrapper creates a generic wrapper of the bittrex API.
@@ -30,6 +41,8 @@ func NewBitfinexWrapper(publicKey string, secretKey string, depositAddresses map
 		unsubscribeChannels: make(map[string]chan bool),
 		summaries:           NewSummaryCache(),
 		orderbook:           NewOrderbookCache(),
+		tradingRules:        NewTradingRulesCache(),
+		meter:               newRequestMeter(),
 		websocketOn:         false,
 		depositAddresses:    depositAddresses,
 	}
@@ -40,37 +53,89 @@ func (wrapper *BitfinexWrapper) Name() string {
 	return "bitfinex"
 }
 
+// SetFeedSettings sets how the data got from the websocket feed is served.
+func (wrapper *BitfinexWrapper) SetFeedSettings(settings FeedSettings) {
+	wrapper.feedSettings = settings
+}
+
 func (wrapper *BitfinexWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *BitfinexWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *BitfinexWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		Websocket:    true,
+		OrderBook:    true,
+		Trades:       true,
+		LimitOrders:  true,
+		MarketOrders: true,
+		Balance:      true,
+		Withdraw:     true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *BitfinexWrapper) GetMarkets() ([]*environment.Market, error) {
-	bitfinexMarkets, err := wrapper.api.Pairs.All()
+	bitfinexMarkets, err := wrapper.api.Pairs.AllDetailed()
 	if err != nil {
 		return nil, err
 	}
 
 	wrappedMarkets := make([]*environment.Market, len(bitfinexMarkets))
 	for i, pair := range bitfinexMarkets {
-		quote, base := pair[0:2], pair[3:5]
-		wrappedMarkets[i] = &environment.Market{
-			Name:           pair,
-			BaseCurrency:   base,
-			MarketCurrency: quote,
+		base, quote := splitBitfinexPair(pair.Pair)
+		wrappedMarkets[i] = listedMarket(wrapper, pair.Pair, base, quote)
+		wrappedMarkets[i].Rules = environment.TradingRules{
+			MinQuantity: decimal.NewFromFloat(pair.MinimumOrderSize),
+			MaxQuantity: decimal.NewFromFloat(pair.MaximumOrderSize),
 		}
+		wrapper.tradingRules.Set(strings.ToLower(pair.Pair), wrappedMarkets[i].Rules)
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 
 	return wrappedMarkets, nil
 }
 
+// splitBitfinexPair gets the currencies of a bitfinex pair: tickers longer than 3 characters
+// are separated by a colon (e.g. dusk:usd), otherwise they are concatenated (e.g. btcusd).
+func splitBitfinexPair(pair string) (string, string) {
+	if currencies := strings.SplitN(pair, ":", 2); len(currencies) == 2 {
+		return currencies[0], currencies[1]
+	}
+	if len(pair) != 6 {
+		return pair, ""
+	}
+	return pair[0:3], pair[3:6]
+}
+
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *BitfinexWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(strings.ToLower(MarketNameFor(market, wrapper)), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *BitfinexWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
 func (wrapper *BitfinexWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
-	if !wrapper.websocketOn {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.orderbook.Updated(market))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
 		bitfinexOrderBook, err := wrapper.api.OrderBook.Get(MarketNameFor(market, wrapper), 0, 0, false)
 		if err != nil {
 			return nil, err
@@ -120,49 +185,197 @@ func (wrapper *BitfinexWrapper) GetOrderBook(market *environment.Market) (*envir
 	return orderbook, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *BitfinexWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	bitfinexTrades, err := wrapper.api.Trades.All(MarketNameFor(market, wrapper), time.Time{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the exchange returns the newest trades first.
+	ret := make([]environment.Trade, len(bitfinexTrades))
+	for i, trade := range bitfinexTrades {
+		price, err := decimal.NewFromString(trade.Price)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := decimal.NewFromString(trade.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		side := environment.Bid
+		if trade.Type == "sell" {
+			side = environment.Ask
+		}
+
+		ret[len(ret)-1-i] = environment.Trade{
+			ID:        fmt.Sprint(trade.TradeId),
+			Price:     price,
+			Quantity:  amount,
+			Side:      side,
+			Timestamp: time.Unix(trade.Timestamp, 0),
+		}
+	}
+
+	return ret, nil
+}
+
 // BuyLimit performs a limit buy action.
 //
 // NOTE: In bitfinex buy and sell orders behave the same (the go bitfinex api automatically puts it on correct side)
-func (wrapper *BitfinexWrapper) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	amount = math.Abs(amount)
+func (wrapper *BitfinexWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount.Abs(), limit)
+	if err != nil {
+		return "", err
+	}
 	return wrapper.createOrder(market, bitfinex.OrderTypeLimit, amount, limit)
 }
 
 // SellLimit performs a limit sell action.
 //
 // NOTE: In bitfinex buy and sell orders behave the same (the go bitfinex api automatically puts it on correct side)
-func (wrapper *BitfinexWrapper) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	amount = -math.Abs(amount) // a sell is a buy with negative amount.
-	return wrapper.createOrder(market, bitfinex.OrderTypeLimit, amount, limit)
+func (wrapper *BitfinexWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount.Abs(), limit)
+	if err != nil {
+		return "", err
+	}
+	return wrapper.createOrder(market, bitfinex.OrderTypeLimit, amount.Neg(), limit) // a sell is a buy with negative amount.
 }
 
 // BuyMarket performs a limit buy action.
 //
 // NOTE: In bitfinex buy and sell orders behave the same (the go bitfinex api automatically puts it on correct side)
-func (wrapper *BitfinexWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	amount = math.Abs(amount)
-	return wrapper.createOrder(market, bitfinex.OrderTypeMarket, amount, 0)
+func (wrapper *BitfinexWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Bid, amount.Abs(), decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+	return wrapper.createOrder(market, bitfinex.OrderTypeMarket, amount, decimal.Zero)
 }
 
 // SellMarket performs a limit sell action.
 //
 // NOTE: In bitfinex buy and sell orders behave the same (the go bitfinex api automatically puts it on correct side)
-func (wrapper *BitfinexWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	amount = -math.Abs(amount) // a sell is a buy with negative amount.
-	return wrapper.createOrder(market, bitfinex.OrderTypeMarket, amount, 0)
+func (wrapper *BitfinexWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Ask, amount.Abs(), decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+	return wrapper.createOrder(market, bitfinex.OrderTypeMarket, amount.Neg(), decimal.Zero) // a sell is a buy with negative amount.
 }
 
 // createOrder creates a generic order.
 //
 // NOTE: In bitfinex buy and sell orders behave the same (in sell the amount is negative)
-func (wrapper *BitfinexWrapper) createOrder(market *environment.Market, orderType string, amount float64, price float64) (string, error) {
-	orderNumber, err := wrapper.api.Orders.Create(MarketNameFor(market, wrapper), amount, price, orderType)
+func (wrapper *BitfinexWrapper) createOrder(market *environment.Market, orderType string, amount decimal.Decimal, price decimal.Decimal) (string, error) {
+	orderNumber, err := wrapper.api.Orders.Create(MarketNameFor(market, wrapper), toFloat(amount), toFloat(price), orderType)
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprint(orderNumber.ID), nil
 }
 
+// CancelOrder cancels an open order.
+func (wrapper *BitfinexWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return wrapper.api.Orders.Cancel(id)
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *BitfinexWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	bitfinexOrder, err := wrapper.api.Orders.Status(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := convertFromBitfinexOrder(market, bitfinexOrder)
+	if ret.FilledQuantity.IsZero() {
+		return ret, nil
+	}
+
+	// fees are only reported in the trades generated by the order.
+	bitfinexTrades, err := wrapper.api.History.Trades(MarketNameFor(market, wrapper), ret.Timestamp, time.Time{}, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, trade := range bitfinexTrades {
+		if trade.OrderId != id {
+			continue
+		}
+		fee, _ := decimal.NewFromString(trade.FeeAmount)
+		ret.Fee = ret.Fee.Add(fee.Abs())
+		ret.FeeCurrency = trade.FeeCurrency
+	}
+
+	return ret, nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *BitfinexWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	bitfinexOrders, err := wrapper.api.Orders.All()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, 0, len(bitfinexOrders))
+	for _, bitfinexOrder := range bitfinexOrders {
+		if !strings.EqualFold(bitfinexOrder.Symbol, MarketNameFor(market, wrapper)) {
+			continue
+		}
+		ret = append(ret, convertFromBitfinexOrder(market, bitfinexOrder))
+	}
+
+	return ret, nil
+}
+
+// convertFromBitfinexOrder converts a bitfinex order to a environment.OrderInfo.
+func convertFromBitfinexOrder(market *environment.Market, bitfinexOrder bitfinex.Order) *environment.OrderInfo {
+	price, _ := decimal.NewFromString(bitfinexOrder.Price)
+	averagePrice, _ := decimal.NewFromString(bitfinexOrder.AvgExecutionPrice)
+	quantity, _ := decimal.NewFromString(bitfinexOrder.OriginalAmount)
+	filled, _ := decimal.NewFromString(bitfinexOrder.ExecutedAmount)
+	seconds, _ := strconv.ParseFloat(bitfinexOrder.Timestamp, 64)
+
+	side := environment.Bid
+	if bitfinexOrder.Side == "sell" {
+		side = environment.Ask
+	}
+
+	var status environment.OrderStatus
+	if bitfinexOrder.IsLive {
+		if filled.IsZero() {
+			status = environment.OrderStatusOpen
+		} else {
+			status = environment.OrderStatusPartiallyFilled
+		}
+	} else if bitfinexOrder.IsCanceled {
+		status = environment.OrderStatusCancelled
+	} else {
+		status = environment.OrderStatusFilled
+	}
+
+	return &environment.OrderInfo{
+		ID:               fmt.Sprint(bitfinexOrder.ID),
+		Market:           market,
+		Side:             side,
+		Status:           status,
+		Price:            price,
+		Quantity:         quantity.Abs(),
+		FilledQuantity:   filled.Abs(),
+		AverageFillPrice: averagePrice,
+		Timestamp:        time.Unix(int64(seconds), 0),
+	}
+}
+
 // GetTicker gets the updated ticker for a market.
 func (wrapper *BitfinexWrapper) GetTicker(market *environment.Market) (*environment.Ticker, error) {
 	bitfinexTicker, err := wrapper.api.Ticker.Get(MarketNameFor(market, wrapper))
@@ -183,7 +396,11 @@ func (wrapper *BitfinexWrapper) GetTicker(market *environment.Market) (*environm
 
 // GetMarketSummary gets the current market summary.
 func (wrapper *BitfinexWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
-	if !wrapper.websocketOn {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.summaries.Updated(market))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
 		bitfinexSummary, err := wrapper.api.Ticker.Get(MarketNameFor(market, wrapper))
 		if err != nil {
 			return nil, err
@@ -214,8 +431,13 @@ func (wrapper *BitfinexWrapper) GetMarketSummary(market *environment.Market) (*e
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *BitfinexWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	panic("Not supported in V1")
+func (wrapper *BitfinexWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *BitfinexWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
 }
 
 // GetBalance gets the balance of the user of the specified currency.
@@ -248,7 +470,7 @@ func (wrapper *BitfinexWrapper) GetDepositAddress(coinTicker string) (string, bo
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Bitfinex fees are currently hardcoded.
-func (wrapper *BitfinexWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *BitfinexWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0010 // 0.1%
@@ -258,72 +480,103 @@ func (wrapper *BitfinexWrapper) CalculateTradingFees(market *environment.Market,
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *BitfinexWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *BitfinexWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the exchange.
+//
+//     The websocket connection is opened again with exponential backoff when lost, subscribing again
+//     to the channels of the markets, until the feed is disconnected.
+//     The markets of a connected feed cannot be changed, since the client cannot subscribe to the channels
+//     of a running connection: the feed must be disconnected first.
 func (wrapper *BitfinexWrapper) FeedConnect(markets []*environment.Market) error {
-	err := wrapper.api.WebSocket.Connect()
-	if err != nil {
-		fmt.Println(err)
+	if wrapper.feedStop != nil {
+		for _, m := range markets {
+			if !feedSubscribed(wrapper.feedMarkets, m) {
+				return fmt.Errorf("%s feed already connected without market %s, disconnect it first", wrapper.Name(), m)
+			}
+		}
+		return nil
 	}
+	wrapper.feedStop = make(chan struct{})
+	wrapper.feedMarkets = markets
+
+	err := superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		err := wrapper.api.WebSocket.Connect()
+		if err != nil {
+			return feedConnection{}, err
+		}
+		wrapper.api.WebSocket.ClearSubscriptions()
+
+		tickers := make(chan []float64, 25)
+		tradeMap := make(map[string]chan []float64)
+		for _, m := range markets {
+			tickerKey := MarketNameFor(m, wrapper)
+			tradeMap[tickerKey] = make(chan []float64, 25)
+			wrapper.api.WebSocket.AddSubscribe(bitfinex.ChanTrade, tickerKey, tradeMap[tickerKey])
+			wrapper.subscribeFeeds(m, tickers, tradeMap[tickerKey]) // tickers is not used
+		}
 
-	bookMap := make(map[string]chan []float64)
-	tickers := make(chan []float64, 25)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			err := wrapper.api.WebSocket.Subscribe()
+			if err != nil && wrapper.websocketOn {
+				logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+			}
 
+			close(tickers)
+			for _, channel := range tradeMap {
+				close(channel)
+			}
+		}()
+		return feedConnection{
+			done:  done,
+			close: wrapper.api.WebSocket.Close,
+		}, nil
+	})
+	if err != nil {
+		wrapper.feedStop = nil
+		return err
+	}
 	for _, m := range markets {
-		tickerKey := MarketNameFor(m, wrapper)
-		bookMap[tickerKey] = make(chan []float64, 25)
-		wrapper.api.WebSocket.AddSubscribe(bitfinex.ChanBook, tickerKey, bookMap[tickerKey])
-		wrapper.subscribeFeeds(m, tickers, bookMap[tickerKey]) // tickers is not used
+		if err := wrapper.subscribeOrderbookFeed(m); err != nil {
+			close(wrapper.feedStop)
+			wrapper.feedStop = nil
+			return err
+		}
 	}
-
 	wrapper.websocketOn = true
 
-	go func(tickers chan []float64, orderbooks map[string]chan []float64) {
-		for {
-			err = wrapper.api.WebSocket.Subscribe()
-			if err != nil {
-				fmt.Println(err)
-			}
-
-			wrapper.api.WebSocket.Close()
+	return nil
+}
 
-			for _, channel := range bookMap {
-				close(channel)
-			}
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *BitfinexWrapper) FeedDisconnect() error {
+	if wrapper.feedStop == nil {
+		return nil
+	}
+	wrapper.websocketOn = false
+	close(wrapper.feedStop)
+	wrapper.feedStop = nil
+	wrapper.feedMarkets = nil
 
-			bookMap := make(map[string]chan []float64)
-			err = errors.New("")
-			for err != nil {
-				err = wrapper.api.WebSocket.Connect()
-				if err != nil {
-					fmt.Println(err)
-				}
-			}
-			wrapper.api.WebSocket.ClearSubscriptions()
-
-			for _, m := range markets {
-				tickerKey := MarketNameFor(m, wrapper)
-				bookMap[tickerKey] = make(chan []float64)
-				//wrapper.api.WebSocket.AddSubscribe(bitfinex.ChanTicker, tickerKey, tickers)
-				wrapper.api.WebSocket.AddSubscribe(bitfinex.ChanBook, tickerKey, bookMap[tickerKey])
-				//wrapper.api.WebSocket.AddSubscribe(bitfinex.ChanTrade, tickerKey, trades)
-			}
-		}
-	}(tickers, bookMap)
 	return nil
 }
 
-// subscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
-func (wrapper *BitfinexWrapper) subscribeFeeds(market *environment.Market, tickers <-chan []float64, orderbooks <-chan []float64) {
-	//trades := make(chan []float64)
+// SubscribeTrades subscribes to the public trades of a market got from the feed,
+// which must be connected to the market to receive them.
+func (wrapper *BitfinexWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return subscribeTrades(market), nil
+}
 
+// subscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
+func (wrapper *BitfinexWrapper) subscribeFeeds(market *environment.Market, tickers <-chan []float64, trades <-chan []float64) {
 	//     NOTE: Content of result array
 	//     BID	float	Price of last highest bid
 	//     BID_SIZE	float	Size of the last highest bid
@@ -353,79 +606,262 @@ func (wrapper *BitfinexWrapper) subscribeFeeds(market *environment.Market, ticke
 		}
 	}
 
-	handleOrderbook := func(results <-chan []float64, m *environment.Market) {
-		orderbookMap := make(map[float64]float64)
+	handleTrades := func(results <-chan []float64, m *environment.Market) {
 		for {
-			// values : []float64 { PRICE, COUNT, TOTAL_AMOUNT }
+			// values : []float64 { ID, TIMESTAMP, PRICE, AMOUNT }
 			values, stillOpen := <-results
 			if !stillOpen {
 				return
 			}
 
-			if len(values) != 3 { // for client bug : https://github.com/bitfinexcom/bitfinex-api-go/issues/133
+			// only the "tu" updates carry the trade ID, the "te" ones sent before them are skipped
+			// as well as the snapshot, which the client cannot decode.
+			if len(values) != 4 {
 				continue
 			}
 
-			price := values[0]
-			count := values[1]
-			amount := values[2]
-
-			if count == 0 {
-				delete(orderbookMap, price)
-				continue
+			side := environment.Bid
+			if values[3] < 0 {
+				side = environment.Ask
 			}
 
-			orderbookMap[price] = amount
+			notifyTrade(m, environment.Trade{
+				ID:        strconv.FormatFloat(values[0], 'f', -1, 64),
+				Price:     decimal.NewFromFloat(values[2]),
+				Quantity:  decimal.NewFromFloat(values[3]).Abs(),
+				Side:      side,
+				Timestamp: time.Unix(int64(values[1]), 0),
+			})
+		}
+	}
 
-			orderbook := environment.OrderBook{
-				Asks: make([]environment.Order, 0, 25),
-				Bids: make([]environment.Order, 0, 25),
-			}
+	go handleTicker(tickers, market)
+	go handleTrades(trades, market)
+}
 
-			// now let's create the cache
-			for price, amount := range orderbookMap {
-				if amount < 0 {
-					orderbook.Asks = insertSort(orderbook.Asks, environment.Order{
-						Value:    decimal.NewFromFloat(price),
-						Quantity: decimal.NewFromFloat(-amount),
-					}, false)
-				} else if amount > 0 {
-					orderbook.Bids = insertSort(orderbook.Bids, environment.Order{
-						Value:    decimal.NewFromFloat(price),
-						Quantity: decimal.NewFromFloat(amount),
-					}, true)
-				}
-			}
+const (
+	bitfinexWebsocketURL = "wss://api-pub.bitfinex.com/ws/2" // Endpoint of the websocket API sending the checksums of the order books.
+	bitfinexChecksumFlag = 131072                            // Configuration flag enabling the checksum messages of the order books.
+	bitfinexBookLength   = 25                                // Number of levels per side of the order books, which the checksums are computed on.
+)
 
-			wrapper.orderbook.Set(m, &orderbook)
-		}
+// bitfinexEvent represents an event message of the websocket API.
+type bitfinexEvent struct {
+	Event  string `json:"event"`
+	ChanID int64  `json:"chanId"`
+	Msg    string `json:"msg"`
+	Code   int    `json:"code"`
+}
+
+// subscribeOrderbookFeed subscribes to the Book channel of the websocket API on a connection of its own,
+// maintaining a local order book of the market validated against the checksums sent by the exchange.
+//
+//     The v1 client cannot receive the checksums, so the connection is handled here: the channel is subscribed
+//     again to get a new snapshot each time the book goes out of sync.
+func (wrapper *BitfinexWrapper) subscribeOrderbookFeed(market *environment.Market) error {
+	subscribe := map[string]string{
+		"event":   "subscribe",
+		"channel": bitfinex.ChanBook,
+		"symbol":  "t" + strings.ToUpper(MarketNameFor(market, wrapper)),
+		"prec":    "P0",
+		"len":     strconv.Itoa(bitfinexBookLength),
 	}
 
-	/*
-		handleTrades := func(results <-chan []float64, tickerKey string) {
+	return superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		conn, _, err := websocket.DefaultDialer.Dial(bitfinexWebsocketURL, nil)
+		if err != nil {
+			return feedConnection{}, err
+		}
+		err = conn.WriteJSON(map[string]interface{}{"event": "conf", "flags": bitfinexChecksumFlag})
+		if err == nil {
+			err = conn.WriteJSON(subscribe)
+		}
+		if err != nil {
+			conn.Close()
+			return feedConnection{}, err
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer conn.Close()
+
+			book := NewL2OrderBook(bitfinexChecksum)
+			var chanID int64 // zero while not subscribed.
 			for {
-				values, closed := <-results
-				if closed {
+				conn.SetReadDeadline(now().Add(feedIdleTimeout))
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					if wrapper.websocketOn {
+						logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+					}
 					return
 				}
-				wrapper.summaries.Set(market, &environment.MarketSummary{
-					Bid:    decimal.NewFromFloat(values[0]),
-					Ask:    decimal.NewFromFloat(values[2]),
-					Volume: decimal.NewFromFloat(values[7]),
-					High:   decimal.NewFromFloat(values[8]),
-					Low:    decimal.NewFromFloat(values[9]),
-				})
+
+				if len(message) > 0 && message[0] == '{' {
+					var event bitfinexEvent
+					if err := json.Unmarshal(message, &event); err != nil {
+						continue
+					}
+					switch event.Event {
+					case "subscribed":
+						chanID = event.ChanID
+					case "unsubscribed":
+						err = conn.WriteJSON(subscribe)
+					case "error":
+						err = fmt.Errorf("%s (code %d)", event.Msg, event.Code)
+					}
+					if err != nil {
+						logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+						return
+					}
+					continue
+				}
+
+				snapshot, diff, err := bitfinexBookMessage(message, chanID)
+				switch {
+				case err != nil:
+				case snapshot != nil:
+					book.Reset(*snapshot, 0)
+				case diff != nil:
+					err = book.Apply(*diff)
+				default:
+					continue
+				}
+				if err != nil {
+					logrus.Warnf("%s feed error: %s, subscribing again to the order book of %s", wrapper.Name(), err, market)
+					err = conn.WriteJSON(map[string]interface{}{"event": "unsubscribe", "chanId": chanID})
+					if err != nil {
+						return
+					}
+					chanID = 0
+					continue
+				}
+				orderbook := book.Top(0)
+				wrapper.orderbook.Set(market, &orderbook)
 			}
+		}()
+		return feedConnection{
+			done: done,
+			close: func() {
+				conn.Close()
+			},
+		}, nil
+	})
+}
+
+// bitfinexBookMessage parses a data message of the Book channel with the specified ID, which contains
+// either a snapshot of the order book or a diff to apply to it (a single level or a checksum).
+//
+//     Heartbeats and the messages of other channels are ignored, returning neither.
+func bitfinexBookMessage(message []byte, chanID int64) (*environment.OrderBook, *BookDiff, error) {
+	var payload []json.RawMessage
+	if err := json.Unmarshal(message, &payload); err != nil {
+		return nil, nil, err
+	}
+	var id int64
+	if chanID == 0 || len(payload) < 2 || json.Unmarshal(payload[0], &id) != nil || id != chanID {
+		return nil, nil, nil
+	}
+
+	var kind string
+	if json.Unmarshal(payload[1], &kind) == nil {
+		if kind != "cs" || len(payload) < 3 {
+			return nil, nil, nil // heartbeat.
 		}
-	*/
+		var checksum int32
+		if err := json.Unmarshal(payload[2], &checksum); err != nil {
+			return nil, nil, err
+		}
+		return nil, &BookDiff{Checksum: uint32(checksum), Checksummed: true}, nil
+	}
 
-	go handleTicker(tickers, market)
-	go handleOrderbook(orderbooks, market)
+	// values : []decimal.Decimal { PRICE, COUNT, AMOUNT }
+	var levels [][]decimal.Decimal
+	if json.Unmarshal(payload[1], &levels) == nil {
+		var snapshot environment.OrderBook
+		for _, values := range levels {
+			if len(values) != 3 {
+				continue
+			}
+			level, isAsk := bitfinexLevel(values)
+			if isAsk {
+				snapshot.Asks = append(snapshot.Asks, level)
+			} else {
+				snapshot.Bids = append(snapshot.Bids, level)
+			}
+		}
+		return &snapshot, nil, nil
+	}
+
+	var values []decimal.Decimal
+	if err := json.Unmarshal(payload[1], &values); err != nil {
+		return nil, nil, err
+	}
+	if len(values) != 3 {
+		return nil, nil, fmt.Errorf("Unexpected order book update %s", payload[1])
+	}
+	var diff BookDiff
+	level, isAsk := bitfinexLevel(values)
+	if isAsk {
+		diff.Asks = []environment.Order{level}
+	} else {
+		diff.Bids = []environment.Order{level}
+	}
+	return nil, &diff, nil
+}
+
+// bitfinexLevel converts a price level of an order book got from the exchange, telling if it is an ask level.
+func bitfinexLevel(values []decimal.Decimal) (environment.Order, bool) {
+	price, count, amount := values[0], values[1], values[2]
+
+	level := environment.Order{
+		Value:    price,
+		Quantity: amount.Abs(),
+	}
+	if count.IsZero() { // the level is removed, the sign of the amount tells its side.
+		level.Quantity = decimal.Zero
+	}
+	return level, amount.IsNegative()
+}
+
+// bitfinexChecksum computes the checksum of an order book in the way of the exchange: the CRC32 of the
+// best levels of each side, alternating bids and asks, joined as price:amount with negative ask amounts.
+func bitfinexChecksum(asks []environment.Order, bids []environment.Order) uint32 {
+	values := make([]string, 0, 4*bitfinexBookLength)
+	for i := 0; i < bitfinexBookLength; i++ {
+		if i < len(bids) {
+			values = append(values, bitfinexNumber(bids[i].Value), bitfinexNumber(bids[i].Quantity))
+		}
+		if i < len(asks) {
+			values = append(values, bitfinexNumber(asks[i].Value), bitfinexNumber(asks[i].Quantity.Neg()))
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(strings.Join(values, ":")))
+}
+
+// bitfinexNumber formats a number as the exchange does when computing the checksums, which is the way
+// of Javascript: exponential notation is used for the numbers lower than 1e-6 or not lower than 1e21.
+func bitfinexNumber(number decimal.Decimal) string {
+	value, _ := number.Float64()
+	if abs := math.Abs(value); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	formatted := strconv.FormatFloat(value, 'e', -1, 64) // e.g. 1.5e-07
+	index := strings.IndexByte(formatted, 'e')
+	exponent, _ := strconv.Atoi(formatted[index+1:])
+	sign := "+"
+	if exponent < 0 {
+		sign = "-"
+		exponent = -exponent
+	}
+	return formatted[:index] + "e" + sign + strconv.Itoa(exponent)
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *BitfinexWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	status, err := wrapper.api.Wallet.WithdrawCrypto(amount, coinTicker, bitfinex.WALLET_TRADING, destinationAddress)
+func (wrapper *BitfinexWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	status, err := wrapper.api.Wallet.WithdrawCrypto(toFloat(amount), coinTicker, bitfinex.WALLET_TRADING, destinationAddress)
 	if err != nil {
 		return err
 	}
@@ -435,16 +871,3 @@ func (wrapper *BitfinexWrapper) Withdraw(destinationAddress string, coinTicker s
 
 	return nil
 }
-
-func insertSort(data []environment.Order, el environment.Order, reverse bool) []environment.Order {
-	index := sort.Search(len(data), func(i int) bool {
-		if reverse {
-			return data[i].Value.LessThan(el.Value)
-		}
-		return data[i].Value.GreaterThan(el.Value)
-	})
-	data = append(data, environment.Order{})
-	copy(data[index+1:], data[index:])
-	data[index] = el
-	return data
-}
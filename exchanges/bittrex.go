@@ -16,7 +16,9 @@
 package exchanges
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/shopspring/decimal"
@@ -35,16 +37,21 @@ type BittrexWrapper struct {
 	candles             *CandlesCache
 	websocketOn         bool
 	unsubscribeChannels map[*environment.Market]chan bool
+	tradingRules        *TradingRulesCache
+	meter               *requestMeter // Meter of the requests made within the calls.
 	depositAddresses    map[string]string
 }
 
 // NewBittrexWrapper creates a generic wrapper of the bittrex API.
 func NewBittrexWrapper(publicKey string, secretKey string, depositAddresses map[string]string) ExchangeWrapper {
+	meter := newRequestMeter()
 	return &BittrexWrapper{
-		api:              api.New(publicKey, secretKey),
+		api:              api.NewWithCustomHttpClient(publicKey, secretKey, meter.httpClient()),
 		websocketOn:      false,
 		summaries:        NewSummaryCache(),
 		candles:          NewCandlesCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            meter,
 		depositAddresses: depositAddresses,
 	}
 }
@@ -58,6 +65,21 @@ func (wrapper *BittrexWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *BittrexWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *BittrexWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		OrderBook:   true,
+		LimitOrders: true,
+		Balance:     true,
+		Withdraw:    true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *BittrexWrapper) GetMarkets() ([]*environment.Market, error) {
 	bittrexMarkets, err := wrapper.api.GetMarkets()
@@ -66,17 +88,31 @@ func (wrapper *BittrexWrapper) GetMarkets() ([]*environment.Market, error) {
 	}
 	wrappedMarkets := make([]*environment.Market, 0, len(bittrexMarkets))
 	for _, market := range bittrexMarkets {
-		wrappedMarkets = append(wrappedMarkets, &environment.Market{
-			Name:           market.Symbol,
-			BaseCurrency:   market.BaseCurrencySymbol,
-			MarketCurrency: market.QuoteCurrencySymbol,
-		})
+		wrappedMarket := listedMarket(wrapper, market.Symbol, market.BaseCurrencySymbol, market.QuoteCurrencySymbol)
+		wrappedMarket.Rules = environment.TradingRules{
+			TickSize:    stepOf(int(market.Precision)),
+			MinQuantity: market.MinTradeSize,
+		}
+		wrapper.tradingRules.Set(market.Symbol, wrappedMarket.Rules)
+		wrappedMarkets = append(wrappedMarkets, wrappedMarket)
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 	return wrappedMarkets, nil
 }
 
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *BittrexWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *BittrexWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
@@ -103,14 +139,24 @@ func (wrapper *BittrexWrapper) GetOrderBook(market *environment.Market) (*enviro
 	return &orderBook, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *BittrexWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, notSupported(wrapper, "public trades")
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *BittrexWrapper) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
+func (wrapper *BittrexWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
 	orderNumber, err := wrapper.api.CreateOrder(bittrex.CreateOrderParams{
 		Type:         bittrex.LIMIT,
 		TimeInForce:  bittrex.GOOD_TIL_CANCELLED,
 		MarketSymbol: MarketNameFor(market, wrapper),
-		Quantity:     decimal.NewFromFloat(amount),
-		Limit:        decimal.NewFromFloat(limit),
+		Quantity:     amount,
+		Limit:        limit,
 		Direction:    bittrex.BUY,
 	})
 
@@ -118,13 +164,18 @@ func (wrapper *BittrexWrapper) BuyLimit(market *environment.Market, amount float
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *BittrexWrapper) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
+func (wrapper *BittrexWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
 	orderNumber, err := wrapper.api.CreateOrder(bittrex.CreateOrderParams{
 		Type:         bittrex.LIMIT,
 		TimeInForce:  bittrex.GOOD_TIL_CANCELLED,
 		MarketSymbol: MarketNameFor(market, wrapper),
-		Quantity:     decimal.NewFromFloat(amount),
-		Limit:        decimal.NewFromFloat(limit),
+		Quantity:     amount,
+		Limit:        limit,
 		Direction:    bittrex.SELL,
 	})
 
@@ -132,13 +183,99 @@ func (wrapper *BittrexWrapper) SellLimit(market *environment.Market, amount floa
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *BittrexWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not supported on bittrex")
+func (wrapper *BittrexWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *BittrexWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not supported on bittrex")
+func (wrapper *BittrexWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *BittrexWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	_, err := wrapper.api.CancelOrder(orderID)
+	return err
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *BittrexWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	openOrders, err := wrapper.api.GetOpenOrders(MarketNameFor(market, wrapper))
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range openOrders {
+		if order.ID == orderID {
+			return convertFromBittrexOrder(market, order), nil
+		}
+	}
+
+	closedOrders, err := wrapper.api.GetClosedOrders(MarketNameFor(market, wrapper))
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range closedOrders {
+		if order.ID == orderID {
+			return convertFromBittrexOrder(market, order), nil
+		}
+	}
+
+	return nil, ErrOrderNotFound
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *BittrexWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	openOrders, err := wrapper.api.GetOpenOrders(MarketNameFor(market, wrapper))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, len(openOrders))
+	for i, order := range openOrders {
+		ret[i] = convertFromBittrexOrder(market, order)
+	}
+
+	return ret, nil
+}
+
+// convertFromBittrexOrder converts a bittrex order to a environment.OrderInfo.
+func convertFromBittrexOrder(market *environment.Market, order api.OrderV3) *environment.OrderInfo {
+	averagePrice := decimal.Zero
+	if !order.FillQuantity.IsZero() {
+		averagePrice = order.Proceeds.Div(order.FillQuantity)
+	}
+
+	side := environment.Bid
+	if order.Direction == string(bittrex.SELL) {
+		side = environment.Ask
+	}
+
+	var status environment.OrderStatus
+	if order.Status == "OPEN" {
+		if order.FillQuantity.IsZero() {
+			status = environment.OrderStatusOpen
+		} else {
+			status = environment.OrderStatusPartiallyFilled
+		}
+	} else if order.FillQuantity.LessThan(order.Quantity) {
+		status = environment.OrderStatusCancelled
+	} else {
+		status = environment.OrderStatusFilled
+	}
+
+	return &environment.OrderInfo{
+		ID:               order.ID,
+		Market:           market,
+		Side:             side,
+		Status:           status,
+		Price:            order.Limit,
+		Quantity:         order.Quantity,
+		FilledQuantity:   order.FillQuantity,
+		AverageFillPrice: averagePrice,
+		Fee:              order.Commission,
+		FeeCurrency:      market.MarketCurrency, // the commission is charged in the quote currency.
+		Timestamp:        order.CreatedAt,
+	}
 }
 
 // GetTicker gets the updated ticker for a market.
@@ -197,8 +334,13 @@ func convertFromBittrexCandle(candle api.Candle) environment.CandleStick {
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *BittrexWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	panic("Not supported in Bittrex V1")
+func (wrapper *BittrexWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *BittrexWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
 }
 
 // GetBalance gets the balance of the user of the specified currency.
@@ -220,7 +362,7 @@ func (wrapper *BittrexWrapper) GetDepositAddress(coinTicker string) (string, boo
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Bittrex fees are hardcoded due to the inability to obtain them via API before placing an order.
-func (wrapper *BittrexWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *BittrexWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0025
@@ -230,12 +372,12 @@ func (wrapper *BittrexWrapper) CalculateTradingFees(market *environment.Market,
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *BittrexWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *BittrexWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the exchange.
@@ -243,16 +385,19 @@ func (wrapper *BittrexWrapper) FeedConnect(markets []*environment.Market) error
 	return ErrWebsocketNotSupported
 }
 
-// SubscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
-//
-//     NOTE: Not supported on Bittrex v1 API, use *BittrexWrapperV2.
-func (wrapper *BittrexWrapper) subscribeMarketSummaryFeed(market *environment.Market) {
-	panic(ErrWebsocketNotSupported)
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *BittrexWrapper) FeedDisconnect() error {
+	return ErrWebsocketNotSupported
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *BittrexWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return nil, notSupported(wrapper, "public trades")
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *BittrexWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	_, err := wrapper.api.Withdraw(destinationAddress, coinTicker, decimal.NewFromFloat(amount), "golang-crypto-trading-bot")
+func (wrapper *BittrexWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.api.Withdraw(destinationAddress, coinTicker, amount, "golang-crypto-trading-bot")
 	if err != nil {
 		return err
 	}
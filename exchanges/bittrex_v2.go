@@ -16,7 +16,8 @@
 package exchanges
 
 import (
-	"errors"
+	"context"
+	"time"
 
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/shopspring/decimal"
@@ -28,6 +29,8 @@ type BittrexWrapperV2 struct {
 	PublicKey        string
 	SecretKey        string
 	summaries        *SummaryCache
+	tradingRules     *TradingRulesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
 	depositAddresses map[string]string
 }
 
@@ -37,6 +40,8 @@ func NewBittrexV2Wrapper(publicKey string, secretKey string, depositAddresses ma
 		PublicKey:        publicKey,
 		SecretKey:        secretKey,
 		summaries:        NewSummaryCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            newRequestMeter(),
 		depositAddresses: depositAddresses,
 	}
 }
@@ -50,6 +55,18 @@ func (wrapper *BittrexWrapperV2) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *BittrexWrapperV2) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *BittrexWrapperV2) Capabilities() Capabilities {
+	return Capabilities{
+		Candles: true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *BittrexWrapperV2) GetMarkets() ([]*environment.Market, error) {
 	bittrexMarkets, err := bittrex.GetMarkets()
@@ -59,43 +76,77 @@ func (wrapper *BittrexWrapperV2) GetMarkets() ([]*environment.Market, error) {
 	wrappedMarkets := make([]*environment.Market, 0, len(bittrexMarkets))
 	for _, market := range bittrexMarkets {
 		if market.IsActive {
-			wrappedMarkets = append(wrappedMarkets, &environment.Market{
-				Name:           market.MarketName,
-				BaseCurrency:   market.BaseCurrency,
-				MarketCurrency: market.MarketCurrency,
-			})
+			// in bittrex v2 the base currency is the one used to price the market (e.g. BTC in BTC-ETH).
+			wrappedMarket := listedMarket(wrapper, market.MarketName, market.MarketCurrency, market.BaseCurrency)
+			wrappedMarket.Rules = environment.TradingRules{
+				MinQuantity: market.MinTradeSize,
+			}
+			wrapper.tradingRules.Set(market.MarketName, wrappedMarket.Rules)
+			wrappedMarkets = append(wrappedMarkets, wrappedMarket)
 		}
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 	return wrappedMarkets, nil
 }
 
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *BittrexWrapperV2) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *BittrexWrapperV2) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
 func (wrapper *BittrexWrapperV2) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
-	panic("GetOrderBook not implemented")
+	return nil, notSupported(wrapper, "order books")
+}
+
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *BittrexWrapperV2) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, notSupported(wrapper, "public trades")
 }
 
 // BuyLimit performs a limit buy action.
-func (wrapper *BittrexWrapperV2) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	return "", errors.New("BuyLimit not implemented")
+func (wrapper *BittrexWrapperV2) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "limit orders")
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *BittrexWrapperV2) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	return "", errors.New("BuyMarket not implemented")
+func (wrapper *BittrexWrapperV2) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *BittrexWrapperV2) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	return "", errors.New("SellLimit not implemented")
+func (wrapper *BittrexWrapperV2) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "limit orders")
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *BittrexWrapperV2) SellMarket(market *environment.Market, amount float64) (string, error) {
-	return "", errors.New("SellMarket not implemented")
+func (wrapper *BittrexWrapperV2) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *BittrexWrapperV2) CancelOrder(market *environment.Market, orderID string) error {
+	return notSupported(wrapper, "limit orders")
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *BittrexWrapperV2) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return nil, notSupported(wrapper, "limit orders")
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *BittrexWrapperV2) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return nil, notSupported(wrapper, "limit orders")
 }
 
 // GetMarketSummary gets the current market summary.
@@ -119,9 +170,23 @@ func (wrapper *BittrexWrapperV2) GetMarketSummary(market *environment.Market) (*
 	return ret, nil
 }
 
+// bittrexV2Intervals contains the codes of the candle intervals supported by the exchange.
+var bittrexV2Intervals = map[environment.Interval]string{
+	environment.Interval1m:  "oneMin",
+	environment.Interval5m:  "fiveMin",
+	environment.Interval30m: "thirtyMin",
+	environment.Interval1h:  "hour",
+	environment.Interval1d:  "day",
+}
+
 // GetCandles gets the candle data from the exchange.
-func (wrapper *BittrexWrapperV2) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	bittrexCandles, err := bittrex.GetTicks(MarketNameFor(market, wrapper), interval)
+func (wrapper *BittrexWrapperV2) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	source, err := sourceInterval(wrapper, bittrexV2Intervals, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	bittrexCandles, err := bittrex.GetTicks(MarketNameFor(market, wrapper), bittrexV2Intervals[source])
 	if err != nil {
 		return nil, err
 	}
@@ -129,21 +194,39 @@ func (wrapper *BittrexWrapperV2) GetCandles(market *environment.Market, interval
 	ret := make([]environment.CandleStick, len(bittrexCandles))
 
 	for i, bittrexCandle := range bittrexCandles {
+		openTime := time.Time(bittrexCandle.Timestamp)
 		ret[i] = environment.CandleStick{
-			High:   bittrexCandle.High,
-			Open:   bittrexCandle.Open,
-			Close:  bittrexCandle.Close,
-			Low:    bittrexCandle.Low,
-			Volume: bittrexCandle.BaseVolume,
+			High:      bittrexCandle.High,
+			Open:      bittrexCandle.Open,
+			Close:     bittrexCandle.Close,
+			Low:       bittrexCandle.Low,
+			Volume:    bittrexCandle.BaseVolume,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(source.Duration() - time.Millisecond),
 		}
 	}
 
+	if source != interval {
+		return environment.ResampleCandles(ret, interval)
+	}
 	return ret, nil
 }
 
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+//
+//     The exchange serves only the latest candles, older ones are not available.
+func (wrapper *BittrexWrapperV2) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	candles, err := wrapper.GetCandles(market, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return candlesInRange(candles, interval, interval, from, to)
+}
+
 // GetBalance gets the balance of the user of the specified currency.
 func (wrapper *BittrexWrapperV2) GetBalance(symbol string) (*decimal.Decimal, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "balances")
 }
 
 // GetDepositAddress gets the deposit address for the specified coin on the exchange.
@@ -155,7 +238,7 @@ func (wrapper *BittrexWrapperV2) GetDepositAddress(coinTicker string) (string, b
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Bittrex fees are hardcoded due to the inability to obtain them via API before placing an order.
-func (wrapper *BittrexWrapperV2) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *BittrexWrapperV2) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0025
@@ -165,12 +248,12 @@ func (wrapper *BittrexWrapperV2) CalculateTradingFees(market *environment.Market
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *BittrexWrapperV2) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *BittrexWrapperV2) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the exchange.
@@ -180,7 +263,17 @@ func (wrapper *BittrexWrapperV2) FeedConnect(markets []*environment.Market) erro
 	return ErrWebsocketNotSupported
 }
 
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *BittrexWrapperV2) FeedDisconnect() error {
+	return ErrWebsocketNotSupported
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *BittrexWrapperV2) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return nil, notSupported(wrapper, "public trades")
+}
+
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *BittrexWrapperV2) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	panic("Not Implemented")
+func (wrapper *BittrexWrapperV2) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return notSupported(wrapper, "withdrawals")
 }
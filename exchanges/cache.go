@@ -2,6 +2,7 @@ package exchanges
 
 import (
 	"sync"
+	"time"
 
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 )
@@ -10,6 +11,7 @@ import (
 type SummaryCache struct {
 	mutex    *sync.RWMutex
 	internal map[*environment.Market]*environment.MarketSummary
+	updated  map[*environment.Market]time.Time
 }
 
 // NewSummaryCache creates a new SummaryCache Object
@@ -17,15 +19,21 @@ func NewSummaryCache() *SummaryCache {
 	return &SummaryCache{
 		mutex:    &sync.RWMutex{},
 		internal: make(map[*environment.Market]*environment.MarketSummary),
+		updated:  make(map[*environment.Market]time.Time),
 	}
 }
 
-// Set sets a value for the specified key.
+// Set sets a value for the specified key, updating the indicators attached to the market
+// and notifying its feed listeners.
 func (sc *SummaryCache) Set(market *environment.Market, summary *environment.MarketSummary) *environment.MarketSummary {
 	sc.mutex.Lock()
 	old := sc.internal[market]
 	sc.internal[market] = summary
+	sc.updated[market] = now()
 	sc.mutex.Unlock()
+
+	updateIndicators(market, summary)
+	notifySummary(market, summary)
 	return old
 }
 
@@ -37,41 +45,67 @@ func (sc *SummaryCache) Get(market *environment.Market) (*environment.MarketSumm
 	return ret, isSet
 }
 
+// Updated gets the time of the last update of the value for the specified key, zero if not set.
+func (sc *SummaryCache) Updated(market *environment.Market) time.Time {
+	sc.mutex.RLock()
+	defer sc.mutex.RUnlock()
+	return sc.updated[market]
+}
+
 // CandlesCache represents a local candles cache for every exchange. To allow dinamic polling from multiple sources (REST + Websocket)
 type CandlesCache struct {
 	mutex    *sync.RWMutex
-	internal map[*environment.Market][]environment.CandleStick
+	internal map[*environment.Market]map[environment.Interval][]environment.CandleStick
+	updated  map[*environment.Market]map[environment.Interval]time.Time
 }
 
 // NewCandlesCache creates a new CandlesCache Object
 func NewCandlesCache() *CandlesCache {
 	return &CandlesCache{
 		mutex:    &sync.RWMutex{},
-		internal: make(map[*environment.Market][]environment.CandleStick),
+		internal: make(map[*environment.Market]map[environment.Interval][]environment.CandleStick),
+		updated:  make(map[*environment.Market]map[environment.Interval]time.Time),
 	}
 }
 
-// Set sets a value for the specified key.
-func (cc *CandlesCache) Set(market *environment.Market, candles []environment.CandleStick) []environment.CandleStick {
+// Set sets a value for the specified key, updating the candle indicators attached to the market.
+func (cc *CandlesCache) Set(market *environment.Market, interval environment.Interval, candles []environment.CandleStick) []environment.CandleStick {
 	cc.mutex.Lock()
-	old := cc.internal[market]
-	cc.internal[market] = candles
+	intervals, exists := cc.internal[market]
+	if !exists {
+		intervals = make(map[environment.Interval][]environment.CandleStick)
+		cc.internal[market] = intervals
+		cc.updated[market] = make(map[environment.Interval]time.Time)
+	}
+	old := intervals[interval]
+	intervals[interval] = candles
+	cc.updated[market][interval] = now()
 	cc.mutex.Unlock()
+
+	updateCandleIndicators(market, interval, candles)
 	return old
 }
 
 // Get gets the value for the specified key.
-func (cc *CandlesCache) Get(market *environment.Market) ([]environment.CandleStick, bool) {
+func (cc *CandlesCache) Get(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, bool) {
 	cc.mutex.RLock()
-	ret, isSet := cc.internal[market]
+	ret, isSet := cc.internal[market][interval]
 	cc.mutex.RUnlock()
 	return ret, isSet
 }
 
+// Updated gets the time of the last update of the value for the specified key, zero if not set.
+func (cc *CandlesCache) Updated(market *environment.Market, interval environment.Interval) time.Time {
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+	return cc.updated[market][interval]
+}
+
 // OrderbookCache represents a local orderbook cache for every exchange. To allow dinamic polling from multiple sources (REST + Websocket)
 type OrderbookCache struct {
 	mutex    *sync.RWMutex
 	internal map[*environment.Market]*environment.OrderBook
+	updated  map[*environment.Market]time.Time
 }
 
 // NewOrderbookCache creates a new OrderbookCache Object
@@ -79,15 +113,19 @@ func NewOrderbookCache() *OrderbookCache {
 	return &OrderbookCache{
 		mutex:    &sync.RWMutex{},
 		internal: make(map[*environment.Market]*environment.OrderBook),
+		updated:  make(map[*environment.Market]time.Time),
 	}
 }
 
-// Set sets a value for the specified key.
+// Set sets a value for the specified key, notifying the feed listeners of the market.
 func (cc *OrderbookCache) Set(market *environment.Market, book *environment.OrderBook) *environment.OrderBook {
 	cc.mutex.Lock()
 	old := cc.internal[market]
 	cc.internal[market] = book
+	cc.updated[market] = now()
 	cc.mutex.Unlock()
+
+	notifyOrderBook(market, book)
 	return old
 }
 
@@ -98,3 +136,10 @@ func (cc *OrderbookCache) Get(market *environment.Market) (*environment.OrderBoo
 	cc.mutex.RUnlock()
 	return ret, isSet
 }
+
+// Updated gets the time of the last update of the value for the specified key, zero if not set.
+func (cc *OrderbookCache) Updated(market *environment.Market) time.Time {
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+	return cc.updated[market]
+}
@@ -0,0 +1,308 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// CandleCloseFunc is called with every candle closed by a candle builder.
+type CandleCloseFunc func(market *environment.Market, interval environment.Interval, candle environment.CandleStick)
+
+// CandleBuilder builds in real time the candles of a market from its public trades, for a set of intervals.
+//
+//     A candle is closed by the first trade after its period or, at the latest, when its period is over
+//     on the clock of the wrappers, in both cases after the close delay: the periods without trades get
+//     flat candles at the last price. Closed candles update the candle indicators attached to the market.
+//     The trades older than the open candles are late: they are dropped, counted and logged.
+type CandleBuilder struct {
+	mutex      *sync.Mutex
+	market     *environment.Market
+	intervals  []environment.Interval
+	open       map[environment.Interval][]*environment.CandleStick // The candles still open, oldest first, after the first trade.
+	closeDelay time.Duration                                       // Grace period for the late trades after the end of a candle.
+	lateTrades int64                                               // Number of late trades dropped.
+	loggedLate int64                                               // Number of late trades dropped when last logged.
+	callbacks  []CandleCloseFunc
+	triggers   map[environment.Interval][]chan struct{}
+}
+
+// closedCandle is a candle closed by a builder, to be notified.
+type closedCandle struct {
+	interval environment.Interval
+	candle   environment.CandleStick
+}
+
+// NewCandleBuilder creates a builder of the candles of a market for the specified intervals.
+func NewCandleBuilder(market *environment.Market, intervals ...environment.Interval) (*CandleBuilder, error) {
+	for _, interval := range intervals {
+		if interval.Duration() == 0 {
+			return nil, fmt.Errorf("%w: %s candles built from trades", ErrUnsupportedInterval, interval)
+		}
+	}
+
+	return &CandleBuilder{
+		mutex:     &sync.Mutex{},
+		market:    market,
+		intervals: intervals,
+		open:      make(map[environment.Interval][]*environment.CandleStick),
+		triggers:  make(map[environment.Interval][]chan struct{}),
+	}, nil
+}
+
+// SetCloseDelay sets how long the candles are kept open after the end of their period, waiting for the trades
+// received late or timestamped by a clock skewed from the one of the wrappers (no delay by default).
+func (builder *CandleBuilder) SetCloseDelay(delay time.Duration) {
+	builder.mutex.Lock()
+	builder.closeDelay = delay
+	builder.mutex.Unlock()
+}
+
+// LateTrades gets the number of trades dropped so far because older than the open candles.
+//
+//     The trades dropped by a full trade subscription are counted by its Dropped method.
+func (builder *CandleBuilder) LateTrades() int64 {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	return builder.lateTrades
+}
+
+// OnClose adds a callback called with every closed candle.
+//
+//     Callbacks are called synchronously, in the order the candles are closed, and must not block.
+func (builder *CandleBuilder) OnClose(callback CandleCloseFunc) {
+	builder.mutex.Lock()
+	builder.callbacks = append(builder.callbacks, callback)
+	builder.mutex.Unlock()
+}
+
+// Trigger gets a channel receiving a value when a candle of the interval closes, which can be used as
+// the Trigger of an interval strategy to update it on candle close.
+//
+//     The candles closed while the channel is not read are signaled once.
+func (builder *CandleBuilder) Trigger(interval environment.Interval) <-chan struct{} {
+	trigger := make(chan struct{}, 1)
+
+	builder.mutex.Lock()
+	builder.triggers[interval] = append(builder.triggers[interval], trigger)
+	builder.mutex.Unlock()
+
+	return trigger
+}
+
+// Current gets the latest candle of the interval still open, false if no trade has been added yet.
+func (builder *CandleBuilder) Current(interval environment.Interval) (environment.CandleStick, bool) {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	candles := builder.open[interval]
+	if len(candles) == 0 {
+		return environment.CandleStick{}, false
+	}
+	return *candles[len(candles)-1], true
+}
+
+// AddTrade adds a trade to the open candle of its period, closing first the candles whose period
+// (plus the close delay) is over at its time.
+//
+//     Trades older than the open candles of an interval are dropped as late for that interval.
+func (builder *CandleBuilder) AddTrade(trade environment.Trade) {
+	builder.mutex.Lock()
+	closed := builder.closeUntil(trade.Timestamp)
+	late := false
+	for _, interval := range builder.intervals {
+		step := interval.Duration()
+		candles := builder.open[interval]
+		if len(candles) == 0 {
+			openTime := trade.Timestamp.Truncate(step)
+			builder.open[interval] = []*environment.CandleStick{{
+				High:       trade.Price,
+				Open:       trade.Price,
+				Close:      trade.Price,
+				Low:        trade.Price,
+				Volume:     trade.Quantity,
+				OpenTime:   openTime,
+				CloseTime:  openTime.Add(step - time.Millisecond),
+				TradeCount: 1,
+			}}
+			continue
+		}
+		if trade.Timestamp.Before(candles[0].OpenTime) {
+			late = true
+			continue
+		}
+
+		for last := candles[len(candles)-1]; !trade.Timestamp.Before(last.OpenTime.Add(step)); last = candles[len(candles)-1] {
+			candles = append(candles, flatCandle(last, step))
+		}
+		builder.open[interval] = candles
+
+		candle := candles[int(trade.Timestamp.Sub(candles[0].OpenTime)/step)]
+		if candle.TradeCount == 0 { // the first trade of a period without trades so far.
+			candle.Open = trade.Price
+			candle.High = trade.Price
+			candle.Low = trade.Price
+		}
+		candle.High = decimal.Max(candle.High, trade.Price)
+		candle.Low = decimal.Min(candle.Low, trade.Price)
+		candle.Close = trade.Price
+		candle.Volume = candle.Volume.Add(trade.Quantity)
+		candle.TradeCount++
+	}
+	if late {
+		builder.lateTrades++
+	}
+	builder.mutex.Unlock()
+
+	builder.notify(closed)
+}
+
+// CloseUntil closes the candles whose period (plus the close delay) is over at the specified time.
+func (builder *CandleBuilder) CloseUntil(t time.Time) {
+	builder.mutex.Lock()
+	closed := builder.closeUntil(t)
+	builder.mutex.Unlock()
+
+	builder.notify(closed)
+}
+
+// Run adds the trades received from a channel, e.g. the one returned by SubscribeTrades, closing the candles
+// when their period is over even without new trades, until the channel is closed.
+func (builder *CandleBuilder) Run(trades <-chan environment.Trade) {
+	var deadline time.Time
+	var timer <-chan time.Time
+	for {
+		if next, exists := builder.nextClose(); exists && !next.Equal(deadline) {
+			deadline = next
+			timer = getClock().After(deadline.Sub(now()))
+		}
+
+		select {
+		case trade, stillOpen := <-trades:
+			if !stillOpen {
+				return
+			}
+			builder.AddTrade(trade)
+		case <-timer:
+			builder.CloseUntil(now())
+			deadline = time.Time{}
+		}
+	}
+}
+
+// nextClose gets the time the first open candle to close is closed at, false if there is none.
+func (builder *CandleBuilder) nextClose() (time.Time, bool) {
+	builder.mutex.Lock()
+	defer builder.mutex.Unlock()
+
+	var ret time.Time
+	for interval, candles := range builder.open {
+		if len(candles) == 0 {
+			continue
+		}
+		end := candles[0].OpenTime.Add(interval.Duration() + builder.closeDelay)
+		if ret.IsZero() || end.Before(ret) {
+			ret = end
+		}
+	}
+	return ret, !ret.IsZero()
+}
+
+// closeUntil closes the open candles whose period plus the close delay is over at the specified time,
+// opening the candles of the following periods flat at their close price.
+func (builder *CandleBuilder) closeUntil(t time.Time) []closedCandle {
+	var closed []closedCandle
+	for _, interval := range builder.intervals {
+		candles := builder.open[interval]
+		if len(candles) == 0 {
+			continue
+		}
+
+		step := interval.Duration()
+		for !t.Before(candles[0].OpenTime.Add(step + builder.closeDelay)) {
+			candle := candles[0]
+			closed = append(closed, closedCandle{
+				interval: interval,
+				candle:   *candle,
+			})
+
+			candles = candles[1:]
+			if len(candles) == 0 {
+				candles = append(candles, flatCandle(candle, step))
+			} else if next := candles[0]; next.TradeCount == 0 { // opened before the close price was final.
+				next.Open, next.High, next.Low, next.Close = candle.Close, candle.Close, candle.Close, candle.Close
+			}
+		}
+		builder.open[interval] = candles
+	}
+	return closed
+}
+
+// flatCandle opens the candle of the period following a candle, flat at its close price.
+func flatCandle(previous *environment.CandleStick, step time.Duration) *environment.CandleStick {
+	openTime := previous.OpenTime.Add(step)
+	return &environment.CandleStick{
+		High:      previous.Close,
+		Open:      previous.Close,
+		Close:     previous.Close,
+		Low:       previous.Close,
+		Volume:    decimal.Zero,
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(step - time.Millisecond),
+	}
+}
+
+// notify updates the attached candle indicators with the closed candles, then calls the callbacks
+// and signals the triggers of their intervals.
+func (builder *CandleBuilder) notify(closed []closedCandle) {
+	if len(closed) == 0 {
+		return
+	}
+
+	builder.mutex.Lock()
+	callbacks := builder.callbacks
+	triggers := make(map[environment.Interval][]chan struct{}, len(builder.triggers))
+	for interval, channels := range builder.triggers {
+		triggers[interval] = channels
+	}
+	late := builder.lateTrades - builder.loggedLate
+	builder.loggedLate = builder.lateTrades
+	builder.mutex.Unlock()
+
+	if late > 0 {
+		logrus.Warnf("%d late trades of %s dropped by the candle builder, consider a longer close delay", late, builder.market)
+	}
+
+	for _, c := range closed {
+		updateClosedCandleIndicators(builder.market, c.interval, []environment.CandleStick{c.candle})
+		for _, callback := range callbacks {
+			callback(builder.market, c.interval, c.candle)
+		}
+		for _, trigger := range triggers[c.interval] {
+			select {
+			case trigger <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
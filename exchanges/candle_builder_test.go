@@ -0,0 +1,134 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// builderEvent is a trade added to a candle builder, or a call to CloseUntil if price is empty.
+type builderEvent struct {
+	at       time.Duration // Time of the event since the start of the test.
+	price    string
+	quantity string
+}
+
+// formatCandle writes a candle as "open time open/high/low/close volume trades".
+func formatCandle(candle environment.CandleStick) string {
+	return fmt.Sprintf("%s %s/%s/%s/%s %s %d", candle.OpenTime.Format("15:04"),
+		candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, candle.TradeCount)
+}
+
+func TestCandleBuilder(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		closeDelay  time.Duration
+		events      []builderEvent
+		wantClosed  []string
+		wantCurrent string
+		wantLate    int64
+	}{
+		{
+			name:        "trades within a candle",
+			events:      []builderEvent{{10 * time.Second, "100", "1"}, {20 * time.Second, "102", "1"}, {50 * time.Second, "99", "2"}},
+			wantCurrent: "00:00 100/102/99/99 4 3",
+		},
+		{
+			name:        "trade closing a candle",
+			events:      []builderEvent{{10 * time.Second, "100", "1"}, {70 * time.Second, "101", "1"}},
+			wantClosed:  []string{"00:00 100/100/100/100 1 1"},
+			wantCurrent: "00:01 101/101/101/101 1 1",
+		},
+		{
+			name:        "periods without trades",
+			events:      []builderEvent{{10 * time.Second, "100", "1"}, {190 * time.Second, "103", "1"}},
+			wantClosed:  []string{"00:00 100/100/100/100 1 1", "00:01 100/100/100/100 0 0", "00:02 100/100/100/100 0 0"},
+			wantCurrent: "00:03 103/103/103/103 1 1",
+		},
+		{
+			name:        "candles closed by the clock",
+			events:      []builderEvent{{10 * time.Second, "100", "1"}, {at: 120 * time.Second}},
+			wantClosed:  []string{"00:00 100/100/100/100 1 1", "00:01 100/100/100/100 0 0"},
+			wantCurrent: "00:02 100/100/100/100 0 0",
+		},
+		{
+			name:        "late trade",
+			events:      []builderEvent{{70 * time.Second, "100", "1"}, {30 * time.Second, "99", "1"}},
+			wantCurrent: "00:01 100/100/100/100 1 1",
+			wantLate:    1,
+		},
+		{
+			name:       "trade within the close delay",
+			closeDelay: 5 * time.Second,
+			events: []builderEvent{
+				{10 * time.Second, "100", "1"}, {62 * time.Second, "101", "1"}, {59 * time.Second, "98", "1"}, {at: 65 * time.Second},
+			},
+			wantClosed:  []string{"00:00 100/100/98/98 2 2"},
+			wantCurrent: "00:01 101/101/101/101 1 1",
+		},
+		{
+			name:       "flat candle following a trade within the close delay",
+			closeDelay: 90 * time.Second,
+			events: []builderEvent{
+				{10 * time.Second, "100", "1"}, {130 * time.Second, "105", "1"}, {50 * time.Second, "98", "1"}, {at: 210 * time.Second},
+			},
+			wantClosed:  []string{"00:00 100/100/98/98 2 2", "00:01 98/98/98/98 0 0"},
+			wantCurrent: "00:02 105/105/105/105 1 1",
+		},
+	}
+
+	for _, test := range tests {
+		builder, err := NewCandleBuilder(&environment.Market{Name: "ETH-BTC"}, environment.Interval1m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		builder.SetCloseDelay(test.closeDelay)
+		var closed []string
+		builder.OnClose(func(market *environment.Market, interval environment.Interval, candle environment.CandleStick) {
+			closed = append(closed, formatCandle(candle))
+		})
+
+		for _, event := range test.events {
+			if event.price == "" {
+				builder.CloseUntil(start.Add(event.at))
+				continue
+			}
+			builder.AddTrade(environment.Trade{
+				Price:     decimal.RequireFromString(event.price),
+				Quantity:  decimal.RequireFromString(event.quantity),
+				Timestamp: start.Add(event.at),
+			})
+		}
+
+		if got, want := strings.Join(closed, ", "), strings.Join(test.wantClosed, ", "); got != want {
+			t.Errorf("%s: closed [%s], want [%s]", test.name, got, want)
+		}
+		current, _ := builder.Current(environment.Interval1m)
+		if got := formatCandle(current); got != test.wantCurrent {
+			t.Errorf("%s: current %s, want %s", test.name, got, test.wantCurrent)
+		}
+		if late := builder.LateTrades(); late != test.wantLate {
+			t.Errorf("%s: %d late trades, want %d", test.name, late, test.wantLate)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"sync"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/clock"
+)
+
+// wrappersClock contains the clock used by the wrappers to get the current time.
+var wrappersClock = struct {
+	mutex sync.RWMutex
+	clock clock.Clock
+}{
+	clock: clock.Real{},
+}
+
+// SetClock sets the clock used by the wrappers to get the current time, nil restores the wall clock.
+func SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real{}
+	}
+	wrappersClock.mutex.Lock()
+	wrappersClock.clock = c
+	wrappersClock.mutex.Unlock()
+}
+
+// getClock gets the clock used by the wrappers.
+func getClock() clock.Clock {
+	wrappersClock.mutex.RLock()
+	defer wrappersClock.mutex.RUnlock()
+
+	return wrappersClock.clock
+}
+
+// now gets the current time of the clock used by the wrappers.
+func now() time.Time {
+	return getClock().Now()
+}
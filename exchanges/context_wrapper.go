@@ -0,0 +1,501 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrOutcomeUnknown is the error representing a call which is not idempotent (orders, cancellations and withdrawals)
+// interrupted by its context after being sent to the exchange: it may have been executed anyway, so the state
+// of the account must be checked (e.g. with GetOpenOrders or GetBalance) before trying it again.
+var ErrOutcomeUnknown = errors.New("Outcome of the call unknown")
+
+// ContextExchangeWrapper provides the context-aware variants of the ExchangeWrapper operations:
+// they return as soon as the context is done, with the error of the context, or with ErrOutcomeUnknown
+// for the orders, cancellations and withdrawals already sent to the exchange.
+type ContextExchangeWrapper interface {
+	ExchangeWrapper
+
+	GetMarketsContext(ctx context.Context) ([]*environment.Market, error)                                                                // Gets the markets of the exchange.
+	GetCandlesContext(ctx context.Context, market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) // Gets the candle data from the exchange.
+	GetMarketSummaryContext(ctx context.Context, market *environment.Market) (*environment.MarketSummary, error)                         // Gets the current market summary.
+	GetOrderBookContext(ctx context.Context, market *environment.Market) (*environment.OrderBook, error)                                 // Gets the order(ASK + BID) book of a market.
+	GetRecentTradesContext(ctx context.Context, market *environment.Market) ([]environment.Trade, error)                                 // Gets the last public trades of a market, oldest first.
+	GetListPriceChangeStatsContext(ctx context.Context) (environment.ListPriceChangeStats, error)                                        // Gets the price change statistics of the markets.
+
+	GetCandlesRangeContext(ctx context.Context, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) // Gets the candles opening in the [from, to) time range.
+
+	BuyLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error)  // Performs a limit buy action.
+	SellLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) // Performs a limit sell action.
+	BuyMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error)                        // Performs a market buy action.
+	SellMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error)                       // Performs a market sell action.
+
+	CancelOrderContext(ctx context.Context, market *environment.Market, orderID string) error                        // Cancels an open order.
+	GetOrderContext(ctx context.Context, market *environment.Market, orderID string) (*environment.OrderInfo, error) // Gets the current status of an order.
+	GetOpenOrdersContext(ctx context.Context, market *environment.Market) ([]*environment.OrderInfo, error)          // Gets the orders of the user still open on a market.
+
+	GetBalanceContext(ctx context.Context, symbol string) (*decimal.Decimal, error) // Gets the balance of the user of the specified currency.
+
+	WithdrawContext(ctx context.Context, destinationAddress string, coinTicker string, amount decimal.Decimal) error // Performs a withdraw operation from the exchange to a destination address.
+}
+
+// ContextWrapper wraps another wrapper, adding the context-aware variants of its operations
+// and enforcing a timeout on each call to the exchange.
+//
+//     The context is propagated to the calls of the wrapped wrapper if it is a ContextExchangeWrapper
+//     (only Binance among the exchanges), so that the request to the exchange is cancelled too;
+//     otherwise the calls are left running in background when the context is done.
+//     The calls which are not idempotent fail with ErrOutcomeUnknown when the context is done after
+//     they are sent, since they may still be executed by the exchange: when left running in background
+//     their late outcome is logged.
+type ContextWrapper struct {
+	innerWrapper ExchangeWrapper
+	timeout      time.Duration // Maximum duration of each call, no limit if zero.
+}
+
+// NewContextWrapper creates a new context-aware wrapper from another wrapper, with the specified timeout
+// for each call (no limit if zero).
+func NewContextWrapper(wrapper ExchangeWrapper, timeout time.Duration) *ContextWrapper {
+	return &ContextWrapper{
+		innerWrapper: wrapper,
+		timeout:      timeout,
+	}
+}
+
+// ContextAware gets the context-aware variant of a wrapper: the wrapper itself if it is
+// a ContextExchangeWrapper, a ContextWrapper without timeout otherwise.
+func ContextAware(wrapper ExchangeWrapper) ContextExchangeWrapper {
+	if ret, ok := wrapper.(ContextExchangeWrapper); ok {
+		return ret
+	}
+	return NewContextWrapper(wrapper, 0)
+}
+
+// wrapped gets the wrapper this wrapper adds the context-aware operations to.
+func (wrapper *ContextWrapper) wrapped() ExchangeWrapper {
+	return wrapper.innerWrapper
+}
+
+// callResult contains the results of a call to a wrapper.
+type callResult struct {
+	value    interface{}
+	err      error
+	panicked interface{} // The value of the panic of the call, if any.
+}
+
+// call executes an operation of the inner wrapper, returning early with the error of the context if it is done first,
+// or with ErrOutcomeUnknown if the operation is not idempotent and it was already sent.
+//
+//     The timeout of the wrapper is applied to the context, which is passed to native, the context-aware
+//     variant of the call, if the inner wrapper supports it, or fallback is run in background otherwise:
+//     its panics are raised again in the calling goroutine.
+func (wrapper *ContextWrapper) call(ctx context.Context, operation string, idempotent bool, native func(context.Context, ContextExchangeWrapper) (interface{}, error), fallback func() (interface{}, error)) (interface{}, error) {
+	if wrapper.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wrapper.timeout)
+		defer cancel()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if inner, ok := wrapper.innerWrapper.(ContextExchangeWrapper); ok {
+		ret, err := native(ctx, inner)
+		if err != nil && !idempotent && ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %s on %s interrupted: %s", ErrOutcomeUnknown, operation, wrapper.Name(), ctx.Err())
+		}
+		return ret, err
+	}
+	results := make(chan callResult, 1)
+	go func() {
+		var result callResult
+		defer func() {
+			result.panicked = recover()
+			results <- result
+		}()
+		result.value, result.err = fallback()
+	}()
+
+	select {
+	case <-ctx.Done():
+		if idempotent {
+			return nil, ctx.Err()
+		}
+		go wrapper.logLateOutcome(operation, results)
+		return nil, fmt.Errorf("%w: %s on %s still running: %s", ErrOutcomeUnknown, operation, wrapper.Name(), ctx.Err())
+	case result := <-results:
+		if result.panicked != nil {
+			panic(result.panicked)
+		}
+		return result.value, result.err
+	}
+}
+
+// logLateOutcome waits for the result of an operation abandoned by call and logs it.
+func (wrapper *ContextWrapper) logLateOutcome(operation string, results <-chan callResult) {
+	result := <-results
+	switch {
+	case result.panicked != nil:
+		logrus.Errorf("%s on %s panicked after its context was done: %v", operation, wrapper.Name(), result.panicked)
+	case result.err != nil:
+		logrus.Warnf("%s on %s failed after its context was done: %s", operation, wrapper.Name(), result.err)
+	case result.value != nil:
+		logrus.Warnf("%s on %s succeeded after its context was done: %v", operation, wrapper.Name(), result.value)
+	default:
+		logrus.Warnf("%s on %s succeeded after its context was done", operation, wrapper.Name())
+	}
+}
+
+// Name gets the name of the wrapped exchange.
+func (wrapper *ContextWrapper) Name() string {
+	return wrapper.innerWrapper.Name()
+}
+
+// String returns a string representation of the object.
+func (wrapper *ContextWrapper) String() string {
+	return wrapper.innerWrapper.String()
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *ContextWrapper) Capabilities() Capabilities {
+	return wrapper.innerWrapper.Capabilities()
+}
+
+// GetMarkets gets all the markets info.
+func (wrapper *ContextWrapper) GetMarkets() ([]*environment.Market, error) {
+	return wrapper.GetMarketsContext(context.Background())
+}
+
+// GetMarketsContext gets all the markets info, until the context is done.
+func (wrapper *ContextWrapper) GetMarketsContext(ctx context.Context) ([]*environment.Market, error) {
+	ret, err := wrapper.call(ctx, "GetMarkets", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetMarketsContext(ctx)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetMarkets()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]*environment.Market), nil
+}
+
+// GetCandles gets the candle data from the exchange.
+func (wrapper *ContextWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesContext(context.Background(), market, interval)
+}
+
+// GetCandlesContext gets the candle data from the exchange, until the context is done.
+func (wrapper *ContextWrapper) GetCandlesContext(ctx context.Context, market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	ret, err := wrapper.call(ctx, "GetCandles", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetCandlesContext(ctx, market, interval)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetCandles(market, interval)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.CandleStick), nil
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *ContextWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesRangeContext(context.Background(), market, interval, from, to)
+}
+
+// GetCandlesRangeContext gets the candles opening in the [from, to) time range from the exchange, until the context is done.
+func (wrapper *ContextWrapper) GetCandlesRangeContext(ctx context.Context, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	ret, err := wrapper.call(ctx, "GetCandlesRange", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetCandlesRangeContext(ctx, market, interval, from, to)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetCandlesRange(market, interval, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.CandleStick), nil
+}
+
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *ContextWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.innerWrapper.GetTradingRules(market)
+}
+
+// GetMarketSummary gets the current market summary.
+func (wrapper *ContextWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
+	return wrapper.GetMarketSummaryContext(context.Background(), market)
+}
+
+// GetMarketSummaryContext gets the current market summary, until the context is done.
+func (wrapper *ContextWrapper) GetMarketSummaryContext(ctx context.Context, market *environment.Market) (*environment.MarketSummary, error) {
+	ret, err := wrapper.call(ctx, "GetMarketSummary", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetMarketSummaryContext(ctx, market)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetMarketSummary(market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.MarketSummary), nil
+}
+
+// GetOrderBook gets the order(ASK + BID) book of a market.
+func (wrapper *ContextWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	return wrapper.GetOrderBookContext(context.Background(), market)
+}
+
+// GetOrderBookContext gets the order(ASK + BID) book of a market, until the context is done.
+func (wrapper *ContextWrapper) GetOrderBookContext(ctx context.Context, market *environment.Market) (*environment.OrderBook, error) {
+	ret, err := wrapper.call(ctx, "GetOrderBook", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetOrderBookContext(ctx, market)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetOrderBook(market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.OrderBook), nil
+}
+
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *ContextWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return wrapper.GetRecentTradesContext(context.Background(), market)
+}
+
+// GetRecentTradesContext gets the last public trades of a market, oldest first, until the context is done.
+func (wrapper *ContextWrapper) GetRecentTradesContext(ctx context.Context, market *environment.Market) ([]environment.Trade, error) {
+	ret, err := wrapper.call(ctx, "GetRecentTrades", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetRecentTradesContext(ctx, market)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetRecentTrades(market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.Trade), nil
+}
+
+// GetListPriceChangeStats gets the price change statistics of the markets.
+func (wrapper *ContextWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
+	return wrapper.GetListPriceChangeStatsContext(context.Background())
+}
+
+// GetListPriceChangeStatsContext gets the price change statistics of the markets, until the context is done.
+func (wrapper *ContextWrapper) GetListPriceChangeStatsContext(ctx context.Context) (environment.ListPriceChangeStats, error) {
+	ret, err := wrapper.call(ctx, "GetListPriceChangeStats", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetListPriceChangeStatsContext(ctx)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetListPriceChangeStats()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(environment.ListPriceChangeStats), nil
+}
+
+// BuyLimit performs a limit buy action.
+func (wrapper *ContextWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.BuyLimitContext(context.Background(), market, amount, limit)
+}
+
+// BuyLimitContext performs a limit buy action, until the context is done.
+func (wrapper *ContextWrapper) BuyLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "BuyLimit", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.BuyLimitContext(ctx, market, amount, limit)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.BuyLimit(market, amount, limit)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// SellLimit performs a limit sell action.
+func (wrapper *ContextWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.SellLimitContext(context.Background(), market, amount, limit)
+}
+
+// SellLimitContext performs a limit sell action, until the context is done.
+func (wrapper *ContextWrapper) SellLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "SellLimit", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.SellLimitContext(ctx, market, amount, limit)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.SellLimit(market, amount, limit)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// BuyMarket performs a market buy action.
+func (wrapper *ContextWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.BuyMarketContext(context.Background(), market, amount)
+}
+
+// BuyMarketContext performs a market buy action, until the context is done.
+func (wrapper *ContextWrapper) BuyMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "BuyMarket", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.BuyMarketContext(ctx, market, amount)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.BuyMarket(market, amount)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// SellMarket performs a market sell action.
+func (wrapper *ContextWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.SellMarketContext(context.Background(), market, amount)
+}
+
+// SellMarketContext performs a market sell action, until the context is done.
+func (wrapper *ContextWrapper) SellMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "SellMarket", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.SellMarketContext(ctx, market, amount)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.SellMarket(market, amount)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *ContextWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	return wrapper.CancelOrderContext(context.Background(), market, orderID)
+}
+
+// CancelOrderContext cancels an open order, until the context is done.
+func (wrapper *ContextWrapper) CancelOrderContext(ctx context.Context, market *environment.Market, orderID string) error {
+	_, err := wrapper.call(ctx, "CancelOrder", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return nil, inner.CancelOrderContext(ctx, market, orderID)
+	}, func() (interface{}, error) {
+		return nil, wrapper.innerWrapper.CancelOrder(market, orderID)
+	})
+	return err
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *ContextWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return wrapper.GetOrderContext(context.Background(), market, orderID)
+}
+
+// GetOrderContext gets the current status of an order, until the context is done.
+func (wrapper *ContextWrapper) GetOrderContext(ctx context.Context, market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	ret, err := wrapper.call(ctx, "GetOrder", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetOrderContext(ctx, market, orderID)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetOrder(market, orderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.OrderInfo), nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *ContextWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return wrapper.GetOpenOrdersContext(context.Background(), market)
+}
+
+// GetOpenOrdersContext gets the orders of the user still open on a market, until the context is done.
+func (wrapper *ContextWrapper) GetOpenOrdersContext(ctx context.Context, market *environment.Market) ([]*environment.OrderInfo, error) {
+	ret, err := wrapper.call(ctx, "GetOpenOrders", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetOpenOrdersContext(ctx, market)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetOpenOrders(market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]*environment.OrderInfo), nil
+}
+
+// CalculateTradingFees calculates the trading fees for an order on a specified market.
+func (wrapper *ContextWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
+	return wrapper.innerWrapper.CalculateTradingFees(market, amount, limit, orderType)
+}
+
+// CalculateWithdrawFees calculates the withdrawal fees on a specified market.
+func (wrapper *ContextWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return wrapper.innerWrapper.CalculateWithdrawFees(market, amount)
+}
+
+// GetBalance gets the balance of the user of the specified currency.
+func (wrapper *ContextWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
+	return wrapper.GetBalanceContext(context.Background(), symbol)
+}
+
+// GetBalanceContext gets the balance of the user of the specified currency, until the context is done.
+func (wrapper *ContextWrapper) GetBalanceContext(ctx context.Context, symbol string) (*decimal.Decimal, error) {
+	ret, err := wrapper.call(ctx, "GetBalance", true, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return inner.GetBalanceContext(ctx, symbol)
+	}, func() (interface{}, error) {
+		return wrapper.innerWrapper.GetBalance(symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*decimal.Decimal), nil
+}
+
+// GetDepositAddress gets the deposit address for the specified coin on the exchange, if exists.
+func (wrapper *ContextWrapper) GetDepositAddress(coinTicker string) (string, bool) {
+	return wrapper.innerWrapper.GetDepositAddress(coinTicker)
+}
+
+// FeedConnect connects to the feed of the exchange.
+func (wrapper *ContextWrapper) FeedConnect(markets []*environment.Market) error {
+	return wrapper.innerWrapper.FeedConnect(markets)
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *ContextWrapper) FeedDisconnect() error {
+	return wrapper.innerWrapper.FeedDisconnect()
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *ContextWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return wrapper.innerWrapper.SubscribeTrades(market)
+}
+
+// Withdraw performs a withdraw operation from the exchange to a destination address.
+func (wrapper *ContextWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return wrapper.WithdrawContext(context.Background(), destinationAddress, coinTicker, amount)
+}
+
+// WithdrawContext performs a withdraw operation from the exchange to a destination address, until the context is done.
+func (wrapper *ContextWrapper) WithdrawContext(ctx context.Context, destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.call(ctx, "Withdraw", false, func(ctx context.Context, inner ContextExchangeWrapper) (interface{}, error) {
+		return nil, inner.WithdrawContext(ctx, destinationAddress, coinTicker, amount)
+	}, func() (interface{}, error) {
+		return nil, wrapper.innerWrapper.Withdraw(destinationAddress, coinTicker, amount)
+	})
+	return err
+}
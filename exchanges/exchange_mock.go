@@ -2,24 +2,44 @@ package exchanges
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/juju/errors"
+	"github.com/saniales/golang-crypto-trading-bot/clock"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/shopspring/decimal"
 )
 
 // ExchangeWrapperSimulator wraps another wrapper and returns simulated balances and orders.
+//
+//     It is safe for concurrent use: the calls left running in background by a ContextWrapper
+//     when their context is done may still change the simulated state.
 type ExchangeWrapperSimulator struct {
+	mutex        *sync.Mutex // Guards the simulated state.
 	innerWrapper ExchangeWrapper
 	balances     map[string]decimal.Decimal
+	orders       map[string]*environment.OrderInfo
+	orderIDs     []string                   // IDs of the orders, in placement order.
+	reserved     map[string]decimal.Decimal // Balance reserved by each open limit order.
+	fills        []environment.OrderFill
+	liquidity    map[string]*marketLiquidity // Liquidity left to the limit orders, by market.
+	latency      time.Duration               // Delay before executing each order.
+	slippage     decimal.Decimal             // Extra slippage of taker fills, as a fraction of the price.
+	clock        clock.Clock                 // Clock of the simulation, the one of the wrappers if nil.
 }
 
 // NewExchangeWrapperSimulator creates a new simulated wrapper from another wrapper and an initial balance.
 func NewExchangeWrapperSimulator(mockedWrapper ExchangeWrapper, initialBalances map[string]decimal.Decimal) *ExchangeWrapperSimulator {
 	return &ExchangeWrapperSimulator{
+		mutex:        &sync.Mutex{},
 		innerWrapper: mockedWrapper,
 		balances:     initialBalances,
+		orders:       make(map[string]*environment.OrderInfo),
+		reserved:     make(map[string]decimal.Decimal),
+		liquidity:    make(map[string]*marketLiquidity),
+		slippage:     decimal.Zero,
 	}
 }
 
@@ -28,24 +48,52 @@ func (wrapper *ExchangeWrapperSimulator) String() string {
 	return wrapper.Name()
 }
 
+// Capabilities gets the features supported by the exchange: orders, balances and withdrawals are simulated,
+// market data comes from the wrapped exchange.
+func (wrapper *ExchangeWrapperSimulator) Capabilities() Capabilities {
+	ret := wrapper.innerWrapper.Capabilities()
+	ret.LimitOrders = true
+	ret.MarketOrders = true
+	ret.Balance = true
+	ret.Withdraw = true
+	return ret
+}
+
 // Name gets the name of the exchange.
 func (wrapper *ExchangeWrapperSimulator) Name() string {
 	return fmt.Sprint(wrapper.innerWrapper.Name(), "mock")
 }
 
+// wrapped gets the wrapper the market data of the simulation comes from.
+func (wrapper *ExchangeWrapperSimulator) wrapped() ExchangeWrapper {
+	return wrapper.innerWrapper
+}
+
+// GetMarkets gets all the markets info.
 func (wrapper *ExchangeWrapperSimulator) GetMarkets() ([]*environment.Market, error) {
-	return wrapper.GetMarkets()
+	return wrapper.innerWrapper.GetMarkets()
 }
 
+// GetListPriceChangeStats gets the list of price change statistics of the markets.
 func (wrapper *ExchangeWrapperSimulator) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	return wrapper.GetListPriceChangeStats()
+	return wrapper.innerWrapper.GetListPriceChangeStats()
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *ExchangeWrapperSimulator) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
+func (wrapper *ExchangeWrapperSimulator) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
 	return wrapper.innerWrapper.GetCandles(market, interval)
 }
 
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *ExchangeWrapperSimulator) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return wrapper.innerWrapper.GetCandlesRange(market, interval, from, to)
+}
+
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *ExchangeWrapperSimulator) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.innerWrapper.GetTradingRules(market)
+}
+
 // GetMarketSummary gets the current market summary.
 func (wrapper *ExchangeWrapperSimulator) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
 	return wrapper.innerWrapper.GetMarketSummary(market)
@@ -56,113 +104,661 @@ func (wrapper *ExchangeWrapperSimulator) GetOrderBook(market *environment.Market
 	return wrapper.innerWrapper.GetOrderBook(market)
 }
 
-// BuyLimit here is just to implement the ExchangeWrapper Interface, do not use, use BuyMarket instead.
-func (wrapper *ExchangeWrapperSimulator) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	return "", errors.New("BuyLimit operation is not mockable")
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *ExchangeWrapperSimulator) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return wrapper.innerWrapper.GetRecentTrades(market)
 }
 
-// SellLimit here is just to implement the ExchangeWrapper Interface, do not use, use SellMarket instead.
-func (wrapper *ExchangeWrapperSimulator) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	return "", errors.New("SellLimit operation is not mockable")
+// BuyLimit performs a FAKE limit buy action.
+//
+//     The order rests until the order book crosses its price, reserving the quote currency (MarketCurrency) it needs.
+func (wrapper *ExchangeWrapperSimulator) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	if !amount.IsPositive() || !limit.IsPositive() {
+		return "", errors.New("Limit order amount and price must be > 0")
+	}
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	wrapper.simulateLatency()
+
+	quoteBalance := wrapper.balance(market.MarketCurrency)
+	reserved := amount.Mul(limit).Add(wrapper.tradingFee(market, amount, limit, TakerTrade))
+	if reserved.GreaterThan(*quoteBalance) {
+		return "", fmt.Errorf("cannot Buy not enough %s balance", market.MarketCurrency)
+	}
+	wrapper.balances[market.MarketCurrency] = quoteBalance.Sub(reserved)
+
+	return wrapper.placeLimitOrder("FAKE_LIMIT_BUY", market, environment.Bid, amount, limit, reserved)
+}
+
+// SellLimit performs a FAKE limit sell action.
+//
+//     The order rests until the order book crosses its price, reserving the base currency (BaseCurrency) it sells.
+func (wrapper *ExchangeWrapperSimulator) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	if !amount.IsPositive() || !limit.IsPositive() {
+		return "", errors.New("Limit order amount and price must be > 0")
+	}
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	wrapper.simulateLatency()
+
+	baseBalance := wrapper.balance(market.BaseCurrency)
+	if amount.GreaterThan(*baseBalance) {
+		return "", fmt.Errorf("Cannot Sell: not enough %s balance", market.BaseCurrency)
+	}
+	wrapper.balances[market.BaseCurrency] = baseBalance.Sub(amount)
+
+	return wrapper.placeLimitOrder("FAKE_LIMIT_SELL", market, environment.Ask, amount, limit, amount)
+}
+
+// placeLimitOrder records a new FAKE limit order and fills it against the current order book, if it crosses.
+func (wrapper *ExchangeWrapperSimulator) placeLimitOrder(prefix string, market *environment.Market, side environment.OrderType, quantity decimal.Decimal, price decimal.Decimal, reserved decimal.Decimal) (string, error) {
+	orderFakeID, err := uuid.NewV4()
+	if err != nil {
+		return "", errors.Annotate(err, "UUID Generation")
+	}
+	orderID := fmt.Sprintf("%s-%s", prefix, orderFakeID)
+
+	order := &environment.OrderInfo{
+		ID:               orderID,
+		Market:           market,
+		Side:             side,
+		Status:           environment.OrderStatusOpen,
+		Price:            price,
+		Quantity:         quantity,
+		FilledQuantity:   decimal.Zero,
+		AverageFillPrice: decimal.Zero,
+		Fee:              decimal.Zero,
+		FeeCurrency:      market.MarketCurrency, // the simulated fees are charged in the quote currency.
+		Timestamp:        wrapper.getClock().Now(),
+	}
+	wrapper.orders[orderID] = order
+	wrapper.orderIDs = append(wrapper.orderIDs, orderID)
+	wrapper.reserved[orderID] = reserved
+
+	if liquidity := wrapper.marketLiquidity(market); liquidity != nil {
+		wrapper.fillLimitOrder(order, liquidity.book, false)
+	}
+	return orderID, nil
+}
+
+// matchLimitOrders fills the FAKE open limit orders crossed by the current order book of their market,
+// or touched by the price range of the current bar when the inner wrapper replays bars (see BarSource).
+//
+//     The liquidity is consumed by the fills, and is available again only with a new bar
+//     (or a new order book, when the inner wrapper does not replay bars).
+//     The market data of each market is got once per matching.
+func (wrapper *ExchangeWrapperSimulator) matchLimitOrders() {
+	liquidities := make(map[string]*marketLiquidity)
+	for _, orderID := range wrapper.orderIDs {
+		order := wrapper.orders[orderID]
+		if !order.Status.IsOpen() {
+			continue
+		}
+
+		liquidity, exists := liquidities[order.Market.Name]
+		if !exists {
+			liquidity = wrapper.marketLiquidity(order.Market)
+			liquidities[order.Market.Name] = liquidity
+		}
+		if liquidity == nil {
+			continue
+		}
+		book := liquidity.book
+		if liquidity.bar != nil && !liquidity.bar.OpenTime.Before(order.Timestamp) {
+			// the whole bar happened after the order was placed.
+			book = liquidity.traded
+		}
+		wrapper.fillLimitOrder(order, book, true)
+	}
+}
+
+// BarSource is implemented by the wrappers replaying bars of historical data (e.g. backtest.HistoricalWrapper),
+// whose order book shows only the last price of the current bar.
+type BarSource interface {
+	CurrentBar(market *environment.Market) (environment.CandleStick, error)
+}
+
+// marketLiquidity is the liquidity left to the FAKE limit orders of a market.
+type marketLiquidity struct {
+	snapshot *environment.OrderBook   // Order book of the inner wrapper the liquidity is taken from.
+	bar      *environment.CandleStick // Current bar of the inner wrapper, nil if it does not replay bars.
+	book     *environment.OrderBook   // Liquidity left in the order book.
+	traded   *environment.OrderBook   // Liquidity left in the range of the bar: asks at its Low, bids at its High.
+}
+
+// marketLiquidity gets the liquidity left to the FAKE limit orders of a market, which is refilled when
+// the inner wrapper moves to a new bar (or shows a new order book), nil if the market data is not available.
+func (wrapper *ExchangeWrapperSimulator) marketLiquidity(market *environment.Market) *marketLiquidity {
+	snapshot := wrapper.matchingBook(market)
+	if snapshot == nil {
+		return nil
+	}
+	var bar *environment.CandleStick
+	if source, ok := wrapper.innerWrapper.(BarSource); ok {
+		if candle, err := source.CurrentBar(market); err == nil {
+			bar = &candle
+		}
+	}
+
+	current, exists := wrapper.liquidity[market.Name]
+	if exists {
+		if bar != nil && current.bar != nil && bar.OpenTime.Equal(current.bar.OpenTime) ||
+			bar == nil && current.bar == nil && sameBook(snapshot, current.snapshot) {
+			return current
+		}
+	}
+
+	ret := &marketLiquidity{
+		snapshot: snapshot,
+		bar:      bar,
+		book:     copyBook(snapshot),
+	}
+	if bar != nil {
+		quantity := bar.Volume
+		if !quantity.IsPositive() {
+			quantity = tickerLiquidity
+		}
+		ret.traded = &environment.OrderBook{
+			Asks: []environment.Order{{Value: bar.Low, Quantity: quantity}},
+			Bids: []environment.Order{{Value: bar.High, Quantity: quantity}},
+		}
+	}
+	wrapper.liquidity[market.Name] = ret
+	return ret
+}
+
+// matchingBook gets a copy of the order book of the inner wrapper, falling back
+// to the ticker values when the order book is not available.
+func (wrapper *ExchangeWrapperSimulator) matchingBook(market *environment.Market) *environment.OrderBook {
+	book, err := wrapper.innerWrapper.GetOrderBook(market)
+	if err == nil {
+		return copyBook(book)
+	}
+
+	summary, err := wrapper.innerWrapper.GetMarketSummary(market)
+	if err != nil {
+		return nil
+	}
+	return &environment.OrderBook{
+		Asks: []environment.Order{{Value: summary.Ask, Quantity: tickerLiquidity}},
+		Bids: []environment.Order{{Value: summary.Bid, Quantity: tickerLiquidity}},
+	}
+}
+
+// tickerLiquidity is the quantity considered available at the ticker values, and in the bars without volume.
+var tickerLiquidity = decimal.New(1, 18)
+
+// copyBook gets a copy of the levels of an order book.
+func copyBook(book *environment.OrderBook) *environment.OrderBook {
+	ret := &environment.OrderBook{
+		Asks: make([]environment.Order, len(book.Asks)),
+		Bids: make([]environment.Order, len(book.Bids)),
+	}
+	copy(ret.Asks, book.Asks)
+	copy(ret.Bids, book.Bids)
+	return ret
+}
+
+// sameBook returns true if two order books have the same levels.
+func sameBook(first *environment.OrderBook, second *environment.OrderBook) bool {
+	return sameLevels(first.Asks, second.Asks) && sameLevels(first.Bids, second.Bids)
+}
+
+// sameLevels returns true if two sides of an order book have the same prices and quantities.
+func sameLevels(first []environment.Order, second []environment.Order) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for i := range first {
+		if !first[i].Value.Equal(second[i].Value) || !first[i].Quantity.Equal(second[i].Quantity) {
+			return false
+		}
+	}
+	return true
+}
+
+// fillLimitOrder fills a FAKE limit order against the levels of the book crossing its price,
+// consuming their quantity.
+//
+//     Taker fills happen at the book price (plus slippage), maker fills at the limit price.
+func (wrapper *ExchangeWrapperSimulator) fillLimitOrder(order *environment.OrderInfo, book *environment.OrderBook, maker bool) {
+	levels := book.Bids
+	if order.Side == environment.Bid {
+		levels = book.Asks
+	}
+
+	remaining := order.RemainingQuantity()
+	for i := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		if order.Side == environment.Bid && levels[i].Value.GreaterThan(order.Price) ||
+			order.Side == environment.Ask && levels[i].Value.LessThan(order.Price) {
+			break
+		}
+
+		quantity := decimal.Min(remaining, levels[i].Quantity)
+		if !quantity.IsPositive() {
+			continue
+		}
+		price := order.Price
+		if !maker {
+			price = wrapper.slippedPrice(levels[i].Value, order.Side)
+			if order.Side == environment.Bid {
+				price = decimal.Min(price, order.Price)
+			} else {
+				price = decimal.Max(price, order.Price)
+			}
+		}
+
+		wrapper.applyLimitFill(order, quantity, price, maker)
+		levels[i].Quantity = levels[i].Quantity.Sub(quantity)
+		remaining = remaining.Sub(quantity)
+	}
+}
+
+// applyLimitFill updates balances and status of a FAKE limit order filled by quantity at price.
+func (wrapper *ExchangeWrapperSimulator) applyLimitFill(order *environment.OrderInfo, quantity decimal.Decimal, price decimal.Decimal, maker bool) {
+	market := order.Market
+	tradeType := TradeType(TakerTrade)
+	if maker {
+		tradeType = MakerTrade
+	}
+	fee := wrapper.tradingFee(market, quantity, price, tradeType)
+
+	if order.Side == environment.Bid {
+		wrapper.reserved[order.ID] = wrapper.reserved[order.ID].Sub(quantity.Mul(price)).Sub(fee)
+		wrapper.balances[market.BaseCurrency] = wrapper.balances[market.BaseCurrency].Add(quantity)
+	} else {
+		wrapper.reserved[order.ID] = wrapper.reserved[order.ID].Sub(quantity)
+		wrapper.balances[market.MarketCurrency] = wrapper.balances[market.MarketCurrency].Add(quantity.Mul(price)).Sub(fee)
+	}
+
+	filled := order.FilledQuantity.Add(quantity)
+	order.AverageFillPrice = order.AverageFillPrice.Mul(order.FilledQuantity).Add(price.Mul(quantity)).Div(filled)
+	order.FilledQuantity = filled
+	order.Fee = order.Fee.Add(fee)
+	wrapper.recordFill(order, quantity, price, fee, maker)
+
+	if order.RemainingQuantity().IsPositive() {
+		order.Status = environment.OrderStatusPartiallyFilled
+	} else {
+		order.Status = environment.OrderStatusFilled
+		wrapper.releaseReserved(order)
+	}
+}
+
+// releaseReserved gives back the balance still reserved by a FAKE limit order.
+func (wrapper *ExchangeWrapperSimulator) releaseReserved(order *environment.OrderInfo) {
+	currency := order.Market.BaseCurrency
+	if order.Side == environment.Bid {
+		currency = order.Market.MarketCurrency
+	}
+
+	wrapper.balances[currency] = wrapper.balances[currency].Add(wrapper.reserved[order.ID])
+	delete(wrapper.reserved, order.ID)
 }
 
 // BuyMarket performs a FAKE market buy action.
-func (wrapper *ExchangeWrapperSimulator) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	baseBalance, _ := wrapper.GetBalance(market.BaseCurrency)
-	quoteBalance, _ := wrapper.GetBalance(market.MarketCurrency)
+func (wrapper *ExchangeWrapperSimulator) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	amount, _, err := RoundOrder(wrapper, market, environment.Bid, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
+	wrapper.simulateLatency()
+
+	quoteBalance := wrapper.balance(market.MarketCurrency)
+	baseBalance := wrapper.balance(market.BaseCurrency)
 
 	orderbook, err := wrapper.GetOrderBook(market)
 	if err != nil {
 		return "", errors.Annotate(err, "Cannot market buy without orderbook knowledge")
 	}
 
-	totalQuote := decimal.Zero
-	remainingAmount := decimal.NewFromFloat(amount)
+	totalBase := decimal.Zero
+	remainingAmount := amount
 	expense := decimal.Zero
+	fills := make([]environment.OrderFill, 0)
 
 	for _, ask := range orderbook.Asks {
-		if remainingAmount.LessThanOrEqual(ask.Quantity) {
-			totalQuote = totalQuote.Add(remainingAmount)
-			expense = expense.Add(remainingAmount.Mul(ask.Value))
-			if expense.GreaterThan(*baseBalance) {
-				return "", fmt.Errorf("cannot Buy not enough %s balance", market.BaseCurrency)
-			}
+		if !remainingAmount.IsPositive() {
 			break
 		}
-		totalQuote = totalQuote.Add(ask.Quantity)
-		expense = expense.Add(ask.Quantity.Mul(ask.Value))
-		if expense.GreaterThan(*baseBalance) {
-			return "", fmt.Errorf("cannot Buy not enough %s balance", market.BaseCurrency)
+		quantity := decimal.Min(remainingAmount, ask.Quantity)
+		price := wrapper.slippedPrice(ask.Value, environment.Bid)
+		fee := wrapper.tradingFee(market, quantity, price, TakerTrade)
+
+		totalBase = totalBase.Add(quantity)
+		expense = expense.Add(quantity.Mul(price)).Add(fee)
+		if expense.GreaterThan(*quoteBalance) {
+			return "", fmt.Errorf("cannot Buy not enough %s balance", market.MarketCurrency)
 		}
+		fills = append(fills, environment.OrderFill{Price: price, Quantity: quantity, Fee: fee})
+		remainingAmount = remainingAmount.Sub(quantity)
+	}
+	if len(fills) == 0 {
+		return "", fmt.Errorf("Cannot Buy: no asks in the %s orderbook", market.Name)
 	}
 
-	wrapper.balances[market.BaseCurrency] = baseBalance.Sub(expense)
-	wrapper.balances[market.MarketCurrency] = quoteBalance.Add(totalQuote)
+	wrapper.balances[market.MarketCurrency] = quoteBalance.Sub(expense)
+	wrapper.balances[market.BaseCurrency] = baseBalance.Add(totalBase)
 
 	orderFakeID, err := uuid.NewV4()
 	if err != nil {
 		return "", errors.Annotate(err, "UUID Generation")
 	}
-	return fmt.Sprintf("FAKE_BUY-%s", orderFakeID), nil
+	orderID := fmt.Sprintf("FAKE_BUY-%s", orderFakeID)
+	wrapper.recordMarketOrder(orderID, market, environment.Bid, amount, fills)
+	return orderID, nil
 }
 
 // SellMarket performs a FAKE market buy action.
-func (wrapper *ExchangeWrapperSimulator) SellMarket(market *environment.Market, amount float64) (string, error) {
-	baseBalance, _ := wrapper.GetBalance(market.BaseCurrency)
-	quoteBalance, _ := wrapper.GetBalance(market.MarketCurrency)
+func (wrapper *ExchangeWrapperSimulator) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	amount, _, err := RoundOrder(wrapper, market, environment.Ask, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
+	wrapper.simulateLatency()
+
+	quoteBalance := wrapper.balance(market.MarketCurrency)
+	baseBalance := wrapper.balance(market.BaseCurrency)
 
 	orderbook, err := wrapper.GetOrderBook(market)
 	if err != nil {
 		return "", errors.Annotate(err, "Cannot market buy without orderbook knowledge")
 	}
 
-	totalQuote := decimal.Zero
-	remainingAmount := decimal.NewFromFloat(amount)
+	totalBase := decimal.Zero
+	remainingAmount := amount
 	gain := decimal.Zero
+	fills := make([]environment.OrderFill, 0)
 
-	if quoteBalance.LessThan(remainingAmount) {
-		return "", fmt.Errorf("Cannot Sell: not enough %s balance", market.MarketCurrency)
+	if baseBalance.LessThan(remainingAmount) {
+		return "", fmt.Errorf("Cannot Sell: not enough %s balance", market.BaseCurrency)
 	}
 
 	for _, bid := range orderbook.Bids {
-		if remainingAmount.LessThanOrEqual(bid.Quantity) {
-			totalQuote = totalQuote.Add(remainingAmount)
-			gain = gain.Add(remainingAmount.Mul(bid.Value))
+		if !remainingAmount.IsPositive() {
 			break
 		}
-		totalQuote = totalQuote.Add(bid.Quantity)
-		gain = gain.Add(bid.Quantity.Mul(bid.Value))
+		quantity := decimal.Min(remainingAmount, bid.Quantity)
+		price := wrapper.slippedPrice(bid.Value, environment.Ask)
+		fee := wrapper.tradingFee(market, quantity, price, TakerTrade)
+
+		totalBase = totalBase.Add(quantity)
+		gain = gain.Add(quantity.Mul(price)).Sub(fee)
+		fills = append(fills, environment.OrderFill{Price: price, Quantity: quantity, Fee: fee})
+		remainingAmount = remainingAmount.Sub(quantity)
+	}
+	if len(fills) == 0 {
+		return "", fmt.Errorf("Cannot Sell: no bids in the %s orderbook", market.Name)
 	}
 
-	wrapper.balances[market.BaseCurrency] = baseBalance.Add(gain)
-	wrapper.balances[market.MarketCurrency] = quoteBalance.Sub(totalQuote)
+	wrapper.balances[market.MarketCurrency] = quoteBalance.Add(gain)
+	wrapper.balances[market.BaseCurrency] = baseBalance.Sub(totalBase)
 
 	orderFakeID, err := uuid.NewV4()
 	if err != nil {
 		return "", errors.Annotate(err, "UUID Generation")
 	}
-	return fmt.Sprintf("FAKE_SELL-%s", orderFakeID), nil
+	orderID := fmt.Sprintf("FAKE_SELL-%s", orderFakeID)
+	wrapper.recordMarketOrder(orderID, market, environment.Ask, amount, fills)
+	return orderID, nil
+}
+
+// recordMarketOrder keeps track of an executed FAKE market order and its fills.
+//
+//     When the orderbook is too thin to fill the whole amount, the rest of the order
+//     is cancelled, as the exchanges do with market orders.
+func (wrapper *ExchangeWrapperSimulator) recordMarketOrder(orderID string, market *environment.Market, side environment.OrderType, amount decimal.Decimal, fills []environment.OrderFill) {
+	order := &environment.OrderInfo{
+		ID:               orderID,
+		Market:           market,
+		Side:             side,
+		Status:           environment.OrderStatusFilled,
+		Quantity:         amount,
+		FilledQuantity:   decimal.Zero,
+		AverageFillPrice: decimal.Zero,
+		Fee:              decimal.Zero,
+		FeeCurrency:      market.MarketCurrency,
+		Timestamp:        wrapper.getClock().Now(),
+	}
+
+	total := decimal.Zero
+	for _, fill := range fills {
+		order.FilledQuantity = order.FilledQuantity.Add(fill.Quantity)
+		order.Fee = order.Fee.Add(fill.Fee)
+		total = total.Add(fill.Quantity.Mul(fill.Price))
+		wrapper.recordFill(order, fill.Quantity, fill.Price, fill.Fee, false)
+	}
+	if !order.FilledQuantity.IsZero() {
+		order.AverageFillPrice = total.Div(order.FilledQuantity)
+	}
+	if order.FilledQuantity.LessThan(order.Quantity) {
+		order.Status = environment.OrderStatusCancelled
+	}
+
+	wrapper.orders[orderID] = order
+	wrapper.orderIDs = append(wrapper.orderIDs, orderID)
+}
+
+// recordFill keeps track of a single execution of a FAKE order.
+func (wrapper *ExchangeWrapperSimulator) recordFill(order *environment.OrderInfo, quantity decimal.Decimal, price decimal.Decimal, fee decimal.Decimal, maker bool) {
+	wrapper.fills = append(wrapper.fills, environment.OrderFill{
+		OrderID:     order.ID,
+		Price:       price,
+		Quantity:    quantity,
+		Fee:         fee,
+		FeeCurrency: order.FeeCurrency,
+		Maker:       maker,
+		Timestamp:   wrapper.getClock().Now(),
+	})
+}
+
+// tradingFee calculates the fee of a FAKE fill, in quote currency, using the fee model of the inner wrapper.
+func (wrapper *ExchangeWrapperSimulator) tradingFee(market *environment.Market, quantity decimal.Decimal, price decimal.Decimal, tradeType TradeType) decimal.Decimal {
+	return wrapper.innerWrapper.CalculateTradingFees(market, quantity, price, tradeType)
+}
+
+// slippedPrice applies the extra slippage to the price of a FAKE taker fill, against the side of the order.
+func (wrapper *ExchangeWrapperSimulator) slippedPrice(price decimal.Decimal, side environment.OrderType) decimal.Decimal {
+	if side == environment.Bid {
+		return price.Mul(decimal.NewFromInt(1).Add(wrapper.slippage))
+	}
+	return price.Mul(decimal.NewFromInt(1).Sub(wrapper.slippage))
+}
+
+// simulateLatency waits the configured latency before executing a FAKE order.
+func (wrapper *ExchangeWrapperSimulator) simulateLatency() {
+	if wrapper.latency > 0 {
+		wrapper.getClock().Sleep(wrapper.latency)
+	}
+}
+
+// SetClock sets the clock of the simulation, used to timestamp and delay the FAKE orders.
+func (wrapper *ExchangeWrapperSimulator) SetClock(c clock.Clock) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.clock = c
+}
+
+// getClock gets the clock of the simulation.
+func (wrapper *ExchangeWrapperSimulator) getClock() clock.Clock {
+	if wrapper.clock == nil {
+		return getClock()
+	}
+	return wrapper.clock
+}
+
+// SetLatency sets the delay waited before executing each FAKE order.
+func (wrapper *ExchangeWrapperSimulator) SetLatency(latency time.Duration) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.latency = latency
+}
+
+// SetSlippage sets the extra slippage applied to the FAKE taker fills, as a fraction of the price (e.g. 0.001 for 0.1%).
+func (wrapper *ExchangeWrapperSimulator) SetSlippage(slippage decimal.Decimal) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.slippage = slippage
+}
+
+// CancelOrder cancels a FAKE open order, releasing the balance it reserves.
+func (wrapper *ExchangeWrapperSimulator) CancelOrder(market *environment.Market, orderID string) error {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	order, exists := wrapper.orders[orderID]
+	if !exists {
+		return ErrOrderNotFound
+	}
+	if !order.Status.IsOpen() {
+		return fmt.Errorf("Cannot cancel order %s: order is %s", orderID, order.Status)
+	}
+
+	wrapper.releaseReserved(order)
+	order.Status = environment.OrderStatusCancelled
+	return nil
+}
+
+// GetOrder gets the current status of a FAKE order.
+func (wrapper *ExchangeWrapperSimulator) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	order, exists := wrapper.orders[orderID]
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+
+	ret := *order
+	return &ret, nil
+}
+
+// GetOpenOrders gets the FAKE orders still open on a market, in placement order.
+func (wrapper *ExchangeWrapperSimulator) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	ret := make([]*environment.OrderInfo, 0)
+	for _, orderID := range wrapper.orderIDs {
+		order := wrapper.orders[orderID]
+		if order.Status.IsOpen() && order.Market.Name == market.Name {
+			openOrder := *order
+			ret = append(ret, &openOrder)
+		}
+	}
+
+	return ret, nil
+}
+
+// OrderHistory gets all the FAKE orders placed so far, in placement order.
+func (wrapper *ExchangeWrapperSimulator) OrderHistory() []*environment.OrderInfo {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	ret := make([]*environment.OrderInfo, len(wrapper.orderIDs))
+	for i, orderID := range wrapper.orderIDs {
+		order := *wrapper.orders[orderID]
+		ret[i] = &order
+	}
+
+	return ret
+}
+
+// Fills gets all the executions of the FAKE orders so far, along with the fee paid for each one.
+func (wrapper *ExchangeWrapperSimulator) Fills() []environment.OrderFill {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	ret := make([]environment.OrderFill, len(wrapper.fills))
+	copy(ret, wrapper.fills)
+	return ret
+}
+
+// GetReservedBalance gets the balance of the specified currency reserved by FAKE open limit orders.
+func (wrapper *ExchangeWrapperSimulator) GetReservedBalance(symbol string) decimal.Decimal {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	ret := decimal.Zero
+	for orderID, reserved := range wrapper.reserved {
+		order := wrapper.orders[orderID]
+		if order.Side == environment.Bid && order.Market.MarketCurrency == symbol ||
+			order.Side == environment.Ask && order.Market.BaseCurrency == symbol {
+			ret = ret.Add(reserved)
+		}
+	}
+	return ret
 }
 
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
-func (wrapper *ExchangeWrapperSimulator) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *ExchangeWrapperSimulator) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	return wrapper.innerWrapper.CalculateTradingFees(market, amount, limit, orderType)
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *ExchangeWrapperSimulator) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
+func (wrapper *ExchangeWrapperSimulator) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
 	return wrapper.innerWrapper.CalculateWithdrawFees(market, amount)
 }
 
 // GetBalance gets the balance of the user of the specified currency.
+//
+//     The balance reserved by open limit orders is not available.
 func (wrapper *ExchangeWrapperSimulator) GetBalance(symbol string) (*decimal.Decimal, error) {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	wrapper.matchLimitOrders()
+
+	return wrapper.balance(symbol), nil
+}
+
+// balance gets the balance of the specified currency, without matching the open limit orders.
+func (wrapper *ExchangeWrapperSimulator) balance(symbol string) *decimal.Decimal {
 	bal, exists := wrapper.balances[symbol]
 	if !exists {
 		wrapper.balances[symbol] = decimal.Zero
 		var bal = decimal.Zero
-		return &bal, nil
+		return &bal
 	}
-	return &bal, nil
+	return &bal
 }
 
 // GetDepositAddress gets the deposit address for the specified coin on the exchange.
@@ -175,19 +771,31 @@ func (wrapper *ExchangeWrapperSimulator) FeedConnect(markets []*environment.Mark
 	return wrapper.innerWrapper.FeedConnect(markets)
 }
 
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *ExchangeWrapperSimulator) FeedDisconnect() error {
+	return wrapper.innerWrapper.FeedDisconnect()
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *ExchangeWrapperSimulator) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return wrapper.innerWrapper.SubscribeTrades(market)
+}
+
 // Withdraw performs a FAKE withdraw operation from the exchange to a destination address.
-func (wrapper *ExchangeWrapperSimulator) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	if amount <= 0 {
+func (wrapper *ExchangeWrapperSimulator) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+
+	if !amount.IsPositive() {
 		return errors.New("Withdraw amount must be > 0")
 	}
 
 	bal, exists := wrapper.balances[coinTicker]
-	amt := decimal.NewFromFloat(amount)
-	if !exists || amt.GreaterThan(bal) {
+	if !exists || amount.GreaterThan(bal) {
 		return errors.New("Not enough balance")
 	}
 
-	wrapper.balances[coinTicker] = bal.Sub(amt)
+	wrapper.balances[coinTicker] = bal.Sub(amount)
 
 	return nil
 }
@@ -0,0 +1,307 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/clock"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// bookWrapper is the wrapper under a simulator in the tests, showing a settable order book
+// (and replaying a bar, if set), and charging a maker fee of 0.1% and a taker fee of 0.2%.
+type bookWrapper struct {
+	ExchangeWrapper // Not set: the other operations are not used by the simulator.
+	book            *environment.OrderBook
+	bar             *environment.CandleStick
+}
+
+// Name gets the name of the exchange.
+func (wrapper *bookWrapper) Name() string {
+	return "book"
+}
+
+// GetOrderBook gets the order book set by the test.
+func (wrapper *bookWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	return wrapper.book, nil
+}
+
+// CurrentBar gets the bar set by the test.
+func (wrapper *bookWrapper) CurrentBar(market *environment.Market) (environment.CandleStick, error) {
+	if wrapper.bar == nil {
+		return environment.CandleStick{}, fmt.Errorf("%w: no bar replayed", ErrNotSupported)
+	}
+	return *wrapper.bar, nil
+}
+
+// GetTradingRules gets no constraint on the orders.
+func (wrapper *bookWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return environment.TradingRules{}, nil
+}
+
+// CalculateTradingFees charges 0.1% of the total to the makers and 0.2% to the takers.
+func (wrapper *bookWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
+	rate := decimal.RequireFromString("0.002")
+	if orderType == MakerTrade {
+		rate = decimal.RequireFromString("0.001")
+	}
+	return amount.Mul(limit).Mul(rate)
+}
+
+// testMarket is the market of the simulator tests.
+var testMarket = &environment.Market{
+	Name:           "ETH-BTC",
+	BaseCurrency:   "ETH",
+	MarketCurrency: "BTC",
+}
+
+// book creates an order book from its levels, written as "price:quantity" space separated.
+func book(asks string, bids string) *environment.OrderBook {
+	return &environment.OrderBook{
+		Asks: levels(strings.Fields(asks)...),
+		Bids: levels(strings.Fields(bids)...),
+	}
+}
+
+// newTestSimulator creates a simulator holding 1000 BTC and 10 ETH, over the specified order book.
+func newTestSimulator(orderBook *environment.OrderBook) (*ExchangeWrapperSimulator, *bookWrapper) {
+	inner := &bookWrapper{book: orderBook}
+	simulator := NewExchangeWrapperSimulator(inner, map[string]decimal.Decimal{
+		"BTC": decimal.NewFromInt(1000),
+		"ETH": decimal.NewFromInt(10),
+	})
+	return simulator, inner
+}
+
+// formatOrder writes an order as "status filled@average price".
+func formatOrder(order *environment.OrderInfo) string {
+	return fmt.Sprintf("%s %s@%s", order.Status, order.FilledQuantity, order.AverageFillPrice)
+}
+
+// checkBalance checks the available and the reserved balances of a currency.
+func checkBalance(t *testing.T, name string, simulator *ExchangeWrapperSimulator, symbol string, available string, reserved string) {
+	t.Helper()
+	balance, err := simulator.GetBalance(symbol)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	if !balance.Equal(decimal.RequireFromString(available)) {
+		t.Errorf("%s: %s balance = %s, want %s", name, symbol, balance, available)
+	}
+	if got := simulator.GetReservedBalance(symbol); !got.Equal(decimal.RequireFromString(reserved)) {
+		t.Errorf("%s: %s reserved = %s, want %s", name, symbol, got, reserved)
+	}
+}
+
+func TestSimulatorMarketOrders(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     environment.OrderType
+		amount   string
+		slippage string
+		wantErr  bool
+		want     string
+		wantFee  string
+		btc      string
+		eth      string
+	}{
+		{"buy across levels", environment.Bid, "2", "0", false, "Filled 2@101.5", "0.406", "796.594", "12"},
+		{"buy with slippage", environment.Bid, "1", "0.01", false, "Filled 1@102.01", "0.20402", "897.78598", "11"},
+		{"sell across levels with slippage", environment.Ask, "2", "0.01", false, "Filled 2@98.505", "0.39402", "1196.61598", "8"},
+		{"buy beyond the book", environment.Bid, "3", "0", false, "Cancelled 2@101.5", "0.406", "796.594", "12"},
+		{"sell beyond the balance", environment.Ask, "11", "0", true, "", "", "1000", "10"},
+	}
+
+	for _, test := range tests {
+		simulator, _ := newTestSimulator(book("101:1 102:1", "100:1 99:1"))
+		simulator.SetSlippage(decimal.RequireFromString(test.slippage))
+
+		placeOrder := simulator.SellMarket
+		if test.side == environment.Bid {
+			placeOrder = simulator.BuyMarket
+		}
+		orderID, err := placeOrder(testMarket, decimal.RequireFromString(test.amount))
+		if test.wantErr != (err != nil) {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+		}
+		if err == nil {
+			order, err := simulator.GetOrder(testMarket, orderID)
+			if err != nil {
+				t.Fatalf("%s: %s", test.name, err)
+			}
+			if got := formatOrder(order); got != test.want {
+				t.Errorf("%s: order %s, want %s", test.name, got, test.want)
+			}
+			if !order.Fee.Equal(decimal.RequireFromString(test.wantFee)) || order.FeeCurrency != "BTC" {
+				t.Errorf("%s: fee %s %s, want %s BTC", test.name, order.Fee, order.FeeCurrency, test.wantFee)
+			}
+		}
+		checkBalance(t, test.name, simulator, "BTC", test.btc, "0")
+		checkBalance(t, test.name, simulator, "ETH", test.eth, "0")
+	}
+}
+
+// matchingStep is a step of a simulator test: the inner wrapper shows a new order book or a new bar,
+// a limit order is placed ("buy 1@100.5" or "sell 2@101") or all the open orders are cancelled ("cancel").
+type matchingStep struct {
+	book   *environment.OrderBook
+	bar    *environment.CandleStick
+	action string
+}
+
+func TestSimulatorLimitMatching(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	bar := func(openTime time.Time, low string, high string) *environment.CandleStick {
+		return &environment.CandleStick{
+			Low:      decimal.RequireFromString(low),
+			High:     decimal.RequireFromString(high),
+			Volume:   decimal.NewFromInt(10),
+			OpenTime: openTime,
+		}
+	}
+	tests := []struct {
+		name        string
+		book        *environment.OrderBook
+		steps       []matchingStep
+		want        []string
+		btc         string
+		btcReserved string
+		eth         string
+		ethReserved string
+	}{
+		{
+			name:  "resting buy",
+			steps: []matchingStep{{action: "buy 1@100.5"}},
+			want:  []string{"Open 0@0"},
+			btc:   "899.299", btcReserved: "100.701", eth: "10", ethReserved: "0",
+		},
+		{
+			name:  "buy crossing the book",
+			steps: []matchingStep{{action: "buy 2@101.5"}},
+			want:  []string{"PartiallyFilled 1@101"},
+			btc:   "796.594", btcReserved: "102.204", eth: "11", ethReserved: "0",
+		},
+		{
+			name:  "resting buy filled by a new book",
+			steps: []matchingStep{{action: "buy 1@100.5"}, {book: book("100.4:5", "100:1")}},
+			want:  []string{"Filled 1@100.5"},
+			btc:   "899.3995", btcReserved: "0", eth: "11", ethReserved: "0",
+		},
+		{
+			name:  "liquidity shared in placement order",
+			steps: []matchingStep{{action: "buy 1@100.5"}, {action: "buy 1@100.5"}, {book: book("100.4:1.5", "100:1")}},
+			want:  []string{"Filled 1@100.5", "PartiallyFilled 0.5@100.5"},
+			btc:   "798.6985", btcReserved: "50.40075", eth: "11.5", ethReserved: "0",
+		},
+		{
+			name:  "liquidity consumed until the book changes",
+			book:  book("100.4:1", "100:1"),
+			steps: []matchingStep{{action: "buy 1@100.5"}, {action: "buy 1@100.5"}},
+			want:  []string{"Filled 1@100.4", "Open 0@0"},
+			btc:   "798.6982", btcReserved: "100.701", eth: "11", ethReserved: "0",
+		},
+		{
+			name:  "resting sell filled by a new book",
+			steps: []matchingStep{{action: "sell 2@101.5"}, {book: book("103:1", "102:3")}},
+			want:  []string{"Filled 2@101.5"},
+			btc:   "1202.797", btcReserved: "0", eth: "8", ethReserved: "0",
+		},
+		{
+			name:  "resting sell",
+			steps: []matchingStep{{action: "sell 2@101.5"}},
+			want:  []string{"Open 0@0"},
+			btc:   "1000", btcReserved: "0", eth: "8", ethReserved: "2",
+		},
+		{
+			name:  "cancelled buy",
+			steps: []matchingStep{{action: "buy 1@100.5"}, {action: "cancel"}},
+			want:  []string{"Cancelled 0@0"},
+			btc:   "1000", btcReserved: "0", eth: "10", ethReserved: "0",
+		},
+		{
+			name:  "bar range before the order",
+			book:  book("100.2:1", "100:1"),
+			steps: []matchingStep{{bar: bar(start.Add(-time.Minute), "99.5", "100.5")}, {action: "buy 1@99.8"}},
+			want:  []string{"Open 0@0"},
+			btc:   "900.0004", btcReserved: "99.9996", eth: "10", ethReserved: "0",
+		},
+		{
+			name: "bar range after the order",
+			book: book("100.2:1", "100:1"),
+			steps: []matchingStep{
+				{bar: bar(start.Add(-time.Minute), "99.5", "100.5")}, {action: "buy 1@99.8"}, {bar: bar(start, "99.5", "100.5")},
+			},
+			want: []string{"Filled 1@99.8"},
+			btc:  "900.1002", btcReserved: "0", eth: "11", ethReserved: "0",
+		},
+	}
+
+	for _, test := range tests {
+		orderBook := test.book
+		if orderBook == nil {
+			orderBook = book("101:1 102:2", "100:1 99:2")
+		}
+		simulator, inner := newTestSimulator(orderBook)
+		simulator.SetClock(clock.NewFake(start))
+
+		for _, step := range test.steps {
+			switch {
+			case step.book != nil:
+				inner.book = step.book
+			case step.bar != nil:
+				inner.bar = step.bar
+			case step.action == "cancel":
+				orders, err := simulator.GetOpenOrders(testMarket)
+				if err != nil {
+					t.Fatalf("%s: %s", test.name, err)
+				}
+				for _, order := range orders {
+					if err := simulator.CancelOrder(testMarket, order.ID); err != nil {
+						t.Fatalf("%s: %s", test.name, err)
+					}
+				}
+			default:
+				var side, quantity, price string
+				if _, err := fmt.Sscanf(strings.Replace(step.action, "@", " ", 1), "%s %s %s", &side, &quantity, &price); err != nil {
+					t.Fatalf("%s: invalid step %q", test.name, step.action)
+				}
+				placeOrder := simulator.SellLimit
+				if side == "buy" {
+					placeOrder = simulator.BuyLimit
+				}
+				if _, err := placeOrder(testMarket, decimal.RequireFromString(quantity), decimal.RequireFromString(price)); err != nil {
+					t.Fatalf("%s: %s", test.name, err)
+				}
+			}
+		}
+
+		orders := simulator.OrderHistory()
+		got := make([]string, len(orders))
+		for i, order := range orders {
+			got[i] = formatOrder(order)
+		}
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%s: orders [%s], want [%s]", test.name, strings.Join(got, ", "), strings.Join(test.want, ", "))
+		}
+		checkBalance(t, test.name, simulator, "BTC", test.btc, test.btcReserved)
+		checkBalance(t, test.name, simulator, "ETH", test.eth, test.ethReserved)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/sirupsen/logrus"
+)
+
+// FeedListener receives the market data updates got by the wrappers for a market,
+// either from REST calls or websocket feeds.
+//
+//     Listeners are called synchronously by the feeds and must not block.
+type FeedListener interface {
+	OnSummary(market *environment.Market, summary environment.MarketSummary) // Called on every new market summary.
+	OnOrderBook(market *environment.Market, book environment.OrderBook)      // Called on every order book update, with the whole updated book.
+	OnTrade(market *environment.Market, trade environment.Trade)             // Called on every public trade of the market.
+}
+
+// feedListeners contains the listeners added to each market.
+var feedListeners = struct {
+	mutex     sync.RWMutex
+	listeners map[*environment.Market][]FeedListener
+}{
+	listeners: make(map[*environment.Market][]FeedListener),
+}
+
+// AddFeedListener adds a listener of the market data updates of a market.
+func AddFeedListener(market *environment.Market, listener FeedListener) {
+	feedListeners.mutex.Lock()
+	feedListeners.listeners[market] = append(feedListeners.listeners[market], listener)
+	feedListeners.mutex.Unlock()
+}
+
+// RemoveFeedListeners removes all the listeners added to a market,
+// closing the channels of the trade subscriptions of the market.
+func RemoveFeedListeners(market *environment.Market) {
+	feedListeners.mutex.Lock()
+	removed := feedListeners.listeners[market]
+	delete(feedListeners.listeners, market)
+	feedListeners.mutex.Unlock()
+
+	for _, listener := range removed {
+		if subscription, isSubscription := listener.(*TradeSubscription); isSubscription {
+			subscription.close()
+		}
+	}
+}
+
+// removeFeedListener removes a listener added to a market, if still added.
+func removeFeedListener(market *environment.Market, listener FeedListener) {
+	feedListeners.mutex.Lock()
+	defer feedListeners.mutex.Unlock()
+
+	listeners := feedListeners.listeners[market]
+	for i, added := range listeners {
+		if added == listener {
+			remaining := make([]FeedListener, 0, len(listeners)-1)
+			remaining = append(remaining, listeners[:i]...)
+			feedListeners.listeners[market] = append(remaining, listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// listenersOf gets the listeners added to a market.
+func listenersOf(market *environment.Market) []FeedListener {
+	feedListeners.mutex.RLock()
+	defer feedListeners.mutex.RUnlock()
+	return feedListeners.listeners[market]
+}
+
+// notifySummary notifies a new summary of a market to its listeners.
+func notifySummary(market *environment.Market, summary *environment.MarketSummary) {
+	for _, listener := range listenersOf(market) {
+		listener.OnSummary(market, *summary)
+	}
+}
+
+// notifyOrderBook notifies an order book update of a market to its listeners.
+//
+//     Listeners get a copy of the book, which is updated in place by some feeds.
+func notifyOrderBook(market *environment.Market, book *environment.OrderBook) {
+	listeners := listenersOf(market)
+	if len(listeners) == 0 {
+		return
+	}
+
+	copied := environment.OrderBook{
+		Asks: append([]environment.Order(nil), book.Asks...),
+		Bids: append([]environment.Order(nil), book.Bids...),
+	}
+	for _, listener := range listeners {
+		listener.OnOrderBook(market, copied)
+	}
+}
+
+// notifyTrade notifies a public trade of a market to its listeners.
+func notifyTrade(market *environment.Market, trade environment.Trade) {
+	for _, listener := range listenersOf(market) {
+		listener.OnTrade(market, trade)
+	}
+}
+
+// tradeSubscriptionBuffer is the number of trades buffered by the channel of a trade subscription.
+const tradeSubscriptionBuffer = 1024
+
+// TradeSubscription is a listener sending the public trades of a market to a channel.
+//
+//     Trades are dropped when the channel is full, so that a slow reader cannot block the feed:
+//     they are counted, and logged when the channel gets full.
+type TradeSubscription struct {
+	mutex    *sync.Mutex
+	market   *environment.Market
+	trades   chan environment.Trade
+	closed   bool
+	dropping bool  // If true, the channel has been full since the last trade dropped.
+	dropped  int64 // Number of trades dropped because the channel was full.
+}
+
+// subscribeTrades adds a listener of the public trades of a market, returning the subscription sending them
+// to a channel, closed when the subscription is cancelled or the listeners of the market are removed.
+func subscribeTrades(market *environment.Market) *TradeSubscription {
+	subscription := &TradeSubscription{
+		mutex:  &sync.Mutex{},
+		market: market,
+		trades: make(chan environment.Trade, tradeSubscriptionBuffer),
+	}
+	AddFeedListener(market, subscription)
+	return subscription
+}
+
+// Trades gets the channel receiving the public trades of the market, closed when the subscription ends.
+func (subscription *TradeSubscription) Trades() <-chan environment.Trade {
+	return subscription.trades
+}
+
+// Dropped gets the number of trades dropped so far because the channel was full.
+func (subscription *TradeSubscription) Dropped() int64 {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	return subscription.dropped
+}
+
+// Cancel ends the subscription, closing its channel, without affecting the other listeners of the market.
+func (subscription *TradeSubscription) Cancel() {
+	removeFeedListener(subscription.market, subscription)
+	subscription.close()
+}
+
+// OnSummary ignores the summaries of the market.
+func (subscription *TradeSubscription) OnSummary(market *environment.Market, summary environment.MarketSummary) {
+}
+
+// OnOrderBook ignores the order book updates of the market.
+func (subscription *TradeSubscription) OnOrderBook(market *environment.Market, book environment.OrderBook) {
+}
+
+// OnTrade sends a public trade of the market to the channel, unless it is full or closed.
+func (subscription *TradeSubscription) OnTrade(market *environment.Market, trade environment.Trade) {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if subscription.closed {
+		return
+	}
+	select {
+	case subscription.trades <- trade:
+		subscription.dropping = false
+	default:
+		subscription.dropped++
+		if !subscription.dropping {
+			subscription.dropping = true
+			logrus.Warnf("Trade subscription of %s full, dropping trades (%d dropped so far)", market, subscription.dropped)
+		}
+	}
+}
+
+// close closes the channel of the subscription.
+func (subscription *TradeSubscription) close() {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if !subscription.closed {
+		subscription.closed = true
+		close(subscription.trades)
+	}
+}
+
+// hasTradeSubscriptions tells whether the public trades of a market are subscribed.
+func hasTradeSubscriptions(market *environment.Market) bool {
+	for _, listener := range listenersOf(market) {
+		if _, isSubscription := listener.(*TradeSubscription); isSubscription {
+			return true
+		}
+	}
+	return false
+}
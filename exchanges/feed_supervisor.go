@@ -0,0 +1,180 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	feedMinBackoff  = time.Second      // Delay before opening again a lost websocket connection.
+	feedMaxBackoff  = time.Minute      // Maximum delay between the attempts to open a websocket connection.
+	feedIdleTimeout = 90 * time.Second // Time without messages after which a websocket connection is considered lost.
+)
+
+// ErrStaleFeed is the error representing when the data got from the websocket feed is older than the maximum age.
+var ErrStaleFeed = errors.New("Stale feed data")
+
+// FeedSettings represents how the data got from the websocket feed of a wrapper is served.
+type FeedSettings struct {
+	MaxAge   time.Duration // Maximum age of the data got from the feed, no limit if zero.
+	Fallback bool          // If true, the data missing from the feed or older than MaxAge is got from the REST API instead.
+}
+
+// FeedConfigurable is implemented by the wrappers supporting websocket feeds.
+type FeedConfigurable interface {
+	SetFeedSettings(settings FeedSettings) // Sets how the data got from the websocket feed is served.
+}
+
+// readFromFeed checks if a read must be served from the cache filled by the websocket feed, given the time
+// of the last update of the cached entry (zero if missing), instead of the REST API.
+//
+//     The stale entries fail with ErrStaleFeed unless the fallback is enabled, the missing ones are served
+//     from the cache (failing as not loaded) unless the fallback is enabled.
+func readFromFeed(websocketOn bool, settings FeedSettings, updated time.Time) (bool, error) {
+	if !websocketOn {
+		return false, nil
+	}
+	if updated.IsZero() {
+		return !settings.Fallback, nil
+	}
+
+	age := now().Sub(updated)
+	if settings.MaxAge <= 0 || age <= settings.MaxAge {
+		return true, nil
+	}
+	if settings.Fallback {
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: last update %s ago", ErrStaleFeed, age.Truncate(time.Millisecond))
+}
+
+// feedConnection represents a websocket connection of a feed.
+type feedConnection struct {
+	done  <-chan struct{} // Closed when the connection is lost.
+	close func()          // Closes the connection.
+}
+
+// superviseFeed opens a websocket connection of a feed using connect, which also subscribes to its channels,
+// then opens it again with exponential backoff each time it is lost, until stop is closed.
+//
+//     The first connection is opened before returning, and its error is returned.
+func superviseFeed(name string, stop <-chan struct{}, connect func() (feedConnection, error)) error {
+	connection, err := connect()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		backoff := feedMinBackoff
+		for {
+			connected := now()
+			select {
+			case <-stop:
+				connection.close()
+				return
+			case <-connection.done:
+			}
+
+			if now().Sub(connected) > feedMaxBackoff { // the lost connection was stable.
+				backoff = feedMinBackoff
+			}
+			logrus.Warnf("%s feed disconnected, reconnecting in %s", name, backoff)
+			for {
+				select {
+				case <-stop:
+					return
+				case <-getClock().After(backoff):
+				}
+
+				connection, err = connect()
+				backoff *= 2
+				if backoff > feedMaxBackoff {
+					backoff = feedMaxBackoff
+				}
+				if err == nil {
+					break
+				}
+				logrus.Warnf("%s feed cannot reconnect, retrying in %s: %s", name, backoff, err)
+			}
+		}
+	}()
+	return nil
+}
+
+// feedWatchdog detects the websocket connections which stopped receiving messages without being closed.
+type feedWatchdog struct {
+	mutex   *sync.Mutex
+	last    time.Time     // Time of the last message received.
+	expired chan struct{} // Closed when the connection is considered lost regardless of the messages.
+	once    *sync.Once
+}
+
+// newFeedWatchdog creates a new watchdog of a websocket connection just opened.
+func newFeedWatchdog() *feedWatchdog {
+	return &feedWatchdog{
+		mutex:   &sync.Mutex{},
+		last:    now(),
+		expired: make(chan struct{}),
+		once:    &sync.Once{},
+	}
+}
+
+// touch records that a message has been received.
+func (watchdog *feedWatchdog) touch() {
+	watchdog.mutex.Lock()
+	watchdog.last = now()
+	watchdog.mutex.Unlock()
+}
+
+// expire makes the connection be considered lost, e.g. when its data is no longer consistent.
+func (watchdog *feedWatchdog) expire() {
+	watchdog.once.Do(func() {
+		close(watchdog.expired)
+	})
+}
+
+// watch returns a channel closed when no message has been received for the idle timeout,
+// when the watchdog expires or when stop is closed.
+func (watchdog *feedWatchdog) watch(stop <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			watchdog.mutex.Lock()
+			deadline := watchdog.last.Add(feedIdleTimeout)
+			watchdog.mutex.Unlock()
+
+			wait := deadline.Sub(now())
+			if wait <= 0 {
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-watchdog.expired:
+				return
+			case <-getClock().After(wait):
+			}
+		}
+	}()
+	return done
+}
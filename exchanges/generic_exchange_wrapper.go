@@ -17,6 +17,9 @@ package exchanges
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/shopspring/decimal"
@@ -35,34 +38,150 @@ const (
 //ExchangeWrapper provides a generic wrapper for exchange services.
 type ExchangeWrapper interface {
 	Name() string
-	GetMarkets() ([]*environment.Market, error)                                                // Gets the name of the exchange.
-	GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) // Gets the candle data from the exchange.
-	GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error)           // Gets the current market summary.
-	GetOrderBook(market *environment.Market) (*environment.OrderBook, error)                   // Gets the order(ASK + BID) book of a market.
-	GetListPriceChangeStats() (environment.ListPriceChangeStats, error)                        // Gets the list of price change
+	GetMarkets() ([]*environment.Market, error)                                                              // Gets the name of the exchange.
+	GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) // Gets the candle data from the exchange.
+	GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error)                         // Gets the current market summary.
+	GetOrderBook(market *environment.Market) (*environment.OrderBook, error)                                 // Gets the order(ASK + BID) book of a market.
+	GetRecentTrades(market *environment.Market) ([]environment.Trade, error)                                 // Gets the last public trades of a market, oldest first.
+	GetListPriceChangeStats() (environment.ListPriceChangeStats, error)                                      // Gets the list of price change
 
-	BuyLimit(market *environment.Market, amount float64, limit float64) (string, error)  // Performs a limit buy action.
-	SellLimit(market *environment.Market, amount float64, limit float64) (string, error) // Performs a limit sell action.
-	BuyMarket(market *environment.Market, amount float64) (string, error)                // Performs a market buy action.
-	SellMarket(market *environment.Market, amount float64) (string, error)               // Performs a market sell action.
+	GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) // Gets the candles opening in the [from, to) time range.
+	GetTradingRules(market *environment.Market) (environment.TradingRules, error)                                                               // Gets the constraints of the exchange on the orders of a market.
 
-	CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 // Calculates the trading fees for an order on a specified market.
-	CalculateWithdrawFees(market *environment.Market, amount float64) float64                                    // Calculates the withdrawal fees on a specified market.
+	BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error)  // Performs a limit buy action.
+	SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) // Performs a limit sell action.
+	BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error)                        // Performs a market buy action.
+	SellMarket(market *environment.Market, amount decimal.Decimal) (string, error)                       // Performs a market sell action.
+
+	CancelOrder(market *environment.Market, orderID string) error                        // Cancels an open order.
+	GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) // Gets the current status of an order.
+	GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error)          // Gets the orders of the user still open on a market.
+
+	CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal // Calculates the trading fees for an order on a specified market.
+	CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error)                                   // Calculates the withdrawal fees on a specified market.
 
 	GetBalance(symbol string) (*decimal.Decimal, error) // Gets the balance of the user of the specified currency.
 	GetDepositAddress(coinTicker string) (string, bool) // Gets the deposit address for the specified coin on the exchange, if exists.
 
-	FeedConnect(markets []*environment.Market) error // Connects to the feed of the exchange.
+	FeedConnect(markets []*environment.Market) error                        // Connects to the feed of the exchange.
+	FeedDisconnect() error                                                  // Disconnects from the feed of the exchange.
+	SubscribeTrades(market *environment.Market) (*TradeSubscription, error) // Subscribes to the public trades of a market got from the feed.
 
-	Withdraw(destinationAddress string, coinTicker string, amount float64) error // Performs a withdraw operation from the exchange to a destination address.
+	Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error // Performs a withdraw operation from the exchange to a destination address.
 
-	String() string // Returns a string representation of the object.
+	Capabilities() Capabilities // Gets the features supported by the exchange.
+	String() string             // Returns a string representation of the object.
 }
 
+// Capabilities describes the features supported by an exchange wrapper: calling the operations
+// of an unsupported feature returns ErrNotSupported.
+type Capabilities struct {
+	Websocket        bool // FeedConnect can be used to get market data from the websocket feed.
+	Candles          bool // Candles can be got using GetCandles and GetCandlesRange.
+	OrderBook        bool // The order book can be got using GetOrderBook.
+	Trades           bool // The public trades of a market can be got using GetRecentTrades and SubscribeTrades.
+	LimitOrders      bool // Limit orders can be placed, checked and cancelled.
+	MarketOrders     bool // Market orders can be placed using BuyMarket and SellMarket.
+	Balance          bool // Balances can be got using GetBalance.
+	Withdraw         bool // Funds can be withdrawn using Withdraw.
+	WithdrawFees     bool // Withdrawal fees can be calculated using CalculateWithdrawFees.
+	PriceChangeStats bool // Price change statistics can be got using GetListPriceChangeStats.
+}
+
+// ErrNotSupported is the error representing when a feature is not supported by an exchange,
+// check the Capabilities of the wrapper before calling.
+var ErrNotSupported = errors.New("Feature not supported by the exchange")
+
 // ErrWebsocketNotSupported is the error representing when an exchange does not support websocket.
-var ErrWebsocketNotSupported = errors.New("Cannot use websocket: exchange does not support it")
+var ErrWebsocketNotSupported = fmt.Errorf("Cannot use websocket: %w", ErrNotSupported)
+
+// ErrOrderNotFound is the error representing when an order cannot be found on the exchange.
+var ErrOrderNotFound = errors.New("Order not found")
+
+// ErrMarketNotListed is the error representing when a market is not listed by the exchange.
+var ErrMarketNotListed = errors.New("Market not listed by the exchange")
+
+// ErrUnsupportedInterval is the error representing when an exchange cannot provide candles of an interval,
+// neither natively nor by resampling a finer one.
+var ErrUnsupportedInterval = errors.New("Unsupported candle interval")
+
+// notSupported returns an ErrNotSupported error describing the unsupported feature of a wrapper.
+func notSupported(wrapper ExchangeWrapper, feature string) error {
+	return fmt.Errorf("%w: %s on %s", ErrNotSupported, feature, wrapper.Name())
+}
+
+// sourceInterval gets the interval to request to an exchange to get candles of the specified interval:
+// the interval itself if natively supported, otherwise the coarsest supported interval dividing it,
+// whose candles must be resampled.
+func sourceInterval(wrapper ExchangeWrapper, native map[environment.Interval]string, interval environment.Interval) (environment.Interval, error) {
+	if _, supported := native[interval]; supported {
+		return interval, nil
+	}
+
+	step := interval.Duration()
+	var source environment.Interval
+	for candidate := range native {
+		duration := candidate.Duration()
+		if duration > 0 && duration < step && step%duration == 0 && duration > source.Duration() {
+			source = candidate
+		}
+	}
+	if source == "" {
+		return "", fmt.Errorf("%w: %s candles on %s", ErrUnsupportedInterval, interval, wrapper.Name())
+	}
+	return source, nil
+}
+
+// rangeStart gets the time to start requesting candles of the source interval to build the candles
+// of the requested interval opening from the specified time.
+func rangeStart(source environment.Interval, interval environment.Interval, from time.Time) time.Time {
+	if source != interval {
+		return from.Truncate(interval.Duration())
+	}
+	return from
+}
+
+// candlesInRange sorts by open time the candles got from an exchange using the source interval and removes
+// the duplicates, then resamples them to the requested interval if needed and keeps the ones opening in [from, to).
+func candlesInRange(candles []environment.CandleStick, source environment.Interval, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].OpenTime.Before(candles[j].OpenTime)
+	})
+
+	// the last version got of a candle is the most updated one.
+	unique := make([]environment.CandleStick, 0, len(candles))
+	for _, candle := range candles {
+		last := len(unique) - 1
+		if last >= 0 && unique[last].OpenTime.Equal(candle.OpenTime) {
+			unique[last] = candle
+			continue
+		}
+		unique = append(unique, candle)
+	}
+
+	if source != interval {
+		var err error
+		unique, err = environment.ResampleCandles(unique, interval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]environment.CandleStick, 0, len(unique))
+	for _, candle := range unique {
+		if !candle.OpenTime.Before(from) && candle.OpenTime.Before(to) {
+			ret = append(ret, candle)
+		}
+	}
+	return ret, nil
+}
 
-// MarketNameFor gets the market name as seen by the exchange.
+// MarketNameFor gets the market name as seen by the exchange: the configured one if any,
+// otherwise the symbol of the market listed by the exchange.
 func MarketNameFor(m *environment.Market, wrapper ExchangeWrapper) string {
-	return m.ExchangeNames[wrapper.Name()]
+	if name := m.ExchangeNames[wrapper.Name()]; name != "" {
+		return name
+	}
+	symbol, _ := NativeSymbol(wrapper, m.Name)
+	return symbol
 }
@@ -17,8 +17,13 @@
 package exchanges
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"sort"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 
@@ -26,27 +31,41 @@ import (
 	"github.com/saniales/go-hitbtc"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
 )
 
 // HitBtcWrapperV2 wraps HitBtc API v2.0
 type HitBtcWrapperV2 struct {
 	api              *hitbtc.HitBtc
-	ws               *hitbtc.WSClient
+	publicKey        string
+	secretKey        string
 	websocketOn      bool
+	feedStop         chan struct{} // Closed to stop the websocket connection of the feed.
+	feedSettings     FeedSettings
+	feedMutex        *sync.Mutex
+	feedMarkets      []*environment.Market // Markets subscribed by the websocket connection of the feed.
+	feedWS           *hitbtc.WSClient      // Current websocket connection of the feed.
+	feedWatchdog     *feedWatchdog         // Watchdog of the current websocket connection of the feed.
 	summaries        *SummaryCache
 	orderbook        *OrderbookCache
+	tradingRules     *TradingRulesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
 	depositAddresses map[string]string
 }
 
 // NewHitBtcV2Wrapper creates a generic wrapper of the HitBtc API v2.0.
 func NewHitBtcV2Wrapper(publicKey string, secretKey string, depositAddresses map[string]string) ExchangeWrapper {
-	ws, _ := hitbtc.NewWSClient()
+	meter := newRequestMeter()
 	return &HitBtcWrapperV2{
-		api:              hitbtc.New(publicKey, secretKey),
-		ws:               ws,
+		api:              hitbtc.NewWithCustomHttpClient(publicKey, secretKey, meter.httpClient()),
+		publicKey:        publicKey,
+		secretKey:        secretKey,
 		websocketOn:      false,
+		feedMutex:        &sync.Mutex{},
 		summaries:        NewSummaryCache(),
 		orderbook:        NewOrderbookCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            meter,
 		depositAddresses: depositAddresses,
 	}
 }
@@ -60,6 +79,24 @@ func (wrapper *HitBtcWrapperV2) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *HitBtcWrapperV2) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *HitBtcWrapperV2) Capabilities() Capabilities {
+	return Capabilities{
+		Websocket:    true,
+		OrderBook:    true,
+		Trades:       true,
+		LimitOrders:  true,
+		MarketOrders: true,
+		Balance:      true,
+		Withdraw:     true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *HitBtcWrapperV2) GetMarkets() ([]*environment.Market, error) {
 	HitBtcMarkets, err := wrapper.api.GetSymbols()
@@ -70,24 +107,43 @@ func (wrapper *HitBtcWrapperV2) GetMarkets() ([]*environment.Market, error) {
 
 	wrappedMarkets := make([]*environment.Market, 0, len(HitBtcMarkets))
 	for _, market := range HitBtcMarkets {
-		wrappedMarkets = append(wrappedMarkets, &environment.Market{
-			Name:           market.Id,
-			BaseCurrency:   market.BaseCurrency,
-			MarketCurrency: market.QuoteCurrency,
-		})
+		wrappedMarket := listedMarket(wrapper, market.Id, market.BaseCurrency, market.QuoteCurrency)
+		wrappedMarket.Rules = environment.TradingRules{
+			TickSize:    decimal.NewFromFloat(market.TickSize),
+			StepSize:    decimal.NewFromFloat(market.QuantityIncrement),
+			MinQuantity: decimal.NewFromFloat(market.QuantityIncrement),
+		}
+		wrapper.tradingRules.Set(market.Id, wrappedMarket.Rules)
+		wrappedMarkets = append(wrappedMarkets, wrappedMarket)
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 
 	return wrappedMarkets, nil
 }
 
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *HitBtcWrapperV2) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *HitBtcWrapperV2) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
 func (wrapper *HitBtcWrapperV2) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.orderbook.Updated(market))
+	if err != nil {
+		return nil, err
+	}
 	ret, exists := wrapper.orderbook.Get(market)
-	if !wrapper.websocketOn {
+	if !fromFeed {
 		hitbtcOrderBook, err := wrapper.api.GetOrderbook(MarketNameFor(market, wrapper))
 
 		if err != nil {
@@ -123,16 +179,81 @@ func (wrapper *HitBtcWrapperV2) GetOrderBook(market *environment.Market) (*envir
 	return ret, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+//
+//     NOTE: go-hitbtc can only get the trades of the user, so the public ones are got via REST.
+func (wrapper *HitBtcWrapperV2) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/public/trades/%s", hitbtc.API_BASE, url.PathEscape(MarketNameFor(market, wrapper))))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Cannot get the trades of market %s: %s", market.Name, resp.Status)
+	}
+
+	var hitbtcTrades []hitbtc.WSTrades
+	if err := json.NewDecoder(resp.Body).Decode(&hitbtcTrades); err != nil {
+		return nil, err
+	}
+
+	// the exchange returns the newest trades first.
+	ret := make([]environment.Trade, len(hitbtcTrades))
+	for i, trade := range hitbtcTrades {
+		converted, err := convertFromHitBtcTrade(trade)
+		if err != nil {
+			return nil, err
+		}
+		ret[len(ret)-1-i] = converted
+	}
+
+	return ret, nil
+}
+
+// convertFromHitBtcTrade converts a public trade got from the exchange.
+func convertFromHitBtcTrade(trade hitbtc.WSTrades) (environment.Trade, error) {
+	price, err := decimal.NewFromString(trade.Price)
+	if err != nil {
+		return environment.Trade{}, err
+	}
+	quantity, err := decimal.NewFromString(trade.Quantity)
+	if err != nil {
+		return environment.Trade{}, err
+	}
+	timestamp, err := time.Parse(time.RFC3339, trade.Timestamp)
+	if err != nil {
+		return environment.Trade{}, err
+	}
+
+	side := environment.Bid
+	if trade.Side == "sell" {
+		side = environment.Ask
+	}
+
+	return environment.Trade{
+		ID:        fmt.Sprint(trade.ID),
+		Price:     price,
+		Quantity:  quantity,
+		Side:      side,
+		Timestamp: timestamp,
+	}, nil
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *HitBtcWrapperV2) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
+func (wrapper *HitBtcWrapperV2) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount, limit)
+	if err != nil {
+		return "", err
+	}
 
 	requestOrder := hitbtc.Order{
 		Symbol:   MarketNameFor(market, wrapper),
 		Side:     "buy",
 		Status:   "new",
 		Type:     "limit",
-		Quantity: amount,
-		Price:    limit,
+		Quantity: toFloat(amount),
+		Price:    toFloat(limit),
 	}
 
 	orderNumber, err := wrapper.api.PlaceOrder(requestOrder)
@@ -143,13 +264,18 @@ func (wrapper *HitBtcWrapperV2) BuyLimit(market *environment.Market, amount floa
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *HitBtcWrapperV2) BuyMarket(market *environment.Market, amount float64) (string, error) {
+func (wrapper *HitBtcWrapperV2) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Bid, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
 	requestOrder := hitbtc.Order{
 		Symbol:   MarketNameFor(market, wrapper),
 		Side:     "buy",
 		Status:   "new",
 		Type:     "market",
-		Quantity: amount,
+		Quantity: toFloat(amount),
 	}
 
 	orderNumber, err := wrapper.api.PlaceOrder(requestOrder)
@@ -160,13 +286,18 @@ func (wrapper *HitBtcWrapperV2) BuyMarket(market *environment.Market, amount flo
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *HitBtcWrapperV2) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
+func (wrapper *HitBtcWrapperV2) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
 	requestOrder := hitbtc.Order{
 		Symbol:   MarketNameFor(market, wrapper),
 		Side:     "sell",
 		Type:     "limit",
-		Quantity: amount,
-		Price:    limit,
+		Quantity: toFloat(amount),
+		Price:    toFloat(limit),
 	}
 
 	orderNumber, err := wrapper.api.PlaceOrder(requestOrder)
@@ -177,7 +308,12 @@ func (wrapper *HitBtcWrapperV2) SellLimit(market *environment.Market, amount flo
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *HitBtcWrapperV2) SellMarket(market *environment.Market, amount float64) (string, error) {
+func (wrapper *HitBtcWrapperV2) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Ask, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
 	clientOrderID, err := uuid.NewV4()
 	if err != nil {
 		return "", err
@@ -186,7 +322,7 @@ func (wrapper *HitBtcWrapperV2) SellMarket(market *environment.Market, amount fl
 		Symbol:        MarketNameFor(market, wrapper),
 		Side:          "sell",
 		Type:          "market",
-		Quantity:      amount,
+		Quantity:      toFloat(amount),
 		ClientOrderId: clientOrderID.String()[:32], // max length is 32 characters
 	}
 
@@ -197,6 +333,139 @@ func (wrapper *HitBtcWrapperV2) SellMarket(market *environment.Market, amount fl
 	return fmt.Sprint(orderNumber.ClientOrderId), nil
 }
 
+// CancelOrder cancels an open order.
+//
+//     NOTE: go-hitbtc can only cancel every order of a market, so the single order is cancelled via REST.
+func (wrapper *HitBtcWrapperV2) CancelOrder(market *environment.Market, orderID string) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/order/%s", hitbtc.API_BASE, url.PathEscape(orderID)), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(wrapper.publicKey, wrapper.secretKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var hitbtcError struct {
+			Error struct {
+				Message     string `json:"message"`
+				Description string `json:"description"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&hitbtcError); err != nil || hitbtcError.Error.Message == "" {
+			return errors.Errorf("Cannot cancel order %s: %s", orderID, resp.Status)
+		}
+		return errors.Errorf("Cannot cancel order %s: %s", orderID, hitbtcError.Error.Message)
+	}
+
+	return nil
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *HitBtcWrapperV2) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	hitbtcOrders, err := wrapper.api.GetOpenOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret *environment.OrderInfo
+	for _, order := range hitbtcOrders {
+		if order.ClientOrderId == orderID {
+			ret = convertFromHitBtcOrder(market, order)
+			break
+		}
+	}
+
+	if ret == nil {
+		hitbtcOrders, err = wrapper.api.GetOrder(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if len(hitbtcOrders) == 0 {
+			return nil, ErrOrderNotFound
+		}
+		ret = convertFromHitBtcOrder(market, hitbtcOrders[0])
+	}
+
+	if ret.FilledQuantity.IsZero() {
+		return ret, nil
+	}
+
+	// fill prices and fees are only reported in the trades generated by the order.
+	hitbtcTrades, err := wrapper.api.GetTrades(MarketNameFor(market, wrapper))
+	if err != nil {
+		return nil, err
+	}
+	total := decimal.Zero
+	for _, trade := range hitbtcTrades {
+		if trade.ClientOrderId != orderID {
+			continue
+		}
+		total = total.Add(decimal.NewFromFloat(trade.Quantity).Mul(decimal.NewFromFloat(trade.Price)))
+		ret.Fee = ret.Fee.Add(decimal.NewFromFloat(trade.Fee))
+	}
+	ret.AverageFillPrice = total.Div(ret.FilledQuantity)
+	ret.FeeCurrency = market.MarketCurrency // the fees are charged in the quote currency.
+
+	return ret, nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *HitBtcWrapperV2) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	hitbtcOrders, err := wrapper.api.GetOpenOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, 0, len(hitbtcOrders))
+	for _, order := range hitbtcOrders {
+		if order.Symbol == MarketNameFor(market, wrapper) {
+			ret = append(ret, convertFromHitBtcOrder(market, order))
+		}
+	}
+
+	return ret, nil
+}
+
+// convertFromHitBtcOrder converts a hitbtc order to a environment.OrderInfo.
+func convertFromHitBtcOrder(market *environment.Market, order hitbtc.Order) *environment.OrderInfo {
+	side := environment.Bid
+	if order.Side == "sell" {
+		side = environment.Ask
+	}
+
+	var status environment.OrderStatus
+	switch order.Status {
+	case "new", "suspended":
+		status = environment.OrderStatusOpen
+	case "partiallyFilled":
+		status = environment.OrderStatusPartiallyFilled
+	case "filled":
+		status = environment.OrderStatusFilled
+	case "canceled":
+		status = environment.OrderStatusCancelled
+	case "expired":
+		status = environment.OrderStatusRejected
+	default:
+		status = environment.OrderStatusUnknown
+	}
+
+	return &environment.OrderInfo{
+		ID:             order.ClientOrderId,
+		Market:         market,
+		Side:           side,
+		Status:         status,
+		Price:          decimal.NewFromFloat(order.Price),
+		Quantity:       decimal.NewFromFloat(order.Quantity),
+		FilledQuantity: decimal.NewFromFloat(order.CumQuantity),
+		Timestamp:      order.Created,
+	}
+}
+
 // GetTicker gets the updated ticker for a market.
 func (wrapper *HitBtcWrapperV2) GetTicker(market *environment.Market) (*environment.Ticker, error) {
 	hitbtcTicker, err := wrapper.api.GetTicker(MarketNameFor(market, wrapper))
@@ -216,8 +485,12 @@ func (wrapper *HitBtcWrapperV2) GetTicker(market *environment.Market) (*environm
 
 // GetMarketSummary gets the current market summary.
 func (wrapper *HitBtcWrapperV2) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.summaries.Updated(market))
+	if err != nil {
+		return nil, err
+	}
 	ret, exists := wrapper.summaries.Get(market)
-	if !wrapper.websocketOn {
+	if !fromFeed {
 		hitbtcSummary, err := wrapper.api.GetTicker(MarketNameFor(market, wrapper))
 		if err != nil {
 			return nil, err
@@ -279,7 +552,7 @@ func (wrapper *HitBtcWrapperV2) GetDepositAddress(coinTicker string) (string, bo
 }
 
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
-func (wrapper *HitBtcWrapperV2) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *HitBtcWrapperV2) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0025
@@ -289,40 +562,144 @@ func (wrapper *HitBtcWrapperV2) CalculateTradingFees(market *environment.Market,
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *HitBtcWrapperV2) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *HitBtcWrapperV2) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *HitBtcWrapperV2) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	panic("Not Implemented")
+func (wrapper *HitBtcWrapperV2) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *HitBtcWrapperV2) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
+}
+
+// SetFeedSettings sets how the data got from the websocket feed is served.
+func (wrapper *HitBtcWrapperV2) SetFeedSettings(settings FeedSettings) {
+	wrapper.feedSettings = settings
 }
 
 // FeedConnect connects to the feed of the exchange.
+//
+//     A new websocket connection is opened with exponential backoff when the current one is lost or stops
+//     receiving messages, subscribing again to the channels of the markets, until the feed is disconnected.
+//     If the feed is already connected, the markets not subscribed yet are added to its connection.
 func (wrapper *HitBtcWrapperV2) FeedConnect(markets []*environment.Market) error {
+	if wrapper.feedStop != nil {
+		wrapper.addFeedMarkets(markets)
+		return nil
+	}
+	wrapper.feedStop = make(chan struct{})
+	wrapper.feedMutex.Lock()
+	wrapper.feedMarkets = append([]*environment.Market(nil), markets...)
+	wrapper.feedMutex.Unlock()
+
+	err := superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		ws, err := hitbtc.NewWSClient()
+		if err != nil {
+			return feedConnection{}, err
+		}
+
+		wrapper.feedMutex.Lock()
+		defer wrapper.feedMutex.Unlock()
+		watchdog := newFeedWatchdog()
+		for _, m := range wrapper.feedMarkets {
+			err := wrapper.subscribeFeeds(ws, watchdog, m)
+			if err != nil {
+				ws.Close()
+				return feedConnection{}, err
+			}
+		}
+		wrapper.feedWS = ws
+		wrapper.feedWatchdog = watchdog
+
+		return feedConnection{
+			done:  watchdog.watch(wrapper.feedStop),
+			close: ws.Close,
+		}, nil
+	})
+	if err != nil {
+		wrapper.feedStop = nil
+		return err
+	}
 	wrapper.websocketOn = true
+
+	return nil
+}
+
+// addFeedMarkets subscribes to the channels of the markets not subscribed yet by the connected feed.
+//
+//     If the subscription fails, the connection is considered lost, so that it is opened again subscribing
+//     to all the markets.
+func (wrapper *HitBtcWrapperV2) addFeedMarkets(markets []*environment.Market) {
+	wrapper.feedMutex.Lock()
+	defer wrapper.feedMutex.Unlock()
+
 	for _, m := range markets {
-		err := wrapper.subscribeFeeds(m)
-		if err != nil {
-			return err
+		if feedSubscribed(wrapper.feedMarkets, m) {
+			continue
+		}
+		wrapper.feedMarkets = append(wrapper.feedMarkets, m)
+		if wrapper.feedWS == nil {
+			continue
+		}
+		if err := wrapper.subscribeFeeds(wrapper.feedWS, wrapper.feedWatchdog, m); err != nil {
+			logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+			wrapper.feedWatchdog.expire()
 		}
 	}
+}
+
+// feedSubscribed checks if a market is among the markets subscribed by a feed.
+func feedSubscribed(subscribed []*environment.Market, market *environment.Market) bool {
+	for _, m := range subscribed {
+		if m.Name == market.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *HitBtcWrapperV2) FeedDisconnect() error {
+	if wrapper.feedStop == nil {
+		return nil
+	}
+	wrapper.websocketOn = false
+	close(wrapper.feedStop)
+	wrapper.feedStop = nil
+
+	wrapper.feedMutex.Lock()
+	wrapper.feedMarkets = nil
+	wrapper.feedWS = nil
+	wrapper.feedWatchdog = nil
+	wrapper.feedMutex.Unlock()
 
 	return nil
 }
 
+// SubscribeTrades subscribes to the public trades of a market, got by polling the REST API.
+//
+//     NOTE: go-hitbtc cannot decode the trades sent by the websocket feed.
+func (wrapper *HitBtcWrapperV2) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return pollTrades(wrapper, market, wrapper.GetRecentTrades), nil
+}
+
 // subscribeFeeds subscribes to the Market Summary Feed service.
-func (wrapper *HitBtcWrapperV2) subscribeFeeds(market *environment.Market) error {
+func (wrapper *HitBtcWrapperV2) subscribeFeeds(ws *hitbtc.WSClient, watchdog *feedWatchdog, market *environment.Market) error {
 	handleTicker := func(wrapper *HitBtcWrapperV2, summaryChannel <-chan hitbtc.WSNotificationTickerResponse, m *environment.Market) {
 		for {
 			summary, stillOpen := <-summaryChannel
 			if !stillOpen {
 				return
 			}
+			watchdog.touch()
 
 			high, _ := decimal.NewFromString(summary.High)
 			low, _ := decimal.NewFromString(summary.Low)
@@ -345,7 +722,7 @@ func (wrapper *HitBtcWrapperV2) subscribeFeeds(market *environment.Market) error
 	}
 
 	handleOrderbook := func(wrapper *HitBtcWrapperV2, bookSnapshotChannel <-chan hitbtc.WSNotificationOrderbookSnapshot, bookUpdateChannel <-chan hitbtc.WSNotificationOrderbookUpdate, m *environment.Market) {
-		var currentSequence int64
+		book := NewL2OrderBook(nil) // HitBTC does not send checksums, the sequence numbers are validated instead.
 
 		for {
 			select {
@@ -353,59 +730,45 @@ func (wrapper *HitBtcWrapperV2) subscribeFeeds(market *environment.Market) error
 				if !stillOpen {
 					return
 				}
-				if currentSequence > snap.Sequence { // my snapshot is more recent than the one provided
-					continue
-				}
+				watchdog.touch()
 
-				orderbook := new(environment.OrderBook)
-
-				for _, item := range snap.Ask {
-					price, _ := decimal.NewFromString(item.Price)
-					size, _ := decimal.NewFromString(item.Size)
-
-					orderbook.Asks = append(orderbook.Asks, environment.Order{
-						Value:    price,
-						Quantity: size,
-					})
-				}
-				for _, item := range snap.Bid {
-					price, _ := decimal.NewFromString(item.Price)
-					size, _ := decimal.NewFromString(item.Size)
-
-					orderbook.Bids = append(orderbook.Bids, environment.Order{
-						Value:    price,
-						Quantity: size,
-					})
-				}
-				wrapper.orderbook.Set(market, orderbook)
+				book.Reset(environment.OrderBook{
+					Asks: hitbtcLevels(snap.Ask),
+					Bids: hitbtcLevels(snap.Bid),
+				}, snap.Sequence)
 			case update, stillOpen := <-bookUpdateChannel:
 				if !stillOpen {
 					return
 				}
+				watchdog.touch()
 
-				if currentSequence > update.Sequence {
-					continue // my snapshot is more recent than the one provided
-				}
-
-				orderbook, exists := wrapper.orderbook.Get(m)
-				if !exists {
+				if !book.Synced() {
 					continue // wait for snapshot
 				}
-
-				orderbook.Asks = updateBook(orderbook.Asks, update.Ask, false)
-				orderbook.Bids = updateBook(orderbook.Bids, update.Bid, true)
-
-				wrapper.orderbook.Set(market, orderbook)
+				err := book.Apply(BookDiff{
+					FirstUpdateID: update.Sequence,
+					LastUpdateID:  update.Sequence,
+					Asks:          hitbtcLevels(update.Ask),
+					Bids:          hitbtcLevels(update.Bid),
+				})
+				if err != nil { // a new snapshot is sent when connecting again.
+					logrus.Warnf("%s feed error: %s", wrapper.Name(), err)
+					watchdog.expire()
+					return
+				}
 			}
+
+			orderbook := book.Top(0)
+			wrapper.orderbook.Set(m, &orderbook)
 		}
 	}
 
-	summaryChannel, err := wrapper.ws.SubscribeTicker(MarketNameFor(market, wrapper))
+	summaryChannel, err := ws.SubscribeTicker(MarketNameFor(market, wrapper))
 	if err != nil {
 		return err
 	}
 
-	bookUpdateChannel, bookSnapshotChannel, err := wrapper.ws.SubscribeOrderbook(MarketNameFor(market, wrapper))
+	bookUpdateChannel, bookSnapshotChannel, err := ws.SubscribeOrderbook(MarketNameFor(market, wrapper))
 	if err != nil {
 		return err
 	}
@@ -415,57 +778,23 @@ func (wrapper *HitBtcWrapperV2) subscribeFeeds(market *environment.Market) error
 	return nil
 }
 
-func updateBook(ordersToUpdate []environment.Order, newOrders []hitbtc.WSSubtypeTrade, reverseOrdering bool) []environment.Order {
-	N := len(ordersToUpdate)
-
-	for _, item := range newOrders {
-		// replace values
+// hitbtcLevels converts the price levels of an order book got from the exchange.
+func hitbtcLevels(items []hitbtc.WSSubtypeTrade) []environment.Order {
+	levels := make([]environment.Order, len(items))
+	for i, item := range items {
 		price, _ := decimal.NewFromString(item.Price)
 		size, _ := decimal.NewFromString(item.Size)
-
-		newOrder := environment.Order{
+		levels[i] = environment.Order{
 			Value:    price,
 			Quantity: size,
 		}
-
-		i := sort.Search(N, func(i int) bool {
-			if reverseOrdering {
-				return ordersToUpdate[i].Value.LessThanOrEqual(price)
-			}
-			return ordersToUpdate[i].Value.GreaterThanOrEqual(price)
-		})
-		if size.Equals(decimal.Zero) { //remove it
-			if i == N-1 {
-				ordersToUpdate = ordersToUpdate[:i-1]
-				N--
-			} else { // i < N - 1
-				ordersToUpdate = append(ordersToUpdate[:i], ordersToUpdate[i+1:]...)
-				N--
-			}
-		} else if i == N { // not found, append
-			ordersToUpdate = append(ordersToUpdate, newOrder)
-			N++
-		} else if price.Equals(ordersToUpdate[i].Value) {
-			// replace it
-			ordersToUpdate[i] = newOrder
-		} else if i == 0 { // prepend it
-			ordersToUpdate = append([]environment.Order{newOrder}, ordersToUpdate...)
-			N++
-		} else { // 0 < i < N, so put new order in the middle
-			orders := ordersToUpdate[:i-1]
-			orders = append(orders, newOrder)
-			orders = append(orders, ordersToUpdate[i-1:]...)
-			ordersToUpdate = orders
-			N++
-		}
 	}
-
-	return ordersToUpdate
+	return levels
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *HitBtcWrapperV2) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	_, err := wrapper.api.Withdraw(destinationAddress, coinTicker, amount)
+func (wrapper *HitBtcWrapperV2) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.api.Withdraw(destinationAddress, coinTicker, toFloat(amount))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,139 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/indicators"
+)
+
+// attachedCandleIndicator is a candle indicator along with the last candle it has been updated with.
+type attachedCandleIndicator struct {
+	indicator indicators.CandleIndicator
+	last      *environment.CandleStick
+}
+
+// attachedIndicators contains the indicators attached to each market.
+var attachedIndicators = struct {
+	mutex   sync.Mutex
+	summary map[*environment.Market][]indicators.Indicator
+	candles map[*environment.Market]map[environment.Interval][]*attachedCandleIndicator
+}{
+	summary: make(map[*environment.Market][]indicators.Indicator),
+	candles: make(map[*environment.Market]map[environment.Interval][]*attachedCandleIndicator),
+}
+
+// AttachIndicator attaches an indicator to a market: the indicator is updated with the last price
+// of every summary of the market got by the wrappers, either from REST calls or websocket feeds.
+func AttachIndicator(market *environment.Market, indicator indicators.Indicator) {
+	attachedIndicators.mutex.Lock()
+	attachedIndicators.summary[market] = append(attachedIndicators.summary[market], indicator)
+	attachedIndicators.mutex.Unlock()
+}
+
+// AttachCandleIndicator attaches an indicator to a market: the indicator is updated with every new
+// closed candle of the specified interval got by the wrappers.
+//
+//     The first candles got after attaching the indicator are all used to warm it up.
+func AttachCandleIndicator(market *environment.Market, interval environment.Interval, indicator indicators.CandleIndicator) {
+	attachedIndicators.mutex.Lock()
+	intervals, exists := attachedIndicators.candles[market]
+	if !exists {
+		intervals = make(map[environment.Interval][]*attachedCandleIndicator)
+		attachedIndicators.candles[market] = intervals
+	}
+	intervals[interval] = append(intervals[interval], &attachedCandleIndicator{indicator: indicator})
+	attachedIndicators.mutex.Unlock()
+}
+
+// DetachIndicators detaches all the indicators attached to a market.
+func DetachIndicators(market *environment.Market) {
+	attachedIndicators.mutex.Lock()
+	delete(attachedIndicators.summary, market)
+	delete(attachedIndicators.candles, market)
+	attachedIndicators.mutex.Unlock()
+}
+
+// updateIndicators updates the indicators attached to a market with a new summary.
+func updateIndicators(market *environment.Market, summary *environment.MarketSummary) {
+	attachedIndicators.mutex.Lock()
+	defer attachedIndicators.mutex.Unlock()
+
+	for _, indicator := range attachedIndicators.summary[market] {
+		indicator.Update(summary.Last)
+	}
+}
+
+// updateCandleIndicators updates the candle indicators attached to a market with the candles
+// closed since their last update.
+//
+//     The last candle of the series is considered still open, unless its close time is over.
+func updateCandleIndicators(market *environment.Market, interval environment.Interval, candles []environment.CandleStick) {
+	closed := candles
+	if len(candles) > 0 {
+		last := candles[len(candles)-1]
+		if last.CloseTime.IsZero() || !last.CloseTime.Before(now()) {
+			closed = candles[:len(candles)-1]
+		}
+	}
+	updateClosedCandleIndicators(market, interval, closed)
+}
+
+// updateClosedCandleIndicators updates the candle indicators attached to a market with the closed candles
+// of a series which are newer than their last update.
+func updateClosedCandleIndicators(market *environment.Market, interval environment.Interval, closed []environment.CandleStick) {
+	if len(closed) == 0 {
+		return
+	}
+
+	attachedIndicators.mutex.Lock()
+	defer attachedIndicators.mutex.Unlock()
+
+	for _, attached := range attachedIndicators.candles[market][interval] {
+		start := 0
+		if attached.last != nil {
+			start = firstNewCandle(closed, *attached.last)
+		}
+
+		for _, candle := range closed[start:] {
+			attached.indicator.UpdateCandle(candle)
+		}
+		last := closed[len(closed)-1]
+		attached.last = &last
+	}
+}
+
+// firstNewCandle gets the index of the first candle of the series after the specified one, using
+// the open time or, if not available, looking for a candle with the same values.
+func firstNewCandle(candles []environment.CandleStick, last environment.CandleStick) int {
+	if !last.OpenTime.IsZero() {
+		return sort.Search(len(candles), func(i int) bool {
+			return candles[i].OpenTime.After(last.OpenTime)
+		})
+	}
+
+	for i := len(candles) - 1; i >= 0; i-- {
+		if candles[i].Open.Equal(last.Open) && candles[i].High.Equal(last.High) &&
+			candles[i].Low.Equal(last.Low) && candles[i].Close.Equal(last.Close) &&
+			candles[i].Volume.Equal(last.Volume) {
+			return i + 1
+		}
+	}
+	return 0
+}
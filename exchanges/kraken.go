@@ -16,8 +16,11 @@
 package exchanges
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	krakenapi "github.com/beldur/kraken-go-api-client"
@@ -33,16 +36,25 @@ type KrakenWrapper struct {
 	api              *krakenapi.KrakenApi
 	summaries        *SummaryCache
 	candles          *CandlesCache
+	tradingRules     *TradingRulesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
+	altnamesMutex    *sync.RWMutex
+	altnames         map[string]string // Alternative names of the pairs (e.g. XBTUSD for XXBTZUSD), used by the orders.
 	depositAddresses map[string]string
 	websocketOn      bool
 }
 
 // NewKrakenWrapper creates a generic wrapper of the poloniex API.
 func NewKrakenWrapper(publicKey string, secretKey string, depositAddresses map[string]string) ExchangeWrapper {
+	meter := newRequestMeter()
 	return &KrakenWrapper{
-		api:              krakenapi.New(publicKey, secretKey),
+		api:              krakenapi.NewWithClient(publicKey, secretKey, meter.httpClient()),
 		summaries:        NewSummaryCache(),
 		candles:          NewCandlesCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            meter,
+		altnamesMutex:    &sync.RWMutex{},
+		altnames:         make(map[string]string),
 		depositAddresses: depositAddresses,
 		websocketOn:      false,
 	}
@@ -57,6 +69,22 @@ func (wrapper *KrakenWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *KrakenWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *KrakenWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		Candles:      true,
+		OrderBook:    true,
+		Trades:       true,
+		LimitOrders:  true,
+		MarketOrders: true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *KrakenWrapper) GetMarkets() ([]*environment.Market, error) {
 	krakenMarkets, err := wrapper.api.AssetPairs()
@@ -66,23 +94,53 @@ func (wrapper *KrakenWrapper) GetMarkets() ([]*environment.Market, error) {
 
 	markets := structs.Map(krakenMarkets)
 
-	wrappedMarkets := make([]*environment.Market, len(markets))
-	i := 0
+	wrappedMarkets := make([]*environment.Market, 0, len(markets))
+	wrapper.altnamesMutex.Lock()
+	defer wrapper.altnamesMutex.Unlock()
 	for name, pair := range markets {
 		p := pair.(krakenapi.AssetPairInfo)
-		wrappedMarkets[i] = &environment.Market{
-			Name:           name,
-			BaseCurrency:   p.Base,
-			MarketCurrency: p.Quote,
+		if p.Base == "" || p.Quote == "" {
+			continue // pair not returned by the exchange.
+		}
+		market := listedMarket(wrapper, name, krakenCurrency(p.Base), krakenCurrency(p.Quote))
+		market.Rules = environment.TradingRules{
+			TickSize: stepOf(p.PairDecimals),
+			StepSize: stepOf(p.LotDecimals),
 		}
-		i++
+		wrapper.tradingRules.Set(name, market.Rules)
+		wrapper.tradingRules.Set(p.Altname, market.Rules)
+		wrapper.altnames[name] = p.Altname
+		wrappedMarkets = append(wrappedMarkets, market)
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 
 	return wrappedMarkets, nil
 }
 
+// krakenCurrency converts a kraken asset name to the common currency ticker (e.g. XXBT to BTC).
+func krakenCurrency(asset string) string {
+	if len(asset) == 4 && (asset[0] == 'X' || asset[0] == 'Z') {
+		asset = asset[1:]
+	}
+	if asset == "XBT" {
+		return "BTC"
+	}
+	return asset
+}
+
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *KrakenWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *KrakenWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
@@ -115,42 +173,197 @@ func (wrapper *KrakenWrapper) GetOrderBook(market *environment.Market) (*environ
 	return &orderBook, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *KrakenWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	krakenTrades, err := wrapper.api.Trades(MarketNameFor(market, wrapper), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.Trade, len(krakenTrades.Trades))
+	for i, trade := range krakenTrades.Trades {
+		side := environment.Bid
+		if trade.Sell {
+			side = environment.Ask
+		}
+
+		ret[i] = environment.Trade{
+			Price:     decimal.NewFromFloat(trade.PriceFloat),
+			Quantity:  decimal.NewFromFloat(trade.VolumeFloat),
+			Side:      side,
+			Timestamp: time.Unix(trade.Time, 0),
+		}
+	}
+
+	return ret, nil
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *KrakenWrapper) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "buy", "limit", fmt.Sprint(amount), map[string]string{"price": fmt.Sprint(limit)})
+func (wrapper *KrakenWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount, limit)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprint(orderNumber.TransactionIds), nil
+
+	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "buy", "limit", amount.String(), map[string]string{"price": limit.String()})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(orderNumber.TransactionIds, ","), nil
 }
 
 // SellLimit performs a limit sell action.
 //
 // NOTE: In kraken buy and sell orders behave the same (the go kraken api automatically puts it on correct side)
-func (wrapper *KrakenWrapper) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "sell", "limit", fmt.Sprint(amount), map[string]string{"price": fmt.Sprint(limit)})
+func (wrapper *KrakenWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount, limit)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprint(orderNumber.TransactionIds), nil
+
+	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "sell", "limit", amount.String(), map[string]string{"price": limit.String()})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(orderNumber.TransactionIds, ","), nil
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *KrakenWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "buy", "market", fmt.Sprint(amount), map[string]string{})
+func (wrapper *KrakenWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Bid, amount, decimal.Zero)
+	if err != nil {
+		return "", err
+	}
+
+	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "buy", "market", amount.String(), map[string]string{})
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprint(orderNumber.TransactionIds), nil
+	return strings.Join(orderNumber.TransactionIds, ","), nil
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *KrakenWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "sell", "market", fmt.Sprint(amount), map[string]string{})
+func (wrapper *KrakenWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	amount, _, err := RoundOrder(wrapper, market, environment.Ask, amount, decimal.Zero)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprint(orderNumber.TransactionIds), nil
+
+	orderNumber, err := wrapper.api.AddOrder(MarketNameFor(market, wrapper), "sell", "market", amount.String(), map[string]string{})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(orderNumber.TransactionIds, ","), nil
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *KrakenWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	_, err := wrapper.api.CancelOrder(orderID)
+	return err
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *KrakenWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	krakenOrders, err := wrapper.api.QueryOrders(orderID, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	order, exists := (*krakenOrders)[orderID]
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+
+	return convertFromKrakenOrder(market, orderID, order), nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *KrakenWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	krakenOrders, err := wrapper.api.OpenOrders(map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the orders name their pair with its alternative name.
+	name := MarketNameFor(market, wrapper)
+	altname := wrapper.altnameOf(name)
+	ret := make([]*environment.OrderInfo, 0, len(krakenOrders.Open))
+	for id, order := range krakenOrders.Open {
+		if order.Description.AssetPair == name || order.Description.AssetPair == altname {
+			ret = append(ret, convertFromKrakenOrder(market, id, order))
+		}
+	}
+
+	return ret, nil
+}
+
+// altnameOf gets the alternative name of a pair, loading the markets if not known yet,
+// or the name itself if it has no alternative name.
+func (wrapper *KrakenWrapper) altnameOf(name string) string {
+	wrapper.altnamesMutex.RLock()
+	altname, exists := wrapper.altnames[name]
+	loaded := len(wrapper.altnames) > 0
+	wrapper.altnamesMutex.RUnlock()
+	if exists {
+		return altname
+	}
+	if loaded {
+		return name
+	}
+
+	if _, err := wrapper.GetMarkets(); err != nil {
+		return name
+	}
+	wrapper.altnamesMutex.RLock()
+	defer wrapper.altnamesMutex.RUnlock()
+	if altname, exists := wrapper.altnames[name]; exists {
+		return altname
+	}
+	return name
+}
+
+// convertFromKrakenOrder converts a kraken order to a environment.OrderInfo.
+func convertFromKrakenOrder(market *environment.Market, id string, order krakenapi.Order) *environment.OrderInfo {
+	price, _ := decimal.NewFromString(order.Description.PrimaryPrice)
+	quantity, _ := decimal.NewFromString(order.Volume)
+	filled := decimal.NewFromFloat(order.VolumeExecuted)
+
+	side := environment.Bid
+	if order.Description.Type == "sell" {
+		side = environment.Ask
+	}
+
+	var status environment.OrderStatus
+	switch order.Status {
+	case "pending", "open":
+		if filled.IsZero() {
+			status = environment.OrderStatusOpen
+		} else {
+			status = environment.OrderStatusPartiallyFilled
+		}
+	case "closed":
+		status = environment.OrderStatusFilled
+	case "canceled":
+		status = environment.OrderStatusCancelled
+	case "expired":
+		status = environment.OrderStatusRejected
+	default:
+		status = environment.OrderStatusUnknown
+	}
+
+	return &environment.OrderInfo{
+		ID:               id,
+		Market:           market,
+		Side:             side,
+		Status:           status,
+		Price:            price,
+		Quantity:         quantity,
+		FilledQuantity:   filled,
+		AverageFillPrice: decimal.NewFromFloat(order.Price),
+		Fee:              decimal.NewFromFloat(order.Fee),
+		FeeCurrency:      market.MarketCurrency, // the fee is charged in the quote currency by default.
+		Timestamp:        time.Unix(int64(order.OpenTime), 0),
+	}
 }
 
 // GetTicker gets the updated ticker for a market.
@@ -199,69 +412,100 @@ func (wrapper *KrakenWrapper) GetMarketSummary(market *environment.Market) (*env
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *KrakenWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
+//
+//     Candles are aggregated from the trades of the last 24 hours.
+func (wrapper *KrakenWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
 	if !wrapper.websocketOn {
-		now := time.Now()
+		now := now()
 
-		krakenTrades, err := wrapper.api.Trades(MarketNameFor(market, wrapper), now.Add(-time.Hour*24).Unix())
+		ret, err := wrapper.aggregateTrades(market, interval, now.Add(-time.Hour*24), now)
 		if err != nil {
 			return nil, err
 		}
 
-		trades := krakenTrades.Trades
+		wrapper.candles.Set(market, interval, ret)
+	}
+
+	ret, candleLoaded := wrapper.candles.Get(market, interval)
+	if !candleLoaded {
+		return nil, errors.New("No candle data yet")
+	}
 
-		for lastTradeTime := time.Unix(krakenTrades.Last, 0); lastTradeTime.Before(now); {
-			krakenTrades, err = wrapper.api.Trades(MarketNameFor(market, wrapper), now.Add(-time.Hour*24).Unix())
-			if err != nil {
-				return nil, err
-			}
+	return ret, nil
+}
 
-			trades = append(trades, krakenTrades.Trades...)
-		}
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+//
+//     Candles are aggregated from the trades of the time range.
+func (wrapper *KrakenWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	ret, err := wrapper.aggregateTrades(market, interval, from.Truncate(interval.Duration()), to)
+	if err != nil {
+		return nil, err
+	}
+
+	return candlesInRange(ret, interval, interval, from, to)
+}
 
-		ret := make([]environment.CandleStick, 0, 50)
-
-		step := time.Minute * 30
-		start := time.Unix(krakenTrades.Trades[0].Time, 0)
-
-		var open = decimal.NewFromFloat(krakenTrades.Trades[0].PriceFloat)
-		var high = open
-		var low = open
-		var close decimal.Decimal
-
-		N := len(trades)
-		for i := 1; i < N; i++ {
-			currentTrade := trades[i]
-			candleTime := time.Unix(currentTrade.Time, 0)
-			isLastTrade := i == N-1
-
-			if candleTime.Before(start.Add(step)) || isLastTrade {
-				// aggregate candles from trades.
-				currentPrice := decimal.NewFromFloat(currentTrade.PriceFloat)
-				high = decimal.Max(high, currentPrice)
-				low = decimal.Min(low, currentPrice)
-			} else {
-				// add candle with aggregate data and reset.
-				previousTrade := trades[i-1]
-				close = decimal.NewFromFloat(previousTrade.PriceFloat)
-				ret = append(ret, environment.CandleStick{
-					High:  high,
-					Open:  open,
-					Close: close,
-					Low:   low,
-				})
-				open = decimal.NewFromFloat(currentTrade.PriceFloat)
-				high = decimal.NewFromFloat(0)
-				low = decimal.NewFromFloat(999999999)
-			}
+// aggregateTrades builds the candles of the specified interval from the trades of a market
+// in the [from, to) time range.
+func (wrapper *KrakenWrapper) aggregateTrades(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	step := interval.Duration()
+	if step == 0 {
+		return nil, fmt.Errorf("%w: %s candles on %s", ErrUnsupportedInterval, interval, wrapper.Name())
+	}
+
+	krakenTrades, err := wrapper.api.Trades(MarketNameFor(market, wrapper), from.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	trades := krakenTrades.Trades
+
+	// Last is the nanoseconds timestamp to use to get the next trades.
+	for len(krakenTrades.Trades) > 0 && time.Unix(0, krakenTrades.Last).Before(to) {
+		if err := wrapper.meter.request(context.Background(), "GetCandlesRange"); err != nil {
+			return nil, err
+		}
+		krakenTrades, err = wrapper.api.Trades(MarketNameFor(market, wrapper), krakenTrades.Last)
+		if err != nil {
+			return nil, err
 		}
 
-		wrapper.candles.Set(market, ret)
+		trades = append(trades, krakenTrades.Trades...)
 	}
 
-	ret, candleLoaded := wrapper.candles.Get(market)
-	if !candleLoaded {
-		return nil, errors.New("No candle data yet")
+	ret := make([]environment.CandleStick, 0, 50)
+
+	for _, trade := range trades {
+		tradeTime := time.Unix(trade.Time, 0)
+		if !tradeTime.Before(to) {
+			break
+		}
+
+		price := decimal.NewFromFloat(trade.PriceFloat)
+		volume := decimal.NewFromFloat(trade.VolumeFloat)
+		openTime := tradeTime.Truncate(step)
+
+		last := len(ret) - 1
+		if last < 0 || !ret[last].OpenTime.Equal(openTime) {
+			ret = append(ret, environment.CandleStick{
+				High:       price,
+				Open:       price,
+				Close:      price,
+				Low:        price,
+				Volume:     volume,
+				OpenTime:   openTime,
+				CloseTime:  openTime.Add(step - time.Millisecond),
+				TradeCount: 1,
+			})
+			continue
+		}
+
+		ret[last].High = decimal.Max(ret[last].High, price)
+		ret[last].Low = decimal.Min(ret[last].Low, price)
+		ret[last].Close = price
+		ret[last].Volume = ret[last].Volume.Add(volume)
+		ret[last].TradeCount++
 	}
 
 	return ret, nil
@@ -269,12 +513,7 @@ func (wrapper *KrakenWrapper) GetCandles(market *environment.Market, interval st
 
 // GetBalance gets the balance of the user of the specified currency.
 func (wrapper *KrakenWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
-	_, err := wrapper.api.Balance()
-	if err != nil {
-		return nil, err
-	}
-
-	panic("Help me integrate this feature!")
+	return nil, notSupported(wrapper, "balances")
 }
 
 // GetDepositAddress gets the deposit address for the specified coin on the exchange.
@@ -286,7 +525,7 @@ func (wrapper *KrakenWrapper) GetDepositAddress(coinTicker string) (string, bool
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Kraken fees are currently hardcoded.
-func (wrapper *KrakenWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *KrakenWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0016
@@ -296,12 +535,12 @@ func (wrapper *KrakenWrapper) CalculateTradingFees(market *environment.Market, a
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *KrakenWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *KrakenWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the exchange.
@@ -309,12 +548,17 @@ func (wrapper *KrakenWrapper) FeedConnect(markets []*environment.Market) error {
 	return ErrWebsocketNotSupported
 }
 
-// SubscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
-func (wrapper *KrakenWrapper) subscribeMarketSummaryFeed(market *environment.Market) {
-	panic("Websocket Not Supported")
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *KrakenWrapper) FeedDisconnect() error {
+	return ErrWebsocketNotSupported
+}
+
+// SubscribeTrades subscribes to the public trades of a market, got by polling the REST API.
+func (wrapper *KrakenWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return pollTrades(wrapper, market, wrapper.GetRecentTrades), nil
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *KrakenWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	panic("Not Supported")
+func (wrapper *KrakenWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return notSupported(wrapper, "withdrawals")
 }
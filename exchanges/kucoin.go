@@ -17,8 +17,10 @@
 package exchanges
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/fiore/kucoin-go"
 	"github.com/fiore/kucoin-go/websocket"
@@ -33,18 +35,23 @@ type KucoinWrapper struct {
 	websocketOn      bool
 	summaries        *SummaryCache
 	orderbook        *OrderbookCache
+	tradingRules     *TradingRulesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
 	depositAddresses map[string]string
 }
 
 // NewKucoinWrapper creates a generic wrapper of theKucoin
 func NewKucoinWrapper(publicKey string, secretKey string, depositAddresses map[string]string) ExchangeWrapper {
 	ws, _ := websocket.NewWS()
+	meter := newRequestMeter()
 	return &KucoinWrapper{
-		api:              kucoin.New(publicKey, secretKey),
+		api:              kucoin.NewCustomClient(publicKey, secretKey, *meter.httpClient()),
 		ws:               ws,
 		websocketOn:      false,
 		summaries:        NewSummaryCache(),
 		orderbook:        NewOrderbookCache(),
+		tradingRules:     NewTradingRulesCache(),
+		meter:            meter,
 		depositAddresses: depositAddresses,
 	}
 }
@@ -58,6 +65,21 @@ func (wrapper *KucoinWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *KucoinWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *KucoinWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		OrderBook:   true,
+		LimitOrders: true,
+		Balance:     true,
+		Withdraw:    true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *KucoinWrapper) GetMarkets() ([]*environment.Market, error) {
 	KucoinMarkets, err := wrapper.api.GetSymbols()
@@ -65,21 +87,46 @@ func (wrapper *KucoinWrapper) GetMarkets() ([]*environment.Market, error) {
 	if err != nil {
 		return nil, err
 	}
+	coins, err := wrapper.api.GetCoins()
+	if err != nil {
+		return nil, err
+	}
+	tradePrecisions := make(map[string]int, len(coins))
+	for _, coin := range coins {
+		tradePrecisions[coin.Coin] = coin.TradePrecision
+	}
 
 	wrappedMarkets := make([]*environment.Market, 0, len(KucoinMarkets))
 	for _, market := range KucoinMarkets {
-		wrappedMarkets = append(wrappedMarkets, &environment.Market{
-			Name:           market.Symbol,
-			BaseCurrency:   market.CoinType,
-			MarketCurrency: market.CoinTypePair,
-		})
+		wrappedMarket := listedMarket(wrapper, market.Symbol, market.CoinType, market.CoinTypePair)
+		if precision, ok := tradePrecisions[market.CoinType]; ok {
+			wrappedMarket.Rules = environment.TradingRules{
+				StepSize: stepOf(precision),
+			}
+		}
+		wrapper.tradingRules.Set(market.Symbol, wrappedMarket.Rules)
+		wrappedMarkets = append(wrappedMarkets, wrappedMarket)
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 
 	return wrappedMarkets, nil
 }
 
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+//
+//     NOTE: Kucoin provides only the trade precision of the coins, used as quantity step.
+func (wrapper *KucoinWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return wrapper.tradingRules.getOrLoad(MarketNameFor(market, wrapper), func() error {
+		if err := wrapper.meter.request(context.Background(), "GetMarkets"); err != nil {
+			return err
+		}
+		_, err := wrapper.GetMarkets()
+		return err
+	})
+}
+
 func (wrapper *KucoinWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
 // GetOrderBook gets the order(ASK + BID) book of a market.
@@ -121,9 +168,19 @@ func (wrapper *KucoinWrapper) GetOrderBook(market *environment.Market) (*environ
 	return ret, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *KucoinWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, notSupported(wrapper, "public trades")
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *KucoinWrapper) BuyLimit(market *environment.Market, amount, limit float64) (string, error) {
-	orderOid, err := wrapper.api.CreateOrder(MarketNameFor(market, wrapper), "BUY", limit, amount)
+func (wrapper *KucoinWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Bid, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	orderOid, err := wrapper.api.CreateOrder(MarketNameFor(market, wrapper), "BUY", toFloat(limit), toFloat(amount))
 
 	if err != nil {
 		return "", err
@@ -133,13 +190,18 @@ func (wrapper *KucoinWrapper) BuyLimit(market *environment.Market, amount, limit
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *KucoinWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not Implemented")
+func (wrapper *KucoinWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *KucoinWrapper) SellLimit(market *environment.Market, amount, limit float64) (string, error) {
-	orderOid, err := wrapper.api.CreateOrder(MarketNameFor(market, wrapper), "SELL", limit, amount)
+func (wrapper *KucoinWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	amount, limit, err := RoundOrder(wrapper, market, environment.Ask, amount, limit)
+	if err != nil {
+		return "", err
+	}
+
+	orderOid, err := wrapper.api.CreateOrder(MarketNameFor(market, wrapper), "SELL", toFloat(limit), toFloat(amount))
 
 	if err != nil {
 		return "", err
@@ -149,8 +211,128 @@ func (wrapper *KucoinWrapper) SellLimit(market *environment.Market, amount, limi
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *KucoinWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not Implemented")
+func (wrapper *KucoinWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *KucoinWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	order, err := wrapper.getActiveOrder(market, orderID)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return ErrOrderNotFound
+	}
+
+	side := "BUY"
+	if order.Side == environment.Ask {
+		side = "SELL"
+	}
+
+	return wrapper.api.CancelOrder(MarketNameFor(market, wrapper), orderID, side)
+}
+
+// GetOrder gets the current status of an order.
+//
+//     NOTE: Kucoin needs the side of the order to query it, so both sides are tried.
+func (wrapper *KucoinWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	active, err := wrapper.getActiveOrder(market, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, side := range []string{"BUY", "SELL"} {
+		details, err := wrapper.api.OrderDetails(MarketNameFor(market, wrapper), side, orderID, 0, 0)
+		if err != nil || details.OrderOid == "" {
+			continue
+		}
+
+		quantity := decimal.NewFromFloat(details.DealAmount).Add(decimal.NewFromFloat(details.PendingAmount))
+		ret := &environment.OrderInfo{
+			ID:               orderID,
+			Market:           market,
+			Side:             environment.Bid,
+			Price:            decimal.NewFromFloat(details.OrderPrice),
+			Quantity:         quantity,
+			FilledQuantity:   decimal.NewFromFloat(details.DealAmount),
+			AverageFillPrice: decimal.NewFromFloat(details.DealPriceAverage),
+			Fee:              decimal.NewFromFloat(details.FeeTotal),
+		}
+		if side == "SELL" {
+			ret.Side = environment.Ask
+		}
+
+		if active != nil {
+			ret.Status = active.Status
+			ret.Timestamp = active.Timestamp
+		} else if details.PendingAmount > 0 {
+			ret.Status = environment.OrderStatusCancelled
+		} else {
+			ret.Status = environment.OrderStatusFilled
+		}
+
+		return ret, nil
+	}
+
+	if active != nil {
+		return active, nil
+	}
+
+	return nil, ErrOrderNotFound
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *KucoinWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	kucoinOrders, err := wrapper.api.ListActiveMapOrders(MarketNameFor(market, wrapper), "")
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, 0, len(kucoinOrders.BUY)+len(kucoinOrders.SELL))
+	for _, order := range kucoinOrders.BUY {
+		ret = append(ret, convertFromKucoinActiveOrder(market, environment.Bid, order.Oid, order.Price, order.DealAmount, order.PendingAmount, order.CreatedAt))
+	}
+	for _, order := range kucoinOrders.SELL {
+		ret = append(ret, convertFromKucoinActiveOrder(market, environment.Ask, order.Oid, order.Price, order.DealAmount, order.PendingAmount, order.CreatedAt))
+	}
+
+	return ret, nil
+}
+
+// getActiveOrder gets an open order by ID, returns nil if the order is not open.
+func (wrapper *KucoinWrapper) getActiveOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	openOrders, err := wrapper.GetOpenOrders(market)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, order := range openOrders {
+		if order.ID == orderID {
+			return order, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// convertFromKucoinActiveOrder converts a kucoin active order to a environment.OrderInfo.
+func convertFromKucoinActiveOrder(market *environment.Market, side environment.OrderType, oid string, price, dealAmount, pendingAmount float64, createdAt int64) *environment.OrderInfo {
+	status := environment.OrderStatusOpen
+	if dealAmount > 0 {
+		status = environment.OrderStatusPartiallyFilled
+	}
+
+	return &environment.OrderInfo{
+		ID:             oid,
+		Market:         market,
+		Side:           side,
+		Status:         status,
+		Price:          decimal.NewFromFloat(price),
+		Quantity:       decimal.NewFromFloat(dealAmount).Add(decimal.NewFromFloat(pendingAmount)),
+		FilledQuantity: decimal.NewFromFloat(dealAmount),
+		Timestamp:      time.Unix(0, createdAt*int64(time.Millisecond)),
+	}
 }
 
 // GetTicker gets the updated ticker for a market.
@@ -227,7 +409,7 @@ func (wrapper *KucoinWrapper) GetDepositAddress(coinTicker string) (string, bool
 }
 
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
-func (wrapper *KucoinWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *KucoinWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	var feePercentage float64
 	if orderType == MakerTrade {
 		feePercentage = 0.0025
@@ -237,32 +419,42 @@ func (wrapper *KucoinWrapper) CalculateTradingFees(market *environment.Market, a
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *KucoinWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *KucoinWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // GetCandles gets the candle data from the exchange.
-func (wrapper *KucoinWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	panic("Not Implemented")
+func (wrapper *KucoinWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *KucoinWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return nil, notSupported(wrapper, "candles")
 }
 
 // FeedConnect connects to the feed of the exchange.
 func (wrapper *KucoinWrapper) FeedConnect(markets []*environment.Market) error {
-	panic("Not Implemented")
+	return ErrWebsocketNotSupported
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *KucoinWrapper) FeedDisconnect() error {
+	return ErrWebsocketNotSupported
 }
 
-// subscribeFeeds subscribes to the Market Summary Feed service.
-func (wrapper *KucoinWrapper) subscribeFeeds(market *environment.Market) error {
-	panic("Not Implemented")
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *KucoinWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return nil, notSupported(wrapper, "public trades")
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *KucoinWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	_, err := wrapper.api.CreateWithdrawalApply(coinTicker, destinationAddress, amount)
+func (wrapper *KucoinWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.api.CreateWithdrawalApply(coinTicker, destinationAddress, toFloat(amount))
 	if err != nil {
 		return err
 	}
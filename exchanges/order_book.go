@@ -0,0 +1,258 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// ErrBookOutOfSync is the error representing when a local order book missed some updates of the exchange,
+// so that it must be loaded again from a snapshot.
+var ErrBookOutOfSync = errors.New("Order book out of sync")
+
+// ErrEmptyBook is the error representing when a side of an order book has no levels.
+var ErrEmptyBook = errors.New("Empty order book")
+
+// ChecksumFunc computes the checksum of an order book in the way of an exchange, from its asks sorted by ascending
+// price and its bids sorted by descending price.
+type ChecksumFunc func(asks []environment.Order, bids []environment.Order) uint32
+
+// BookDiff represents an update of the price levels of an order book got from a depth feed.
+//
+//     The update IDs are zero if the feed does not number its updates, so that gaps cannot be detected.
+type BookDiff struct {
+	FirstUpdateID int64               // ID of the first update of the exchange contained in the diff.
+	LastUpdateID  int64               // ID of the last update of the exchange contained in the diff.
+	Asks          []environment.Order // Updated ask levels, removed if their quantity is zero.
+	Bids          []environment.Order // Updated bid levels, removed if their quantity is zero.
+	Checksum      uint32              // Checksum of the updated book as computed by the exchange.
+	Checksummed   bool                // If true, the updated book is validated against Checksum.
+}
+
+// L2OrderBook represents a local order book aggregated by price level, loaded from a snapshot and kept
+// up to date by the diffs of a depth feed.
+//
+//     A book missing some updates goes out of sync and ignores the diffs until it is loaded again from a snapshot.
+type L2OrderBook struct {
+	mutex        *sync.RWMutex
+	asks         []environment.Order // Sorted by ascending price.
+	bids         []environment.Order // Sorted by descending price.
+	lastUpdateID int64               // ID of the last update of the exchange applied to the book.
+	synced       bool
+	checksum     ChecksumFunc // Validates the checksums of the diffs, if any.
+}
+
+// NewL2OrderBook creates a new order book, out of sync until loaded from a snapshot, validating the checksums
+// of the diffs using the specified function, if not nil.
+func NewL2OrderBook(checksum ChecksumFunc) *L2OrderBook {
+	return &L2OrderBook{
+		mutex:    &sync.RWMutex{},
+		checksum: checksum,
+	}
+}
+
+// Reset loads the book from a snapshot, containing the updates of the exchange up to the specified ID.
+func (book *L2OrderBook) Reset(snapshot environment.OrderBook, lastUpdateID int64) {
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	book.asks = book.asks[:0]
+	book.bids = book.bids[:0]
+	for _, ask := range snapshot.Asks {
+		book.asks = setLevel(book.asks, ask, false)
+	}
+	for _, bid := range snapshot.Bids {
+		book.bids = setLevel(book.bids, bid, true)
+	}
+	book.lastUpdateID = lastUpdateID
+	book.synced = true
+}
+
+// Synced checks if the book is in sync with the exchange.
+func (book *L2OrderBook) Synced() bool {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	return book.synced
+}
+
+// Apply applies a diff to the book, ignoring it if older than the book.
+//
+//     ErrBookOutOfSync is returned if the diff does not follow the last applied update or the updated book
+//     does not match its checksum, and if the book is already out of sync.
+func (book *L2OrderBook) Apply(diff BookDiff) error {
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	if !book.synced {
+		return fmt.Errorf("%w: waiting for a snapshot", ErrBookOutOfSync)
+	}
+	if diff.LastUpdateID != 0 || diff.FirstUpdateID != 0 {
+		if diff.LastUpdateID <= book.lastUpdateID {
+			return nil
+		}
+		if diff.FirstUpdateID > book.lastUpdateID+1 {
+			book.synced = false
+			return fmt.Errorf("%w: missed updates from %d to %d", ErrBookOutOfSync, book.lastUpdateID+1, diff.FirstUpdateID-1)
+		}
+		book.lastUpdateID = diff.LastUpdateID
+	}
+
+	for _, ask := range diff.Asks {
+		book.asks = setLevel(book.asks, ask, false)
+	}
+	for _, bid := range diff.Bids {
+		book.bids = setLevel(book.bids, bid, true)
+	}
+
+	if diff.Checksummed && book.checksum != nil {
+		if checksum := book.checksum(book.asks, book.bids); checksum != diff.Checksum {
+			book.synced = false
+			return fmt.Errorf("%w: checksum %d instead of %d", ErrBookOutOfSync, checksum, diff.Checksum)
+		}
+	}
+	return nil
+}
+
+// setLevel sets the quantity of a price level in a side of a book, removing the level if the quantity is zero.
+func setLevel(levels []environment.Order, level environment.Order, descending bool) []environment.Order {
+	index := sort.Search(len(levels), func(i int) bool {
+		if descending {
+			return levels[i].Value.LessThanOrEqual(level.Value)
+		}
+		return levels[i].Value.GreaterThanOrEqual(level.Value)
+	})
+	found := index < len(levels) && levels[index].Value.Equal(level.Value)
+
+	switch {
+	case level.Quantity.IsZero() && found:
+		return append(levels[:index], levels[index+1:]...)
+	case level.Quantity.IsZero():
+		return levels
+	case found:
+		levels[index] = level
+		return levels
+	}
+	levels = append(levels, environment.Order{})
+	copy(levels[index+1:], levels[index:])
+	levels[index] = level
+	return levels
+}
+
+// Top gets the best levels of the book, at most n per side (all the levels if n is not positive).
+func (book *L2OrderBook) Top(n int) environment.OrderBook {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	asks, bids := book.asks, book.bids
+	if n > 0 && len(asks) > n {
+		asks = asks[:n]
+	}
+	if n > 0 && len(bids) > n {
+		bids = bids[:n]
+	}
+	return environment.OrderBook{
+		Asks: append([]environment.Order(nil), asks...),
+		Bids: append([]environment.Order(nil), bids...),
+	}
+}
+
+// BestAsk gets the ask level with the lowest price.
+func (book *L2OrderBook) BestAsk() (environment.Order, error) {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	if len(book.asks) == 0 {
+		return environment.Order{}, fmt.Errorf("%w: no asks", ErrEmptyBook)
+	}
+	return book.asks[0], nil
+}
+
+// BestBid gets the bid level with the highest price.
+func (book *L2OrderBook) BestBid() (environment.Order, error) {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	if len(book.bids) == 0 {
+		return environment.Order{}, fmt.Errorf("%w: no bids", ErrEmptyBook)
+	}
+	return book.bids[0], nil
+}
+
+// Spread gets the difference between the best ask and the best bid prices.
+func (book *L2OrderBook) Spread() (decimal.Decimal, error) {
+	ask, bid, err := book.best()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ask.Value.Sub(bid.Value), nil
+}
+
+// Mid gets the price halfway between the best ask and the best bid prices.
+func (book *L2OrderBook) Mid() (decimal.Decimal, error) {
+	ask, bid, err := book.best()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ask.Value.Add(bid.Value).Div(decimal.NewFromInt(2)), nil
+}
+
+// best gets the best ask and the best bid levels.
+func (book *L2OrderBook) best() (environment.Order, environment.Order, error) {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	if len(book.asks) == 0 || len(book.bids) == 0 {
+		return environment.Order{}, environment.Order{}, fmt.Errorf("%w: no asks or no bids", ErrEmptyBook)
+	}
+	return book.asks[0], book.bids[0], nil
+}
+
+// AskDepth gets the total quantity offered at a price lower than or equal to the specified one.
+func (book *L2OrderBook) AskDepth(price decimal.Decimal) decimal.Decimal {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	depth := decimal.Zero
+	for _, ask := range book.asks {
+		if ask.Value.GreaterThan(price) {
+			break
+		}
+		depth = depth.Add(ask.Quantity)
+	}
+	return depth
+}
+
+// BidDepth gets the total quantity bid at a price greater than or equal to the specified one.
+func (book *L2OrderBook) BidDepth(price decimal.Decimal) decimal.Decimal {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	depth := decimal.Zero
+	for _, bid := range book.bids {
+		if bid.Value.LessThan(price) {
+			break
+		}
+		depth = depth.Add(bid.Quantity)
+	}
+	return depth
+}
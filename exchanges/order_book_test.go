@@ -0,0 +1,226 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// levels parses price levels written as "price:quantity".
+func levels(values ...string) []environment.Order {
+	ret := make([]environment.Order, len(values))
+	for i, value := range values {
+		parts := strings.Split(value, ":")
+		ret[i] = environment.Order{
+			Value:    decimal.RequireFromString(parts[0]),
+			Quantity: decimal.RequireFromString(parts[1]),
+		}
+	}
+	return ret
+}
+
+// formatLevels writes price levels as "price:quantity", space separated.
+func formatLevels(orders []environment.Order) string {
+	values := make([]string, len(orders))
+	for i, order := range orders {
+		values[i] = fmt.Sprintf("%s:%s", order.Value, order.Quantity)
+	}
+	return strings.Join(values, " ")
+}
+
+// levelsChecksum is a checksum counting the levels of each side of a book.
+func levelsChecksum(asks []environment.Order, bids []environment.Order) uint32 {
+	return uint32(100*len(asks) + len(bids))
+}
+
+func TestL2OrderBookApply(t *testing.T) {
+	snapshot := environment.OrderBook{
+		Asks: levels("102:2", "101:1"),
+		Bids: levels("99:2", "100:1"),
+	}
+	tests := []struct {
+		name     string
+		diffs    []BookDiff
+		wantErr  error
+		synced   bool
+		wantAsks string
+		wantBids string
+	}{
+		{
+			name:     "older diff ignored",
+			diffs:    []BookDiff{{FirstUpdateID: 9, LastUpdateID: 10, Asks: levels("101:5")}},
+			synced:   true,
+			wantAsks: "101:1 102:2",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name:     "diff overlapping the snapshot",
+			diffs:    []BookDiff{{FirstUpdateID: 8, LastUpdateID: 12, Asks: levels("101:5")}},
+			synced:   true,
+			wantAsks: "101:5 102:2",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name: "consecutive diffs",
+			diffs: []BookDiff{
+				{FirstUpdateID: 11, LastUpdateID: 11, Bids: levels("100.5:3")},
+				{FirstUpdateID: 12, LastUpdateID: 13, Asks: levels("100.8:1", "102:0")},
+			},
+			synced:   true,
+			wantAsks: "100.8:1 101:1",
+			wantBids: "100.5:3 100:1 99:2",
+		},
+		{
+			name: "missed updates",
+			diffs: []BookDiff{
+				{FirstUpdateID: 11, LastUpdateID: 11, Bids: levels("100:4")},
+				{FirstUpdateID: 13, LastUpdateID: 14, Bids: levels("99:0")},
+			},
+			wantErr:  ErrBookOutOfSync,
+			wantAsks: "101:1 102:2",
+			wantBids: "100:4 99:2",
+		},
+		{
+			name: "diffs after missed updates",
+			diffs: []BookDiff{
+				{FirstUpdateID: 12, LastUpdateID: 12},
+				{FirstUpdateID: 11, LastUpdateID: 11, Bids: levels("100:4")},
+			},
+			wantErr:  ErrBookOutOfSync,
+			wantAsks: "101:1 102:2",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name:     "unnumbered diffs",
+			diffs:    []BookDiff{{Bids: levels("100:0", "98:1")}, {Asks: levels("101:0")}},
+			synced:   true,
+			wantAsks: "102:2",
+			wantBids: "99:2 98:1",
+		},
+		{
+			name:     "removal of a missing level",
+			diffs:    []BookDiff{{FirstUpdateID: 11, LastUpdateID: 11, Asks: levels("103:0")}},
+			synced:   true,
+			wantAsks: "101:1 102:2",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name:     "matching checksum",
+			diffs:    []BookDiff{{FirstUpdateID: 11, LastUpdateID: 11, Asks: levels("103:1"), Checksum: 302, Checksummed: true}},
+			synced:   true,
+			wantAsks: "101:1 102:2 103:1",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name:     "mismatching checksum",
+			diffs:    []BookDiff{{FirstUpdateID: 11, LastUpdateID: 11, Asks: levels("103:1"), Checksum: 202, Checksummed: true}},
+			wantErr:  ErrBookOutOfSync,
+			wantAsks: "101:1 102:2 103:1",
+			wantBids: "100:1 99:2",
+		},
+		{
+			name:     "checksum not sent",
+			diffs:    []BookDiff{{FirstUpdateID: 11, LastUpdateID: 11, Asks: levels("103:1"), Checksum: 202}},
+			synced:   true,
+			wantAsks: "101:1 102:2 103:1",
+			wantBids: "100:1 99:2",
+		},
+	}
+
+	for _, test := range tests {
+		book := NewL2OrderBook(levelsChecksum)
+		book.Reset(snapshot, 10)
+
+		var err error
+		for _, diff := range test.diffs {
+			if diffErr := book.Apply(diff); diffErr != nil {
+				err = diffErr
+			}
+		}
+		if test.wantErr == nil && err != nil || test.wantErr != nil && !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.wantErr)
+		}
+		if book.Synced() != test.synced {
+			t.Errorf("%s: synced = %v, want %v", test.name, book.Synced(), test.synced)
+		}
+		top := book.Top(0)
+		if got := formatLevels(top.Asks); got != test.wantAsks {
+			t.Errorf("%s: asks = %s, want %s", test.name, got, test.wantAsks)
+		}
+		if got := formatLevels(top.Bids); got != test.wantBids {
+			t.Errorf("%s: bids = %s, want %s", test.name, got, test.wantBids)
+		}
+	}
+}
+
+func TestL2OrderBookNotLoaded(t *testing.T) {
+	book := NewL2OrderBook(nil)
+	if err := book.Apply(BookDiff{Asks: levels("101:1")}); !errors.Is(err, ErrBookOutOfSync) {
+		t.Errorf("got error %v, want %v", err, ErrBookOutOfSync)
+	}
+	if _, err := book.Mid(); !errors.Is(err, ErrEmptyBook) {
+		t.Errorf("got error %v, want %v", err, ErrEmptyBook)
+	}
+
+	book.Reset(environment.OrderBook{Asks: levels("101:1", "102:2"), Bids: levels("99:3")}, 0)
+	tests := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		{"AskDepth", book.AskDepth(decimal.NewFromInt(101)), "1"},
+		{"BidDepth", book.BidDepth(decimal.NewFromInt(98)), "3"},
+		{"Spread", first(book.Spread()), "2"},
+		{"Mid", first(book.Mid()), "100"},
+	}
+	for _, test := range tests {
+		if !test.got.Equal(decimal.RequireFromString(test.want)) {
+			t.Errorf("%s = %s, want %s", test.name, test.got, test.want)
+		}
+	}
+}
+
+// first gets the value returned along with an error.
+func first(value decimal.Decimal, _ error) decimal.Decimal {
+	return value
+}
+
+func TestBitfinexNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"0", "0"},
+		{"123.45", "123.45"},
+		{"-0.5", "-0.5"},
+		{"0.000001", "0.000001"},
+		{"0.00000015", "1.5e-7"},
+		{"-0.0000001", "-1e-7"},
+		{"1000000000000000000000", "1e+21"},
+	}
+
+	for _, test := range tests {
+		if got := bitfinexNumber(decimal.RequireFromString(test.number)); got != test.want {
+			t.Errorf("bitfinexNumber(%s) = %s, want %s", test.number, got, test.want)
+		}
+	}
+}
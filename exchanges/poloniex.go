@@ -16,9 +16,12 @@
 package exchanges
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 
@@ -32,8 +35,13 @@ type PoloniexWrapper struct {
 	bindedTickers    map[string]bool    // if true, i am subscribing to market ticker.
 	summaries        *SummaryCache
 	candles          *CandlesCache
+	meter            *requestMeter // Meter of the requests made within the calls.
 	depositAddresses map[string]string
 	websocketOn      bool
+	wsStarted        bool          // if true, the messages of the websocket are being read.
+	feedStop         chan struct{} // Closed to stop the supervision of the ticker subscription.
+	feedWatchdog     *feedWatchdog // Touched at each ticker message.
+	feedSettings     FeedSettings
 }
 
 // NewPoloniexWrapper creates a generic wrapper of the poloniex API.
@@ -43,8 +51,10 @@ func NewPoloniexWrapper(publicKey string, secretKey string, depositAddresses map
 		bindedTickers:    make(map[string]bool),
 		summaries:        NewSummaryCache(),
 		candles:          NewCandlesCache(),
+		meter:            newRequestMeter(),
 		depositAddresses: depositAddresses,
 		websocketOn:      false,
+		feedWatchdog:     newFeedWatchdog(),
 	}
 }
 
@@ -53,58 +63,104 @@ func (wrapper *PoloniexWrapper) Name() string {
 	return "poloniex"
 }
 
+// SetFeedSettings sets how the data got from the websocket feed is served.
+func (wrapper *PoloniexWrapper) SetFeedSettings(settings FeedSettings) {
+	wrapper.feedSettings = settings
+}
+
 func (wrapper *PoloniexWrapper) String() string {
 	return wrapper.Name()
 }
 
+// requestMeter gets the meter the wrapper reports its requests to.
+func (wrapper *PoloniexWrapper) requestMeter() *requestMeter {
+	return wrapper.meter
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *PoloniexWrapper) Capabilities() Capabilities {
+	return Capabilities{
+		Websocket:   true,
+		Candles:     true,
+		OrderBook:   true,
+		LimitOrders: true,
+		Balance:     true,
+		Withdraw:    true,
+	}
+}
+
 // GetMarkets gets all the markets info.
 func (wrapper *PoloniexWrapper) GetMarkets() ([]*environment.Market, error) {
-	poloniexMarkets, err := wrapper.api.Currencies()
+	poloniexTicker, err := wrapper.api.Ticker()
 	if err != nil {
 		return nil, err
 	}
-	wrappedMarkets := make([]*environment.Market, 0, len(poloniexMarkets))
-	for _, market := range poloniexMarkets {
-		if market.Disabled == 1 {
-			name := strings.SplitN(market.Name, "/", 2)
-			wrappedMarkets = append(wrappedMarkets, &environment.Market{
-				Name:           market.Name,
-				BaseCurrency:   name[1],
-				MarketCurrency: name[0],
-			})
+	wrappedMarkets := make([]*environment.Market, 0, len(poloniexTicker))
+	for pair := range poloniexTicker {
+		// in poloniex pairs the currency used to price the market comes first (e.g. BTC_ETH).
+		currencies := strings.SplitN(pair, "_", 2)
+		if len(currencies) != 2 {
+			continue
 		}
+		wrappedMarkets = append(wrappedMarkets, listedMarket(wrapper, pair, currencies[1], currencies[0]))
 	}
+	registerMarkets(wrapper, wrappedMarkets)
 	return wrappedMarkets, nil
 }
 
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+//
+//     NOTE: Poloniex does not provide trading rules, orders are sent as they are.
+func (wrapper *PoloniexWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return environment.TradingRules{}, nil
+}
+
 func (wrapper *PoloniexWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
-	panic("Not Implemented")
+	return nil, notSupported(wrapper, "price change stats")
 }
 
+// poloniexIntervals contains the periods, in seconds, of the candle intervals supported by the exchange.
+var poloniexIntervals = map[environment.Interval]string{
+	environment.Interval5m:  "300",
+	environment.Interval15m: "900",
+	environment.Interval30m: "1800",
+	environment.Interval2h:  "7200",
+	environment.Interval4h:  "14400",
+	environment.Interval1d:  "86400",
+}
+
+// poloniexChartCandles is the number of candles requested to the chart data API in a single call.
+const poloniexChartCandles = 288
+
 // GetCandles gets the candle data from the exchange.
-func (wrapper *PoloniexWrapper) GetCandles(market *environment.Market, interval string) ([]environment.CandleStick, error) {
-	if !wrapper.websocketOn {
-		poloniesCandles, err := wrapper.api.ChartData(MarketNameFor(market, wrapper))
+func (wrapper *PoloniexWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.candles.Updated(market, interval))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
+		source, err := sourceInterval(wrapper, poloniexIntervals, interval)
 		if err != nil {
 			return nil, err
 		}
 
-		ret := make([]environment.CandleStick, len(poloniesCandles))
+		now := now()
+		ret, err := wrapper.getChartData(market, source, now.Add(-poloniexChartCandles*source.Duration()), now)
+		if err != nil {
+			return nil, err
+		}
 
-		for i, poloniexCandle := range poloniesCandles {
-			ret[i] = environment.CandleStick{
-				High:   decimal.NewFromFloat(poloniexCandle.High),
-				Open:   decimal.NewFromFloat(poloniexCandle.Open),
-				Close:  decimal.NewFromFloat(poloniexCandle.Close),
-				Low:    decimal.NewFromFloat(poloniexCandle.Low),
-				Volume: decimal.NewFromFloat(poloniexCandle.Volume),
+		if source != interval {
+			ret, err = environment.ResampleCandles(ret, interval)
+			if err != nil {
+				return nil, err
 			}
 		}
 
-		wrapper.candles.Set(market, ret)
+		wrapper.candles.Set(market, interval, ret)
 	}
 
-	ret, candleLoaded := wrapper.candles.Get(market)
+	ret, candleLoaded := wrapper.candles.Get(market, interval)
 	if !candleLoaded {
 		return nil, errors.New("No candle data yet")
 	}
@@ -112,6 +168,67 @@ func (wrapper *PoloniexWrapper) GetCandles(market *environment.Market, interval
 	return ret, nil
 }
 
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *PoloniexWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	source, err := sourceInterval(wrapper, poloniexIntervals, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []environment.CandleStick
+	page := poloniexChartCandles * source.Duration()
+	first := rangeStart(source, interval, from)
+	for start := first; start.Before(to); start = start.Add(page) {
+		if start.After(first) {
+			if err := wrapper.meter.request(context.Background(), "GetCandlesRange"); err != nil {
+				return nil, err
+			}
+		}
+		end := start.Add(page)
+		if end.After(to) {
+			end = to
+		}
+
+		candles, err := wrapper.getChartData(market, source, start, end)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, candles...)
+	}
+
+	return candlesInRange(ret, source, interval, from, to)
+}
+
+// getChartData gets the candles of a natively supported interval in the specified time range.
+func (wrapper *PoloniexWrapper) getChartData(market *environment.Market, interval environment.Interval, start time.Time, end time.Time) ([]environment.CandleStick, error) {
+	period, _ := strconv.Atoi(poloniexIntervals[interval])
+	poloniesCandles, err := wrapper.api.ChartDataPeriod(MarketNameFor(market, wrapper), start, end, period)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.CandleStick, 0, len(poloniesCandles))
+	for _, poloniexCandle := range poloniesCandles {
+		// an empty time range is returned as a single candle with zero date.
+		if poloniexCandle.Date == 0 {
+			continue
+		}
+
+		openTime := time.Unix(poloniexCandle.Date, 0)
+		ret = append(ret, environment.CandleStick{
+			High:      decimal.NewFromFloat(poloniexCandle.High),
+			Open:      decimal.NewFromFloat(poloniexCandle.Open),
+			Close:     decimal.NewFromFloat(poloniexCandle.Close),
+			Low:       decimal.NewFromFloat(poloniexCandle.Low),
+			Volume:    decimal.NewFromFloat(poloniexCandle.Volume),
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(interval.Duration() - time.Millisecond),
+		})
+	}
+
+	return ret, nil
+}
+
 // GetOrderBook gets the order(ASK + BID) book of a market.
 func (wrapper *PoloniexWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
 	poloniexOrderBook, err := wrapper.api.OrderBook(MarketNameFor(market, wrapper))
@@ -136,26 +253,137 @@ func (wrapper *PoloniexWrapper) GetOrderBook(market *environment.Market) (*envir
 	return &orderBook, nil
 }
 
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *PoloniexWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, notSupported(wrapper, "public trades")
+}
+
 // BuyLimit performs a limit buy action.
-func (wrapper *PoloniexWrapper) BuyLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.Buy(MarketNameFor(market, wrapper), amount, limit)
+func (wrapper *PoloniexWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	orderNumber, err := wrapper.api.Buy(MarketNameFor(market, wrapper), toFloat(amount), toFloat(limit))
 	return fmt.Sprint(orderNumber.OrderNumber), err
 }
 
 // SellLimit performs a limit sell action.
-func (wrapper *PoloniexWrapper) SellLimit(market *environment.Market, amount float64, limit float64) (string, error) {
-	orderNumber, err := wrapper.api.Sell(MarketNameFor(market, wrapper), amount, limit)
+func (wrapper *PoloniexWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	orderNumber, err := wrapper.api.Sell(MarketNameFor(market, wrapper), toFloat(amount), toFloat(limit))
 	return fmt.Sprint(orderNumber.OrderNumber), err
 }
 
 // BuyMarket performs a market buy action.
-func (wrapper *PoloniexWrapper) BuyMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not supported on poloniex")
+func (wrapper *PoloniexWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
 }
 
 // SellMarket performs a market sell action.
-func (wrapper *PoloniexWrapper) SellMarket(market *environment.Market, amount float64) (string, error) {
-	panic("Not supported on poloniex")
+func (wrapper *PoloniexWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", notSupported(wrapper, "market orders")
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *PoloniexWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	orderNumber, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	success, err := wrapper.api.CancelOrder(orderNumber)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("Cannot cancel order %s", orderID)
+	}
+	return nil
+}
+
+// GetOrder gets the current status of an order.
+//
+//     NOTE: Poloniex does not report closed orders, a closed order is rebuilt from its trades.
+func (wrapper *PoloniexWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	orderNumber, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders, err := wrapper.GetOpenOrders(market)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range openOrders {
+		if order.ID == orderID {
+			return order, nil
+		}
+	}
+
+	poloniexTrades, err := wrapper.api.OrderTrades(orderNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(poloniexTrades) == 0 {
+		return nil, ErrOrderNotFound
+	}
+
+	ret := &environment.OrderInfo{
+		ID:          orderID,
+		Market:      market,
+		Side:        environment.Bid,
+		Status:      environment.OrderStatusFilled,
+		FeeCurrency: market.MarketCurrency, // the fee rate applies to the total, in quote currency.
+	}
+	total := decimal.Zero
+	for _, trade := range poloniexTrades {
+		if trade.Type == "sell" {
+			ret.Side = environment.Ask
+		}
+		tradeTotal := decimal.NewFromFloat(trade.Total)
+		ret.FilledQuantity = ret.FilledQuantity.Add(decimal.NewFromFloat(trade.Amount))
+		ret.Fee = ret.Fee.Add(tradeTotal.Mul(decimal.NewFromFloat(trade.Fee))) // fee is expressed as rate.
+		total = total.Add(tradeTotal)
+	}
+	ret.Quantity = ret.FilledQuantity
+	ret.AverageFillPrice = total.Div(ret.FilledQuantity)
+
+	return ret, nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *PoloniexWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	poloniexOrders, err := wrapper.api.OpenOrders(MarketNameFor(market, wrapper))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*environment.OrderInfo, len(poloniexOrders))
+	for i, order := range poloniexOrders {
+		quantity := decimal.NewFromFloat(order.StartingAmount)
+		remaining := decimal.NewFromFloat(order.Amount)
+
+		side := environment.Bid
+		if order.Type == "sell" {
+			side = environment.Ask
+		}
+
+		status := environment.OrderStatusOpen
+		if remaining.LessThan(quantity) {
+			status = environment.OrderStatusPartiallyFilled
+		}
+
+		timestamp, _ := time.Parse("2006-01-02 15:04:05", order.Date)
+
+		ret[i] = &environment.OrderInfo{
+			ID:             fmt.Sprint(order.OrderNumber),
+			Market:         market,
+			Side:           side,
+			Status:         status,
+			Price:          decimal.NewFromFloat(order.Rate),
+			Quantity:       quantity,
+			FilledQuantity: quantity.Sub(remaining),
+			Timestamp:      timestamp,
+		}
+	}
+
+	return ret, nil
 }
 
 // GetTicker gets the updated ticker for a market.
@@ -178,7 +406,11 @@ func (wrapper *PoloniexWrapper) GetTicker(market *environment.Market) (*environm
 
 // GetMarketSummary gets the current market summary.
 func (wrapper *PoloniexWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
-	if !wrapper.websocketOn {
+	fromFeed, err := readFromFeed(wrapper.websocketOn, wrapper.feedSettings, wrapper.summaries.Updated(market))
+	if err != nil {
+		return nil, err
+	}
+	if !fromFeed {
 		poloniexSummaries, err := wrapper.api.Ticker()
 		if err != nil {
 			return nil, err
@@ -231,7 +463,7 @@ func (wrapper *PoloniexWrapper) GetDepositAddress(coinTicker string) (string, bo
 // CalculateTradingFees calculates the trading fees for an order on a specified market.
 //
 //     NOTE: In Binance fees are currently hardcoded.
-func (wrapper *PoloniexWrapper) CalculateTradingFees(market *environment.Market, amount float64, limit float64, orderType TradeType) float64 {
+func (wrapper *PoloniexWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
 	// NOTE: possibility to use wrapper FeesInfo function.
 	var feePercentage float64
 	if orderType == MakerTrade {
@@ -242,38 +474,82 @@ func (wrapper *PoloniexWrapper) CalculateTradingFees(market *environment.Market,
 		panic("Unknown trade type")
 	}
 
-	return amount * limit * feePercentage
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
 }
 
 // CalculateWithdrawFees calculates the withdrawal fees on a specified market.
-func (wrapper *PoloniexWrapper) CalculateWithdrawFees(market *environment.Market, amount float64) float64 {
-	panic("Not Implemented")
+func (wrapper *PoloniexWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, notSupported(wrapper, "withdraw fees")
 }
 
 // FeedConnect connects to the feed of the poloniex websocket.
+//
+//     The websocket connection is opened again by the client when lost, while the ticker channel is
+//     subscribed again with exponential backoff when it stops receiving messages, until the feed is disconnected.
 func (wrapper *PoloniexWrapper) FeedConnect(markets []*environment.Market) error {
-	wrapper.api.StartWS()
+	if wrapper.feedStop != nil {
+		return nil
+	}
+	if !wrapper.wsStarted {
+		wrapper.wsStarted = true
+		go wrapper.api.StartWS()
+	}
+	wrapper.feedStop = make(chan struct{})
 	wrapper.websocketOn = true
 
 	for _, m := range markets {
 		wrapper.subscribeMarketSummaryFeed(m)
 	}
 
+	err := superviseFeed(wrapper.Name(), wrapper.feedStop, func() (feedConnection, error) {
+		err := wrapper.api.Subscribe("ticker")
+		if err != nil {
+			return feedConnection{}, err
+		}
+
+		wrapper.feedWatchdog.touch()
+		return feedConnection{
+			done: wrapper.feedWatchdog.watch(wrapper.feedStop),
+			close: func() {
+				wrapper.api.Unsubscribe("ticker")
+			},
+		}, nil
+	})
+	if err != nil {
+		wrapper.websocketOn = false
+		wrapper.feedStop = nil
+		return err
+	}
+
 	return nil
 }
 
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *PoloniexWrapper) FeedDisconnect() error {
+	if wrapper.feedStop == nil {
+		return nil
+	}
+	wrapper.websocketOn = false
+	close(wrapper.feedStop)
+	wrapper.feedStop = nil
+
+	return nil
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *PoloniexWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return nil, notSupported(wrapper, "public trades")
+}
+
 // SubscribeMarketSummaryFeed subscribes to the Market Summary Feed service.
 func (wrapper *PoloniexWrapper) subscribeMarketSummaryFeed(market *environment.Market) {
 	if wrapper.websocketOn {
 		subTicker := fmt.Sprintf("ticker:%s", MarketNameFor(market, wrapper))
 		if len(wrapper.bindedTickers) == 0 {
-			wrapper.api.Subscribe("ticker")
-
 			wrapper.api.On("ticker", func(t poloniex.WSTicker) {
-				for bindedTicker := range wrapper.bindedTickers {
-					if bindedTicker == t.Pair {
-						wrapper.api.Emit(subTicker, t)
-					}
+				wrapper.feedWatchdog.touch()
+				if wrapper.bindedTickers[t.Pair] {
+					wrapper.api.Emit(fmt.Sprintf("ticker:%s", t.Pair), t)
 				}
 			})
 		}
@@ -296,8 +572,8 @@ func (wrapper *PoloniexWrapper) subscribeMarketSummaryFeed(market *environment.M
 }
 
 // Withdraw performs a withdraw operation from the exchange to a destination address.
-func (wrapper *PoloniexWrapper) Withdraw(destinationAddress string, coinTicker string, amount float64) error {
-	_, err := wrapper.api.Withdraw(coinTicker, amount, destinationAddress)
+func (wrapper *PoloniexWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.api.Withdraw(coinTicker, toFloat(amount), destinationAddress)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,641 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// defaultRateLimits contains the request budgets of the exchange APIs, by exchange name.
+var defaultRateLimits = map[string]environment.RateLimitConfig{
+	"binance":  {Weight: 1200, Interval: time.Minute},
+	"bitfinex": {Weight: 60, Interval: time.Minute},
+	"bittrex":  {Weight: 60, Interval: time.Minute},
+	"hitbtc":   {Weight: 100, Interval: time.Second},
+	"kraken":   {Weight: 20, Interval: time.Minute},
+	"kucoin":   {Weight: 30, Interval: time.Second},
+	"poloniex": {Weight: 6, Interval: time.Second},
+}
+
+// callWeights contains the weights of the calls counted against the budget of the exchange APIs which
+// differ from 1, by exchange name and operation.
+var callWeights = map[string]map[string]int{
+	"binance": {
+		"GetMarkets":              10,
+		"GetListPriceChangeStats": 40,
+		"GetOrder":                2,
+		"GetOrderTrades":          10,
+		"GetOpenOrders":           3,
+		"GetBalance":              10,
+	},
+}
+
+// cachedOperations contains the operations served from a local cache, which are not counted against the budget:
+// the requests made on a miss are reported by the wrapper (see requestMeter).
+var cachedOperations = map[string]bool{
+	"GetTradingRules": true,
+}
+
+// binanceTransientCodes contains the codes of the Binance API errors which are worth a retry.
+var binanceTransientCodes = map[int64]bool{
+	-1000: true, // Unknown error while processing the request.
+	-1001: true, // Internal error, disconnected.
+	-1003: true, // Too many requests.
+	-1006: true, // Unexpected response from the message bus.
+	-1007: true, // Timeout waiting for the response of the backend.
+}
+
+// transientStatuses contains the HTTP statuses of the responses which are worth a retry.
+var transientStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// BudgetUsage represents the usage of the request budget of an exchange API.
+type BudgetUsage struct {
+	Weight    float64       // Weight of the calls currently counted against the budget.
+	Limit     int           // Maximum weight of the calls in each interval, no limit if zero.
+	Interval  time.Duration // Interval over which the budget is refilled.
+	Throttled int64         // Number of calls delayed to stay within the budget.
+	Retries   int64         // Number of retries of the calls failed with a transient error.
+}
+
+// requestBudget represents a budget of request weight, refilled continuously over an interval.
+type requestBudget struct {
+	mutex     *sync.Mutex
+	limit     int
+	interval  time.Duration
+	used      float64   // Weight of the calls counted against the budget at the last update, can exceed the limit.
+	updated   time.Time // Time of the last update.
+	throttled int64
+	retries   int64
+}
+
+// reserve counts the weight of a call against the budget, returning how long the call must wait
+// for the budget to be refilled.
+func (budget *requestBudget) reserve(weight int) time.Duration {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	if budget.limit <= 0 {
+		return 0
+	}
+	budget.refill()
+	budget.used += float64(weight)
+	if budget.used <= float64(budget.limit) {
+		return 0
+	}
+	budget.throttled++
+	return time.Duration((budget.used - float64(budget.limit)) / float64(budget.limit) * float64(budget.interval))
+}
+
+// refund removes the weight of a call which has not been made from the budget.
+func (budget *requestBudget) refund(weight int) {
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.refill()
+	budget.used -= float64(weight)
+	if budget.used < 0 {
+		budget.used = 0
+	}
+}
+
+// refill removes from the used weight the share of the budget refilled since the last update,
+// must be called holding the mutex.
+func (budget *requestBudget) refill() {
+	current := now()
+	if elapsed := current.Sub(budget.updated); elapsed > 0 && !budget.updated.IsZero() {
+		budget.used -= float64(elapsed) / float64(budget.interval) * float64(budget.limit)
+		if budget.used < 0 {
+			budget.used = 0
+		}
+	}
+	budget.updated = current
+}
+
+// RateLimitWrapper wraps another wrapper, keeping its calls within the request budget of the exchange API
+// and retrying with exponential backoff the idempotent calls failing with a transient error.
+//
+//     The orders and the withdrawals are never retried, since a failed call could have been executed anyway.
+//     The weight of a call is counted once before it, even if it is served from the websocket caches;
+//     the extra requests of the calls making more than one (e.g. paging the candles) are counted when
+//     made, and the calls wait for the pauses asked by the exchange with the Retry-After header.
+type RateLimitWrapper struct {
+	innerWrapper ContextExchangeWrapper
+	budget       *requestBudget
+	meter        *requestMeter // Meter of the requests made by the inner wrapper.
+	retries      int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+// NewRateLimitWrapper creates a new rate limited wrapper from another wrapper, taking the default values
+// of the exchange for the zero fields of the configuration.
+//
+//     The exchange and its requests are the ones of the wrapper at the bottom of the chain, even when
+//     wrapped by other wrappers (e.g. a ContextWrapper or an ExchangeWrapperSimulator).
+func NewRateLimitWrapper(wrapper ExchangeWrapper, config environment.RateLimitConfig) *RateLimitWrapper {
+	live := exchangeOf(wrapper)
+	defaults := defaultRateLimits[live.Name()]
+	if config.Weight == 0 {
+		config.Weight = defaults.Weight
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.Retries == 0 {
+		config.Retries = defaultRetries
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = defaultBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	ret := &RateLimitWrapper{
+		innerWrapper: ContextAware(wrapper),
+		budget: &requestBudget{
+			mutex:    &sync.Mutex{},
+			limit:    config.Weight,
+			interval: config.Interval,
+		},
+		meter:      newRequestMeter(),
+		retries:    config.Retries,
+		backoff:    config.Backoff,
+		maxBackoff: config.MaxBackoff,
+	}
+	if metered, ok := live.(meteredWrapper); ok {
+		ret.meter = metered.requestMeter()
+		ret.meter.setCharge(func(ctx context.Context, operation string) error {
+			return ret.wait(ctx, ret.weightOf(operation))
+		})
+	}
+	return ret
+}
+
+// layeredWrapper is implemented by the wrappers adding a feature to another wrapper (e.g. ContextWrapper).
+type layeredWrapper interface {
+	wrapped() ExchangeWrapper
+}
+
+// exchangeOf gets the wrapper of the exchange at the bottom of a chain of layered wrappers.
+func exchangeOf(wrapper ExchangeWrapper) ExchangeWrapper {
+	for {
+		layered, ok := wrapper.(layeredWrapper)
+		if !ok {
+			return wrapper
+		}
+		wrapper = layered.wrapped()
+	}
+}
+
+// wrapped gets the wrapper this wrapper limits the requests of.
+func (wrapper *RateLimitWrapper) wrapped() ExchangeWrapper {
+	return wrapper.innerWrapper
+}
+
+// Usage gets the current usage of the request budget of the exchange API.
+func (wrapper *RateLimitWrapper) Usage() BudgetUsage {
+	wrapper.budget.mutex.Lock()
+	defer wrapper.budget.mutex.Unlock()
+
+	usage := BudgetUsage{
+		Interval:  wrapper.budget.interval,
+		Throttled: wrapper.budget.throttled,
+		Retries:   wrapper.budget.retries,
+	}
+	if wrapper.budget.limit > 0 {
+		wrapper.budget.refill()
+		usage.Weight = wrapper.budget.used
+		usage.Limit = wrapper.budget.limit
+	}
+	return usage
+}
+
+// weightOf gets the weight of an operation counted against the budget of the exchange API.
+func (wrapper *RateLimitWrapper) weightOf(operation string) int {
+	if cachedOperations[operation] {
+		return 0
+	}
+	if weight, exists := callWeights[wrapper.Name()][operation]; exists {
+		return weight
+	}
+	return 1
+}
+
+// wait waits for the pause asked by the exchange, if any, and for the budget to allow a call
+// of the specified weight, or for the context to be done.
+func (wrapper *RateLimitWrapper) wait(ctx context.Context, weight int) error {
+	if pause := wrapper.meter.pauseLeft(); pause > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-getClock().After(pause):
+		}
+	}
+
+	delay := wrapper.budget.reserve(weight)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		wrapper.budget.refund(weight)
+		return ctx.Err()
+	case <-getClock().After(delay):
+		return nil
+	}
+}
+
+// call executes an operation of the inner wrapper within the budget, retrying it with exponential backoff
+// while it fails with a transient error if it is idempotent.
+func (wrapper *RateLimitWrapper) call(ctx context.Context, operation string, idempotent bool, do func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	weight := wrapper.weightOf(operation)
+	backoff := wrapper.backoff
+	for attempt := 0; ; attempt++ {
+		if err := wrapper.wait(ctx, weight); err != nil {
+			return nil, err
+		}
+		ret, err := do(ctx)
+		if err == nil || !idempotent || attempt >= wrapper.retries || ctx.Err() != nil || !isTransient(err) {
+			return ret, err
+		}
+
+		wrapper.budget.mutex.Lock()
+		wrapper.budget.retries++
+		wrapper.budget.mutex.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-getClock().After(backoff):
+		}
+		backoff *= 2
+		if backoff > wrapper.maxBackoff {
+			backoff = wrapper.maxBackoff
+		}
+	}
+}
+
+// isTransient checks if an error is transient, so that the failed call is worth a retry: network errors,
+// timeouts of the call and responses telling that the exchange is overloaded.
+func isTransient(err error) bool {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return binanceTransientCodes[apiErr.Code]
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	message := err.Error()
+	for _, status := range transientStatuses {
+		if strings.Contains(message, http.StatusText(status)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Name gets the name of the wrapped exchange.
+func (wrapper *RateLimitWrapper) Name() string {
+	return wrapper.innerWrapper.Name()
+}
+
+// String returns a string representation of the object.
+func (wrapper *RateLimitWrapper) String() string {
+	return wrapper.innerWrapper.String()
+}
+
+// Capabilities gets the features supported by the exchange.
+func (wrapper *RateLimitWrapper) Capabilities() Capabilities {
+	return wrapper.innerWrapper.Capabilities()
+}
+
+// GetMarkets gets all the markets info.
+func (wrapper *RateLimitWrapper) GetMarkets() ([]*environment.Market, error) {
+	return wrapper.GetMarketsContext(context.Background())
+}
+
+// GetMarketsContext gets all the markets info, until the context is done.
+func (wrapper *RateLimitWrapper) GetMarketsContext(ctx context.Context) ([]*environment.Market, error) {
+	ret, err := wrapper.call(ctx, "GetMarkets", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetMarketsContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]*environment.Market), nil
+}
+
+// GetCandles gets the candle data from the exchange.
+func (wrapper *RateLimitWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesContext(context.Background(), market, interval)
+}
+
+// GetCandlesContext gets the candle data from the exchange, until the context is done.
+func (wrapper *RateLimitWrapper) GetCandlesContext(ctx context.Context, market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	ret, err := wrapper.call(ctx, "GetCandles", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetCandlesContext(ctx, market, interval)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.CandleStick), nil
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range from the exchange.
+func (wrapper *RateLimitWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	return wrapper.GetCandlesRangeContext(context.Background(), market, interval, from, to)
+}
+
+// GetCandlesRangeContext gets the candles opening in the [from, to) time range from the exchange, until the context is done.
+func (wrapper *RateLimitWrapper) GetCandlesRangeContext(ctx context.Context, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	ret, err := wrapper.call(ctx, "GetCandlesRange", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetCandlesRangeContext(ctx, market, interval, from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.CandleStick), nil
+}
+
+// GetTradingRules gets the constraints of the exchange on the orders of a market.
+func (wrapper *RateLimitWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	ret, err := wrapper.call(context.Background(), "GetTradingRules", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetTradingRules(market)
+	})
+	if err != nil {
+		return environment.TradingRules{}, err
+	}
+	return ret.(environment.TradingRules), nil
+}
+
+// GetMarketSummary gets the current market summary.
+func (wrapper *RateLimitWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
+	return wrapper.GetMarketSummaryContext(context.Background(), market)
+}
+
+// GetMarketSummaryContext gets the current market summary, until the context is done.
+func (wrapper *RateLimitWrapper) GetMarketSummaryContext(ctx context.Context, market *environment.Market) (*environment.MarketSummary, error) {
+	ret, err := wrapper.call(ctx, "GetMarketSummary", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetMarketSummaryContext(ctx, market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.MarketSummary), nil
+}
+
+// GetOrderBook gets the order(ASK + BID) book of a market.
+func (wrapper *RateLimitWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	return wrapper.GetOrderBookContext(context.Background(), market)
+}
+
+// GetOrderBookContext gets the order(ASK + BID) book of a market, until the context is done.
+func (wrapper *RateLimitWrapper) GetOrderBookContext(ctx context.Context, market *environment.Market) (*environment.OrderBook, error) {
+	ret, err := wrapper.call(ctx, "GetOrderBook", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetOrderBookContext(ctx, market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.OrderBook), nil
+}
+
+// GetRecentTrades gets the last public trades of a market, oldest first.
+func (wrapper *RateLimitWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return wrapper.GetRecentTradesContext(context.Background(), market)
+}
+
+// GetRecentTradesContext gets the last public trades of a market, oldest first, until the context is done.
+func (wrapper *RateLimitWrapper) GetRecentTradesContext(ctx context.Context, market *environment.Market) ([]environment.Trade, error) {
+	ret, err := wrapper.call(ctx, "GetRecentTrades", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetRecentTradesContext(ctx, market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]environment.Trade), nil
+}
+
+// GetListPriceChangeStats gets the price change statistics of the markets.
+func (wrapper *RateLimitWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
+	return wrapper.GetListPriceChangeStatsContext(context.Background())
+}
+
+// GetListPriceChangeStatsContext gets the price change statistics of the markets, until the context is done.
+func (wrapper *RateLimitWrapper) GetListPriceChangeStatsContext(ctx context.Context) (environment.ListPriceChangeStats, error) {
+	ret, err := wrapper.call(ctx, "GetListPriceChangeStats", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetListPriceChangeStatsContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(environment.ListPriceChangeStats), nil
+}
+
+// BuyLimit performs a limit buy action.
+func (wrapper *RateLimitWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.BuyLimitContext(context.Background(), market, amount, limit)
+}
+
+// BuyLimitContext performs a limit buy action, until the context is done.
+func (wrapper *RateLimitWrapper) BuyLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "BuyLimit", false, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.BuyLimitContext(ctx, market, amount, limit)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// SellLimit performs a limit sell action.
+func (wrapper *RateLimitWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return wrapper.SellLimitContext(context.Background(), market, amount, limit)
+}
+
+// SellLimitContext performs a limit sell action, until the context is done.
+func (wrapper *RateLimitWrapper) SellLimitContext(ctx context.Context, market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "SellLimit", false, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.SellLimitContext(ctx, market, amount, limit)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// BuyMarket performs a market buy action.
+func (wrapper *RateLimitWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.BuyMarketContext(context.Background(), market, amount)
+}
+
+// BuyMarketContext performs a market buy action, until the context is done.
+func (wrapper *RateLimitWrapper) BuyMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "BuyMarket", false, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.BuyMarketContext(ctx, market, amount)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// SellMarket performs a market sell action.
+func (wrapper *RateLimitWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return wrapper.SellMarketContext(context.Background(), market, amount)
+}
+
+// SellMarketContext performs a market sell action, until the context is done.
+func (wrapper *RateLimitWrapper) SellMarketContext(ctx context.Context, market *environment.Market, amount decimal.Decimal) (string, error) {
+	ret, err := wrapper.call(ctx, "SellMarket", false, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.SellMarketContext(ctx, market, amount)
+	})
+	if err != nil {
+		return "", err
+	}
+	return ret.(string), nil
+}
+
+// CancelOrder cancels an open order.
+func (wrapper *RateLimitWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	return wrapper.CancelOrderContext(context.Background(), market, orderID)
+}
+
+// CancelOrderContext cancels an open order, until the context is done.
+func (wrapper *RateLimitWrapper) CancelOrderContext(ctx context.Context, market *environment.Market, orderID string) error {
+	_, err := wrapper.call(ctx, "CancelOrder", false, func(ctx context.Context) (interface{}, error) {
+		return nil, wrapper.innerWrapper.CancelOrderContext(ctx, market, orderID)
+	})
+	return err
+}
+
+// GetOrder gets the current status of an order.
+func (wrapper *RateLimitWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return wrapper.GetOrderContext(context.Background(), market, orderID)
+}
+
+// GetOrderContext gets the current status of an order, until the context is done.
+func (wrapper *RateLimitWrapper) GetOrderContext(ctx context.Context, market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	ret, err := wrapper.call(ctx, "GetOrder", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetOrderContext(ctx, market, orderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*environment.OrderInfo), nil
+}
+
+// GetOpenOrders gets the orders of the user still open on a market.
+func (wrapper *RateLimitWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return wrapper.GetOpenOrdersContext(context.Background(), market)
+}
+
+// GetOpenOrdersContext gets the orders of the user still open on a market, until the context is done.
+func (wrapper *RateLimitWrapper) GetOpenOrdersContext(ctx context.Context, market *environment.Market) ([]*environment.OrderInfo, error) {
+	ret, err := wrapper.call(ctx, "GetOpenOrders", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetOpenOrdersContext(ctx, market)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]*environment.OrderInfo), nil
+}
+
+// CalculateTradingFees calculates the trading fees for an order on a specified market.
+func (wrapper *RateLimitWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType TradeType) decimal.Decimal {
+	return wrapper.innerWrapper.CalculateTradingFees(market, amount, limit, orderType)
+}
+
+// CalculateWithdrawFees calculates the withdrawal fees on a specified market.
+func (wrapper *RateLimitWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return wrapper.innerWrapper.CalculateWithdrawFees(market, amount)
+}
+
+// GetBalance gets the balance of the user of the specified currency.
+func (wrapper *RateLimitWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
+	return wrapper.GetBalanceContext(context.Background(), symbol)
+}
+
+// GetBalanceContext gets the balance of the user of the specified currency, until the context is done.
+func (wrapper *RateLimitWrapper) GetBalanceContext(ctx context.Context, symbol string) (*decimal.Decimal, error) {
+	ret, err := wrapper.call(ctx, "GetBalance", true, func(ctx context.Context) (interface{}, error) {
+		return wrapper.innerWrapper.GetBalanceContext(ctx, symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*decimal.Decimal), nil
+}
+
+// GetDepositAddress gets the deposit address for the specified coin on the exchange, if exists.
+func (wrapper *RateLimitWrapper) GetDepositAddress(coinTicker string) (string, bool) {
+	return wrapper.innerWrapper.GetDepositAddress(coinTicker)
+}
+
+// FeedConnect connects to the feed of the exchange.
+func (wrapper *RateLimitWrapper) FeedConnect(markets []*environment.Market) error {
+	return wrapper.innerWrapper.FeedConnect(markets)
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *RateLimitWrapper) FeedDisconnect() error {
+	return wrapper.innerWrapper.FeedDisconnect()
+}
+
+// SubscribeTrades subscribes to the public trades of a market got from the feed.
+func (wrapper *RateLimitWrapper) SubscribeTrades(market *environment.Market) (*TradeSubscription, error) {
+	return wrapper.innerWrapper.SubscribeTrades(market)
+}
+
+// Withdraw performs a withdraw operation from the exchange to a destination address.
+func (wrapper *RateLimitWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return wrapper.WithdrawContext(context.Background(), destinationAddress, coinTicker, amount)
+}
+
+// WithdrawContext performs a withdraw operation from the exchange to a destination address, until the context is done.
+func (wrapper *RateLimitWrapper) WithdrawContext(ctx context.Context, destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	_, err := wrapper.call(ctx, "Withdraw", false, func(ctx context.Context) (interface{}, error) {
+		return nil, wrapper.innerWrapper.WithdrawContext(ctx, destinationAddress, coinTicker, amount)
+	})
+	return err
+}
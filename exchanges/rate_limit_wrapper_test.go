@@ -0,0 +1,133 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/clock"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+func TestRequestBudget(t *testing.T) {
+	fake := clock.NewFake(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(fake)
+	defer SetClock(nil)
+
+	budget := &requestBudget{
+		mutex:    &sync.Mutex{},
+		limit:    10,
+		interval: 10 * time.Second,
+	}
+	steps := []struct {
+		name      string
+		elapsed   time.Duration
+		weight    int // Weight reserved, refunded if negative.
+		wantWait  time.Duration
+		wantUsed  float64
+		throttled int64
+	}{
+		{"first call", 0, 4, 0, 4, 0},
+		{"budget filled", 0, 6, 0, 10, 0},
+		{"budget exceeded", 0, 2, 2 * time.Second, 12, 1},
+		{"budget exceeded again", 0, 1, 3 * time.Second, 13, 2},
+		{"partial refill", 5 * time.Second, 1, 0, 9, 2},
+		{"refund", 0, -3, 0, 6, 2},
+		{"refund of more than used", 0, -10, 0, 0, 2},
+		{"full refill", 20 * time.Second, 10, 0, 10, 2},
+		{"refill of a share", 1500 * time.Millisecond, 3, 1500 * time.Millisecond, 11.5, 3},
+	}
+
+	for _, step := range steps {
+		fake.Advance(step.elapsed)
+		var wait time.Duration
+		if step.weight >= 0 {
+			wait = budget.reserve(step.weight)
+		} else {
+			budget.refund(-step.weight)
+		}
+		if wait != step.wantWait {
+			t.Errorf("%s: wait = %s, want %s", step.name, wait, step.wantWait)
+		}
+		if budget.used != step.wantUsed {
+			t.Errorf("%s: used = %v, want %v", step.name, budget.used, step.wantUsed)
+		}
+		if budget.throttled != step.throttled {
+			t.Errorf("%s: throttled = %d, want %d", step.name, budget.throttled, step.throttled)
+		}
+	}
+}
+
+func TestUnlimitedRequestBudget(t *testing.T) {
+	budget := &requestBudget{
+		mutex:    &sync.Mutex{},
+		interval: time.Second,
+	}
+	for i := 0; i < 100; i++ {
+		if wait := budget.reserve(100); wait != 0 {
+			t.Fatalf("call %d: wait = %s, want 0", i, wait)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(clock.NewFake(start))
+	defer SetClock(nil)
+
+	tests := []struct {
+		value string
+		want  time.Time // Zero if the value is invalid.
+	}{
+		{"", time.Time{}},
+		{"120", start.Add(2 * time.Minute)},
+		{"Mon, 01 Mar 2021 00:05:00 GMT", start.Add(5 * time.Minute)},
+		{"soon", time.Time{}},
+	}
+
+	for _, test := range tests {
+		got, ok := retryAfter(test.value)
+		if ok != !test.want.IsZero() || !got.Equal(test.want) {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s", test.value, got, ok, test.want)
+		}
+	}
+}
+
+func TestRateLimitWrapperMeter(t *testing.T) {
+	live := NewBinanceWrapper("", "", nil).(*BinanceWrapper)
+	tests := []struct {
+		name    string
+		wrapper ExchangeWrapper
+	}{
+		{"exchange", live},
+		{"context wrapper", NewContextWrapper(live, time.Second)},
+		{"simulator", NewExchangeWrapperSimulator(live, map[string]decimal.Decimal{})},
+		{"context wrapper of the simulator", NewContextWrapper(NewExchangeWrapperSimulator(live, map[string]decimal.Decimal{}), 0)},
+	}
+
+	for _, test := range tests {
+		wrapper := NewRateLimitWrapper(test.wrapper, environment.RateLimitConfig{})
+		if wrapper.meter != live.meter {
+			t.Errorf("%s: the requests of the exchange are not metered", test.name)
+		}
+		if wrapper.budget.limit != defaultRateLimits["binance"].Weight {
+			t.Errorf("%s: limit = %d, want the one of the exchange", test.name, wrapper.budget.limit)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout is the timeout of the HTTP clients given to the exchange APIs, as their default one.
+const defaultHTTPTimeout = 30 * time.Second
+
+// requestMeter reports to a RateLimitWrapper the requests made to the exchange API by the wrapper it wraps
+// which are not visible from outside: the extra requests of the calls making more than one (e.g. paging the candles),
+// and the pauses asked by the exchange with the Retry-After header.
+type requestMeter struct {
+	mutex       *sync.Mutex
+	charge      func(ctx context.Context, operation string) error // Waits for the budget to allow a request, nil if not rate limited.
+	pausedUntil time.Time                                         // Time until which the exchange asked not to make requests.
+}
+
+// meteredWrapper is implemented by the wrappers reporting their requests to a requestMeter.
+type meteredWrapper interface {
+	requestMeter() *requestMeter
+}
+
+// newRequestMeter creates a new meter, counting nothing until it is used by a RateLimitWrapper.
+func newRequestMeter() *requestMeter {
+	return &requestMeter{
+		mutex: &sync.Mutex{},
+	}
+}
+
+// request waits for the budget to allow an extra request of the specified operation, or for the context to be done.
+func (meter *requestMeter) request(ctx context.Context, operation string) error {
+	if meter == nil {
+		return nil
+	}
+	meter.mutex.Lock()
+	charge := meter.charge
+	meter.mutex.Unlock()
+
+	if charge == nil {
+		return nil
+	}
+	return charge(ctx, operation)
+}
+
+// setCharge sets the function counting the requests against the budget of the exchange API.
+func (meter *requestMeter) setCharge(charge func(ctx context.Context, operation string) error) {
+	meter.mutex.Lock()
+	meter.charge = charge
+	meter.mutex.Unlock()
+}
+
+// pause stops the requests until the specified time.
+func (meter *requestMeter) pause(until time.Time) {
+	meter.mutex.Lock()
+	if until.After(meter.pausedUntil) {
+		meter.pausedUntil = until
+	}
+	meter.mutex.Unlock()
+}
+
+// pauseLeft gets how long the requests must still wait for the pause asked by the exchange.
+func (meter *requestMeter) pauseLeft() time.Duration {
+	meter.mutex.Lock()
+	defer meter.mutex.Unlock()
+	return meter.pausedUntil.Sub(now())
+}
+
+// httpClient gets an HTTP client for the exchange API, pausing the meter as asked by the responses.
+func (meter *requestMeter) httpClient() *http.Client {
+	return &http.Client{
+		Transport: &retryAfterTransport{
+			meter: meter,
+			inner: http.DefaultTransport,
+		},
+		Timeout: defaultHTTPTimeout,
+	}
+}
+
+// retryAfterTransport is an http.RoundTripper pausing a meter for the time asked by the Retry-After header
+// of the responses telling that the requests are too many (429) or that the client is banned (418).
+type retryAfterTransport struct {
+	meter *requestMeter
+	inner http.RoundTripper
+}
+
+// RoundTrip executes a single HTTP transaction, see http.RoundTripper.
+func (transport *retryAfterTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := transport.inner.RoundTrip(request)
+	if err != nil {
+		return response, err
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusTeapot {
+		if until, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			transport.meter.pause(until)
+		}
+	}
+	return response, nil
+}
+
+// retryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date.
+func retryAfter(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now().Add(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
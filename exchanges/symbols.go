@@ -0,0 +1,112 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+)
+
+// listedSymbols contains the markets listed by the exchanges, to map the canonical names
+// of the markets (BASE-QUOTE) to the native symbols of the exchanges, and back.
+var listedSymbols = struct {
+	mutex   sync.RWMutex
+	natives map[string]map[string]string              // Native symbols by exchange and canonical name.
+	markets map[string]map[string]*environment.Market // Listed markets by exchange and native symbol.
+}{
+	natives: make(map[string]map[string]string),
+	markets: make(map[string]map[string]*environment.Market),
+}
+
+// listedMarket creates a market listed by an exchange, named using the canonical notation.
+func listedMarket(wrapper ExchangeWrapper, symbol string, baseCurrency string, marketCurrency string) *environment.Market {
+	return &environment.Market{
+		Name:           environment.MarketName(baseCurrency, marketCurrency),
+		BaseCurrency:   strings.ToUpper(baseCurrency),
+		MarketCurrency: strings.ToUpper(marketCurrency),
+		ExchangeNames:  map[string]string{wrapper.Name(): symbol},
+	}
+}
+
+// registerMarkets registers the markets listed by an exchange, replacing the previous listing.
+func registerMarkets(wrapper ExchangeWrapper, markets []*environment.Market) {
+	exchange := wrapper.Name()
+	natives := make(map[string]string, len(markets))
+	listed := make(map[string]*environment.Market, len(markets))
+	for _, market := range markets {
+		symbol := market.ExchangeNames[exchange]
+		natives[market.Name] = symbol
+		listed[symbol] = market
+	}
+
+	listedSymbols.mutex.Lock()
+	listedSymbols.natives[exchange] = natives
+	listedSymbols.markets[exchange] = listed
+	listedSymbols.mutex.Unlock()
+}
+
+// marketsListed checks if the markets of an exchange have been registered.
+func marketsListed(wrapper ExchangeWrapper) bool {
+	listedSymbols.mutex.RLock()
+	defer listedSymbols.mutex.RUnlock()
+
+	_, listed := listedSymbols.markets[wrapper.Name()]
+	return listed
+}
+
+// listMarkets registers the markets of an exchange, if not registered yet.
+func listMarkets(wrapper ExchangeWrapper) error {
+	if marketsListed(wrapper) {
+		return nil
+	}
+	_, err := wrapper.GetMarkets()
+	return err
+}
+
+// NativeSymbol gets the symbol of a market on an exchange from its canonical name (BASE-QUOTE),
+// listing the markets of the exchange if not listed yet.
+func NativeSymbol(wrapper ExchangeWrapper, name string) (string, error) {
+	if err := listMarkets(wrapper); err != nil {
+		return "", err
+	}
+
+	listedSymbols.mutex.RLock()
+	symbol, exists := listedSymbols.natives[wrapper.Name()][strings.ToUpper(name)]
+	listedSymbols.mutex.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("%w: %s on %s", ErrMarketNotListed, name, wrapper.Name())
+	}
+	return symbol, nil
+}
+
+// ListedMarket gets the market of an exchange from its native symbol,
+// listing the markets of the exchange if not listed yet.
+func ListedMarket(wrapper ExchangeWrapper, symbol string) (*environment.Market, error) {
+	if err := listMarkets(wrapper); err != nil {
+		return nil, err
+	}
+
+	listedSymbols.mutex.RLock()
+	market, exists := listedSymbols.markets[wrapper.Name()][symbol]
+	listedSymbols.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s on %s", ErrMarketNotListed, symbol, wrapper.Name())
+	}
+	return market, nil
+}
@@ -0,0 +1,88 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+)
+
+// listingWrapper is a wrapper listing its markets in the symbol registry, as the exchange wrappers do.
+type listingWrapper struct {
+	ExchangeWrapper // Not set: the other operations are not used by the registry.
+	listings        int
+}
+
+// Name gets the name of the exchange.
+func (wrapper *listingWrapper) Name() string {
+	return "listing"
+}
+
+// GetMarkets lists two markets, named by the exchange as quote and base concatenated.
+func (wrapper *listingWrapper) GetMarkets() ([]*environment.Market, error) {
+	wrapper.listings++
+	markets := []*environment.Market{
+		listedMarket(wrapper, "btceth", "eth", "btc"),
+		listedMarket(wrapper, "usdtbtc", "BTC", "USDT"),
+	}
+	registerMarkets(wrapper, markets)
+	return markets, nil
+}
+
+func TestSymbolRegistry(t *testing.T) {
+	wrapper := &listingWrapper{}
+	tests := []struct {
+		name       string
+		symbol     string
+		wantErr    error
+		wantBase   string
+		wantMarket string
+	}{
+		{"ETH-BTC", "btceth", nil, "ETH", "BTC"},
+		{"btc-usdt", "usdtbtc", nil, "BTC", "USDT"},
+		{"BTC-ETH", "", ErrMarketNotListed, "", ""},
+		{"XMR-BTC", "", ErrMarketNotListed, "", ""},
+	}
+
+	for _, test := range tests {
+		symbol, err := NativeSymbol(wrapper, test.name)
+		if !errors.Is(err, test.wantErr) || symbol != test.symbol {
+			t.Errorf("NativeSymbol(%s) = %q, %v, want %q, %v", test.name, symbol, err, test.symbol, test.wantErr)
+		}
+		if test.wantErr != nil {
+			continue
+		}
+
+		market, err := ListedMarket(wrapper, symbol)
+		if err != nil {
+			t.Fatalf("ListedMarket(%s): %s", symbol, err)
+		}
+		if market.Name != environment.MarketName(test.wantBase, test.wantMarket) ||
+			market.BaseCurrency != test.wantBase || market.MarketCurrency != test.wantMarket {
+			t.Errorf("ListedMarket(%s) = %s (%s priced in %s), want %s priced in %s", symbol, market.Name,
+				market.BaseCurrency, market.MarketCurrency, test.wantBase, test.wantMarket)
+		}
+	}
+
+	if _, err := ListedMarket(wrapper, "ethbtc"); !errors.Is(err, ErrMarketNotListed) {
+		t.Errorf("ListedMarket(ethbtc): got error %v, want %v", err, ErrMarketNotListed)
+	}
+	if wrapper.listings != 1 {
+		t.Errorf("markets listed %d times, want once", wrapper.listings)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"sync"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/sirupsen/logrus"
+)
+
+// tradePollingInterval is the interval between the REST calls getting the public trades of a market,
+// on the exchanges whose feed does not provide them.
+const tradePollingInterval = 5 * time.Second
+
+// tradePollers contains the markets whose public trades are polled, by exchange.
+var tradePollers = struct {
+	mutex   sync.Mutex
+	polling map[string]map[*environment.Market]bool
+}{
+	polling: make(map[string]map[*environment.Market]bool),
+}
+
+// pollTrades subscribes to the public trades of a market, notifying the new trades got from the REST API
+// of an exchange until the trade subscriptions of the market are cancelled or closed.
+func pollTrades(wrapper ExchangeWrapper, market *environment.Market, recentTrades func(market *environment.Market) ([]environment.Trade, error)) *TradeSubscription {
+	subscription := subscribeTrades(market)
+
+	tradePollers.mutex.Lock()
+	defer tradePollers.mutex.Unlock()
+
+	polling, exists := tradePollers.polling[wrapper.Name()]
+	if !exists {
+		polling = make(map[*environment.Market]bool)
+		tradePollers.polling[wrapper.Name()] = polling
+	}
+	if polling[market] {
+		return subscription
+	}
+	polling[market] = true
+
+	go func() {
+		var last *environment.Trade
+		for {
+			tradePollers.mutex.Lock()
+			if !hasTradeSubscriptions(market) {
+				delete(polling, market)
+				tradePollers.mutex.Unlock()
+				return
+			}
+			tradePollers.mutex.Unlock()
+
+			recent, err := recentTrades(market)
+			if err != nil {
+				logrus.Warnf("%s trades polling error: %s", wrapper.Name(), err)
+			} else if len(recent) > 0 {
+				for _, trade := range tradesAfter(recent, last) {
+					notifyTrade(market, trade)
+				}
+				last = &recent[len(recent)-1]
+			}
+
+			<-getClock().After(tradePollingInterval)
+		}
+	}()
+
+	return subscription
+}
+
+// tradesAfter gets the recent trades following the last notified one, none when polling for the first time.
+func tradesAfter(recent []environment.Trade, last *environment.Trade) []environment.Trade {
+	if last == nil {
+		return nil
+	}
+
+	for i := len(recent) - 1; i >= 0; i-- {
+		if sameTrade(recent[i], *last) {
+			return recent[i+1:]
+		}
+	}
+
+	// the last notified trade is not recent anymore, some trades may have been missed.
+	for i, trade := range recent {
+		if trade.Timestamp.After(last.Timestamp) {
+			return recent[i:]
+		}
+	}
+	return nil
+}
+
+// sameTrade tells whether two trades are the same one, comparing their IDs when given by the exchange.
+func sameTrade(a environment.Trade, b environment.Trade) bool {
+	if a.ID != "" || b.ID != "" {
+		return a.ID == b.ID
+	}
+	return a.Timestamp.Equal(b.Timestamp) && a.Price.Equal(b.Price) && a.Quantity.Equal(b.Quantity) && a.Side == b.Side
+}
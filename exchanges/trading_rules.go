@@ -0,0 +1,93 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exchanges
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// TradingRulesCache represents a local cache of the trading rules of the markets of an exchange,
+// by exchange specific market name.
+type TradingRulesCache struct {
+	mutex    *sync.RWMutex
+	internal map[string]environment.TradingRules
+}
+
+// NewTradingRulesCache creates a new TradingRulesCache Object
+func NewTradingRulesCache() *TradingRulesCache {
+	return &TradingRulesCache{
+		mutex:    &sync.RWMutex{},
+		internal: make(map[string]environment.TradingRules),
+	}
+}
+
+// Set sets a value for the specified key.
+func (trc *TradingRulesCache) Set(marketName string, rules environment.TradingRules) {
+	trc.mutex.Lock()
+	trc.internal[marketName] = rules
+	trc.mutex.Unlock()
+}
+
+// Get gets the value for the specified key.
+func (trc *TradingRulesCache) Get(marketName string) (environment.TradingRules, bool) {
+	trc.mutex.RLock()
+	ret, isSet := trc.internal[marketName]
+	trc.mutex.RUnlock()
+	return ret, isSet
+}
+
+// getOrLoad gets the value for the specified key, loading the markets of the exchange if not known yet.
+func (trc *TradingRulesCache) getOrLoad(marketName string, loadMarkets func() error) (environment.TradingRules, error) {
+	if rules, isSet := trc.Get(marketName); isSet {
+		return rules, nil
+	}
+
+	if err := loadMarkets(); err != nil {
+		return environment.TradingRules{}, err
+	}
+
+	rules, isSet := trc.Get(marketName)
+	if !isSet {
+		return environment.TradingRules{}, fmt.Errorf("Trading rules of market %s not found", marketName)
+	}
+	return rules, nil
+}
+
+// RoundOrder rounds an order to the trading rules of its market on the exchange, or explains why it cannot be placed.
+//
+//     The side is Bid for a buy order and Ask for a sell order; use a zero price for market orders.
+func RoundOrder(wrapper ExchangeWrapper, market *environment.Market, side environment.OrderType, quantity decimal.Decimal, price decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	rules, err := wrapper.GetTradingRules(market)
+	if err != nil {
+		return quantity, price, err
+	}
+	return rules.RoundOrder(side, quantity, price)
+}
+
+// stepOf gets the step represented by a number of decimal places (e.g. 0.001 for 3 places).
+func stepOf(places int) decimal.Decimal {
+	return decimal.New(1, int32(-places))
+}
+
+// toFloat converts an exact amount for the exchange APIs accepting only floats.
+func toFloat(amount decimal.Decimal) float64 {
+	ret, _ := amount.Float64()
+	return ret
+}
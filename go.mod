@@ -6,26 +6,18 @@ require (
 	github.com/adshao/go-binance/v2 v2.2.0
 	github.com/beldur/kraken-go-api-client v0.0.0-20210113103835-3f11c80eba1a
 	github.com/bitfinexcom/bitfinex-api-go v0.0.0-20210101155619-bb56f756df78
-	github.com/bwmarrin/discordgo v0.22.1 // indirect
 	github.com/dgrr/fastws v1.0.3 // indirect
 	github.com/fatih/structs v1.1.0
 	github.com/fiore/kucoin-go v0.0.0-20190107105632-5a814c26befa
 	github.com/gofrs/uuid v4.0.0+incompatible
+	github.com/gorilla/websocket v1.4.2
 	github.com/juju/errors v0.0.0-20200330140219-3fe23663418f
 	github.com/kr/pretty v0.2.1 // indirect
-	github.com/nlopes/slack v0.6.0 // indirect
-	github.com/openacid/genr v0.1.1 // indirect
-	github.com/openacid/low v0.1.14 // indirect
 	github.com/openacid/slim v0.5.4
-	github.com/openacid/testkeys v0.1.6 // indirect
 	github.com/pharrisee/poloniex-api v0.0.0-20200602104112-ce8fafd80b26
-	github.com/pplcc/plotext v0.0.0-20180221170324-68ab3c6e05c3 // indirect
 	github.com/saniales/go-hitbtc v0.0.0-20190107211814-7468d66640dd
-	github.com/satori/go.uuid v1.2.0 // indirect
-	github.com/shomali11/slacker v0.0.0-20201209125859-873a67578e50 // indirect
 	github.com/shopspring/decimal v1.2.0
 	github.com/sirupsen/logrus v1.7.0
-	github.com/slack-go/slack v0.6.3 // indirect
 	github.com/sourcegraph/jsonrpc2 v0.0.0-20200429184054-15c2290dcb37 // indirect
 	github.com/spf13/cobra v1.1.1
 	github.com/thebotguys/golang-bittrex-api v0.0.0-20180802202435-20ec1f520379
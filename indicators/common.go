@@ -0,0 +1,124 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"errors"
+	"math"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidPeriod is the error representing an indicator period lower than 1.
+var ErrInvalidPeriod = errors.New("Indicator period must be > 0")
+
+// ErrNotEnoughCandles is the error representing a series too short to compute an indicator.
+var ErrNotEnoughCandles = errors.New("Not enough candles to compute the indicator")
+
+var (
+	two     = decimal.NewFromInt(2)
+	three   = decimal.NewFromInt(3)
+	hundred = decimal.NewFromInt(100)
+)
+
+// Closes returns the close prices of the candles.
+func Closes(candles []environment.CandleStick) []decimal.Decimal {
+	ret := make([]decimal.Decimal, len(candles))
+	for i, candle := range candles {
+		ret[i] = candle.Close
+	}
+	return ret
+}
+
+// TypicalPrice returns the typical price of a candle: (High + Low + Close) / 3.
+func TypicalPrice(candle environment.CandleStick) decimal.Decimal {
+	return candle.High.Add(candle.Low).Add(candle.Close).Div(three)
+}
+
+// checkPeriod returns an error if the period is invalid or longer than the series.
+func checkPeriod(length int, period int) error {
+	if period < 1 {
+		return ErrInvalidPeriod
+	}
+	if length < period {
+		return ErrNotEnoughCandles
+	}
+	return nil
+}
+
+// zeros returns a series of length zero values.
+func zeros(length int) []decimal.Decimal {
+	ret := make([]decimal.Decimal, length)
+	for i := range ret {
+		ret[i] = decimal.Zero
+	}
+	return ret
+}
+
+// sma computes the simple moving average of values, starting from index start.
+func sma(values []decimal.Decimal, start int, period int) []decimal.Decimal {
+	ret := zeros(len(values))
+	if len(values)-start < period {
+		return ret
+	}
+
+	sum := decimal.Zero
+	length := decimal.NewFromInt(int64(period))
+	for i := start; i < len(values); i++ {
+		sum = sum.Add(values[i])
+		if i-start >= period {
+			sum = sum.Sub(values[i-period])
+		}
+		if i-start >= period-1 {
+			ret[i] = sum.Div(length)
+		}
+	}
+	return ret
+}
+
+// ema computes the exponential moving average of values, starting from index start.
+//
+//     The first value is the simple average of the first period values.
+func ema(values []decimal.Decimal, start int, period int) []decimal.Decimal {
+	ret := zeros(len(values))
+	if len(values)-start < period {
+		return ret
+	}
+
+	k := two.Div(decimal.NewFromInt(int64(period + 1)))
+	first := start + period - 1
+	ret[first] = sma(values[start:first+1], 0, period)[period-1]
+	for i := first + 1; i < len(values); i++ {
+		ret[i] = values[i].Sub(ret[i-1]).Mul(k).Add(ret[i-1])
+	}
+	return ret
+}
+
+// sqrt computes the square root of a non negative value.
+func sqrt(value decimal.Decimal) decimal.Decimal {
+	if !value.IsPositive() {
+		return decimal.Zero
+	}
+
+	f, _ := value.Float64()
+	ret := decimal.NewFromFloat(math.Sqrt(f))
+	// refine the float estimate with Newton's method.
+	for i := 0; i < 3; i++ {
+		ret = ret.Add(value.Div(ret)).Div(two)
+	}
+	return ret
+}
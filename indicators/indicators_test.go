@@ -0,0 +1,290 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// stockChartsCloses are the closes of the StockCharts moving averages sample (SMA and EMA tables).
+var stockChartsCloses = []float64{
+	22.2734, 22.1940, 22.0847, 22.1741, 22.1840, 22.1344, 22.2337, 22.4323, 22.2436, 22.2933,
+	22.1542, 22.3926, 22.3816, 22.6109, 23.3558, 24.0519, 23.7530, 23.8324, 23.9516, 23.6338,
+	23.8225, 23.8722, 23.6537, 23.1870, 23.0976, 23.3260, 22.6805, 23.0976, 22.4025, 22.1725,
+}
+
+// stockChartsRSICloses are the closes of the StockCharts RSI sample (Wilder's 14 periods table).
+var stockChartsRSICloses = []float64{
+	44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+	45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+	46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+	43.4205, 42.6628, 43.1314,
+}
+
+// stockChartsHighs, stockChartsLows and stockChartsHLCCloses are the candles of the StockCharts ATR sample,
+// sampleVolumes are the volumes used along with them.
+var (
+	stockChartsHighs = []float64{
+		48.70, 48.72, 48.90, 48.87, 48.82, 49.05, 49.20, 49.35, 49.92, 50.19,
+		50.12, 49.66, 49.88, 50.19, 50.36, 50.57, 50.65, 50.43, 49.63, 50.33,
+		50.29, 50.17, 49.32, 48.50, 48.32, 46.80, 47.80, 48.39, 48.66, 48.79,
+	}
+	stockChartsLows = []float64{
+		47.79, 48.14, 48.39, 48.37, 48.24, 48.64, 48.94, 48.86, 49.50, 49.87,
+		49.20, 48.90, 49.43, 49.73, 49.26, 50.09, 50.30, 49.21, 48.98, 49.61,
+		49.20, 49.43, 48.08, 47.64, 41.55, 44.28, 47.31, 47.20, 47.90, 47.73,
+	}
+	stockChartsHLCCloses = []float64{
+		48.16, 48.61, 48.75, 48.63, 48.74, 49.03, 49.07, 49.32, 49.91, 50.13,
+		49.53, 49.50, 49.75, 50.03, 50.31, 50.52, 50.41, 49.34, 49.37, 50.23,
+		49.24, 49.93, 48.43, 48.18, 46.57, 45.41, 47.77, 47.72, 48.62, 47.85,
+	}
+	sampleVolumes = []float64{
+		1200, 1500, 900, 1100, 1300, 1250, 980, 1700, 2100, 1800,
+		1600, 1400, 1350, 1500, 1900, 2200, 1750, 2600, 1650, 1450,
+		1900, 1550, 2400, 2300, 3900, 3100, 2000, 1800, 1700, 1600,
+	}
+)
+
+// closeCandles creates candles from their closes.
+func closeCandles(closes []float64) []environment.CandleStick {
+	ret := make([]environment.CandleStick, len(closes))
+	for i, close := range closes {
+		price := decimal.NewFromFloat(close)
+		ret[i] = environment.CandleStick{High: price, Open: price, Close: price, Low: price, Volume: decimal.Zero}
+	}
+	return ret
+}
+
+// sampleCandles creates the candles of the StockCharts ATR sample, with the sample volumes.
+func sampleCandles() []environment.CandleStick {
+	ret := make([]environment.CandleStick, len(stockChartsHLCCloses))
+	for i := range ret {
+		ret[i] = environment.CandleStick{
+			High:   decimal.NewFromFloat(stockChartsHighs[i]),
+			Open:   decimal.NewFromFloat(stockChartsHLCCloses[i]),
+			Close:  decimal.NewFromFloat(stockChartsHLCCloses[i]),
+			Low:    decimal.NewFromFloat(stockChartsLows[i]),
+			Volume: decimal.NewFromFloat(sampleVolumes[i]),
+		}
+	}
+	return ret
+}
+
+// checkSeries checks the values of a series from index first, within the tolerance.
+func checkSeries(t *testing.T, name string, got []decimal.Decimal, first int, want []float64, tolerance float64) {
+	t.Helper()
+	if len(got) != first+len(want) {
+		t.Fatalf("%s: got %d values, want %d", name, len(got), first+len(want))
+	}
+	for i := 0; i < first; i++ {
+		if !got[i].IsZero() {
+			t.Errorf("%s[%d] = %s during warm-up, want 0", name, i, got[i])
+		}
+	}
+	for i, value := range want {
+		if got[first+i].Sub(decimal.NewFromFloat(value)).Abs().GreaterThan(decimal.NewFromFloat(tolerance)) {
+			t.Errorf("%s[%d] = %s, want %v", name, first+i, got[first+i].StringFixed(4), value)
+		}
+	}
+}
+
+// The values of SMA, EMA and RSI are the ones published by StockCharts (rounded to 2 decimals), the others
+// follow the TA-Lib definitions and have been computed independently from the same samples.
+
+func TestMovingAverages(t *testing.T) {
+	candles := closeCandles(stockChartsCloses)
+	tests := []struct {
+		name      string
+		indicator func([]environment.CandleStick, int) ([]decimal.Decimal, error)
+		want      []float64
+		tolerance float64
+	}{
+		{"SMA", SMA, []float64{
+			22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21,
+			23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28, 23.13,
+		}, 0.005},
+		{"EMA", EMA, []float64{
+			22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34,
+			23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+		}, 0.005},
+		{"WMA", WMA, []float64{
+			22.2465, 22.2337, 22.2664, 22.2934, 22.3568, 22.5477, 22.8438, 23.0507, 23.2444, 23.4344, 23.5355,
+			23.6465, 23.7363, 23.7594, 23.6745, 23.5629, 23.4975, 23.3280, 23.2538, 23.0665, 22.8657,
+		}, 0.0001},
+	}
+
+	for _, test := range tests {
+		got, err := test.indicator(candles, 10)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		checkSeries(t, test.name, got, 9, test.want, test.tolerance)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	got, err := RSI(closeCandles(stockChartsRSICloses), 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeries(t, "RSI", got, 14, []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}, 0.005)
+}
+
+func TestMACD(t *testing.T) {
+	macd, signal, histogram, err := MACD(closeCandles(stockChartsCloses), 5, 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeries(t, "MACD", macd, 9, []float64{
+		0.0471, 0.0207, 0.0412, 0.0482, 0.0838, 0.2111, 0.3731, 0.3936, 0.3930, 0.3869, 0.3119, 0.2808,
+		0.2543, 0.1913, 0.0746, -0.0071, -0.0166, -0.1186, -0.1038, -0.1948, -0.2675,
+	}, 0.0001)
+	checkSeries(t, "MACD signal", signal, 12, []float64{
+		0.0393, 0.0571, 0.1187, 0.2205, 0.2897, 0.3310, 0.3534, 0.3368, 0.3144,
+		0.2903, 0.2507, 0.1803, 0.1054, 0.0566, -0.0135, -0.0496, -0.1077, -0.1716,
+	}, 0.0001)
+	checkSeries(t, "MACD histogram", histogram, 12, []float64{
+		0.0089, 0.0267, 0.0924, 0.1527, 0.1039, 0.0620, 0.0335, -0.0249, -0.0336,
+		-0.0361, -0.0594, -0.1056, -0.1124, -0.0732, -0.1051, -0.0542, -0.0871, -0.0959,
+	}, 0.0001)
+}
+
+func TestBollingerBands(t *testing.T) {
+	middle, upper, lower, err := BollingerBands(closeCandles(stockChartsCloses), 20, decimal.NewFromInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeries(t, "Bollinger middle", middle, 19, []float64{
+		22.7183, 22.7957, 22.8796, 22.9581, 23.0087, 23.0544, 23.1140, 23.1363, 23.1696, 23.1775, 23.1715,
+	}, 0.0001)
+	checkSeries(t, "Bollinger upper", upper, 19, []float64{
+		24.1271, 24.2672, 24.3950, 24.4631, 24.4725, 24.4685, 24.4671, 24.4446, 24.4378, 24.4242, 24.4363,
+	}, 0.0001)
+	checkSeries(t, "Bollinger lower", lower, 19, []float64{
+		21.3094, 21.3243, 21.3642, 21.4530, 21.5450, 21.6403, 21.7608, 21.8280, 21.9014, 21.9308, 21.9067,
+	}, 0.0001)
+}
+
+func TestATR(t *testing.T) {
+	got, err := ATR(sampleCandles(), 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeries(t, "ATR", got, 14, []float64{
+		0.5679, 0.5616, 0.5465, 0.5946, 0.5985, 0.6244, 0.6576, 0.6771,
+		0.7609, 0.7679, 1.1967, 1.2912, 1.3697, 1.3568, 1.3271, 1.3080,
+	}, 0.0001)
+}
+
+func TestStochastic(t *testing.T) {
+	k, d, err := Stochastic(sampleCandles(), 14, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSeries(t, "Stochastic %K", k, 13, []float64{
+		93.3333, 97.7477, 97.8541, 90.0415, 45.6432, 36.3184, 76.5363, 21.2291, 58.8571,
+		13.6187, 17.9402, 55.1648, 42.4176, 68.3516, 67.8022, 77.6923, 69.2308,
+	}, 0.0001)
+	checkSeries(t, "Stochastic %D", d, 15, []float64{
+		96.3117, 95.2144, 77.8462, 57.3344, 52.8326, 44.6946, 52.2075, 31.2350,
+		30.1387, 28.9079, 38.5075, 55.3114, 59.5238, 71.2821, 71.5751,
+	}, 0.0001)
+}
+
+func TestVolumeIndicators(t *testing.T) {
+	candles := sampleCandles()
+	checkSeries(t, "OBV", OBV(candles), 0, []float64{
+		0, 1500, 2400, 1300, 2600, 3850, 4830, 6530, 8630, 10430,
+		8830, 7430, 8780, 10280, 12180, 14380, 12630, 10030, 11680, 13130,
+		11230, 12780, 10380, 8080, 4180, 1080, 3080, 1280, 2980, 1380,
+	}, 0)
+	checkSeries(t, "VWAP", VWAP(candles), 0, []float64{
+		48.2167, 48.3685, 48.4464, 48.4878, 48.5121, 48.5801, 48.6385, 48.7306, 48.9132, 49.0629,
+		49.1203, 49.1397, 49.1803, 49.2415, 49.3063, 49.4068, 49.4786, 49.4953, 49.4860, 49.5125,
+		49.5162, 49.5308, 49.4712, 49.3915, 49.0390, 48.8023, 48.7537, 48.7184, 48.7077, 48.6902,
+	}, 0.0001)
+}
+
+func TestInvalidPeriods(t *testing.T) {
+	candles := sampleCandles()
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"SMA zero period", second(SMA(candles, 0)), ErrInvalidPeriod},
+		{"EMA too long", second(EMA(candles, 31)), ErrNotEnoughCandles},
+		{"RSI zero period", second(RSI(candles, 0)), ErrInvalidPeriod},
+		{"ATR zero period", second(ATR(candles, 0)), ErrInvalidPeriod},
+		{"ATR too long", second(ATR(candles, 30)), ErrNotEnoughCandles},
+		{"Stochastic zero %K period", third(Stochastic(candles, 0, 3)), ErrInvalidPeriod},
+		{"Stochastic zero %D period", third(Stochastic(candles, 14, 0)), ErrInvalidPeriod},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.want) {
+			t.Errorf("%s: got error %v, want %v", test.name, test.err, test.want)
+		}
+	}
+}
+
+func TestInvalidStreamPeriods(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"SMA zero period", streamError(NewSMAStream(0)), ErrInvalidPeriod},
+		{"EMA zero period", streamError(NewEMAStream(0)), ErrInvalidPeriod},
+		{"WMA negative period", streamError(NewWMAStream(-1)), ErrInvalidPeriod},
+		{"RSI zero period", streamError(NewRSIStream(0)), ErrInvalidPeriod},
+		{"MACD zero signal period", streamError(NewMACDStream(12, 26, 0)), ErrInvalidPeriod},
+		{"MACD fast not lower than slow", streamError(NewMACDStream(26, 12, 9)), errMACDPeriods},
+		{"Bollinger zero period", streamError(NewBollingerStream(0, decimal.NewFromInt(2))), ErrInvalidPeriod},
+		{"ATR zero period", streamError(NewATRStream(0)), ErrInvalidPeriod},
+		{"Stochastic zero %K period", streamError(NewStochasticStream(0, 3)), ErrInvalidPeriod},
+		{"Stochastic zero %D period", streamError(NewStochasticStream(14, 0)), ErrInvalidPeriod},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.want) {
+			t.Errorf("%s: got error %v, want %v", test.name, test.err, test.want)
+		}
+	}
+}
+
+// streamError gets the error returned along with a streaming indicator.
+func streamError(_ interface{}, err error) error {
+	return err
+}
+
+// second gets the error returned along with a series.
+func second(_ []decimal.Decimal, err error) error {
+	return err
+}
+
+// third gets the error returned along with two series.
+func third(_ []decimal.Decimal, _ []decimal.Decimal, err error) error {
+	return err
+}
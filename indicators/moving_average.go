@@ -0,0 +1,58 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// SMA computes the Simple Moving Average of the close prices over period candles.
+func SMA(candles []environment.CandleStick, period int) ([]decimal.Decimal, error) {
+	if err := checkPeriod(len(candles), period); err != nil {
+		return nil, err
+	}
+	return sma(Closes(candles), 0, period), nil
+}
+
+// EMA computes the Exponential Moving Average of the close prices over period candles.
+//
+//     The first value is the SMA of the first period candles, the smoothing factor is 2 / (period + 1).
+func EMA(candles []environment.CandleStick, period int) ([]decimal.Decimal, error) {
+	if err := checkPeriod(len(candles), period); err != nil {
+		return nil, err
+	}
+	return ema(Closes(candles), 0, period), nil
+}
+
+// WMA computes the linearly Weighted Moving Average of the close prices over period candles,
+// where the most recent candle has weight period and the oldest has weight 1.
+func WMA(candles []environment.CandleStick, period int) ([]decimal.Decimal, error) {
+	if err := checkPeriod(len(candles), period); err != nil {
+		return nil, err
+	}
+
+	ret := zeros(len(candles))
+	weights := decimal.NewFromInt(int64(period * (period + 1) / 2))
+	for i := period - 1; i < len(candles); i++ {
+		sum := decimal.Zero
+		for j := 0; j < period; j++ {
+			sum = sum.Add(candles[i-j].Close.Mul(decimal.NewFromInt(int64(period - j))))
+		}
+		ret[i] = sum.Div(weights)
+	}
+	return ret, nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"errors"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// RSI computes the Relative Strength Index of the close prices over period candles, using Wilder's smoothing.
+//
+//     The first value is at index period, as it needs period price changes.
+func RSI(candles []environment.CandleStick, period int) ([]decimal.Decimal, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	if err := checkPeriod(len(candles), period+1); err != nil {
+		return nil, err
+	}
+
+	ret := zeros(len(candles))
+	length := decimal.NewFromInt(int64(period))
+	avgGain, avgLoss := decimal.Zero, decimal.Zero
+	for i := 1; i < len(candles); i++ {
+		gain, loss := decimal.Zero, decimal.Zero
+		change := candles[i].Close.Sub(candles[i-1].Close)
+		if change.IsPositive() {
+			gain = change
+		} else {
+			loss = change.Neg()
+		}
+
+		if i <= period {
+			avgGain = avgGain.Add(gain)
+			avgLoss = avgLoss.Add(loss)
+			if i < period {
+				continue
+			}
+			avgGain = avgGain.Div(length)
+			avgLoss = avgLoss.Div(length)
+		} else {
+			avgGain = avgGain.Mul(length.Sub(decimal.NewFromInt(1))).Add(gain).Div(length)
+			avgLoss = avgLoss.Mul(length.Sub(decimal.NewFromInt(1))).Add(loss).Div(length)
+		}
+
+		ret[i] = rsiValue(avgGain, avgLoss)
+	}
+	return ret, nil
+}
+
+// rsiValue computes the RSI from the average gain and loss.
+func rsiValue(avgGain decimal.Decimal, avgLoss decimal.Decimal) decimal.Decimal {
+	if avgLoss.IsZero() {
+		if avgGain.IsZero() {
+			return decimal.NewFromInt(50)
+		}
+		return hundred
+	}
+	rs := avgGain.Div(avgLoss)
+	return hundred.Sub(hundred.Div(rs.Add(decimal.NewFromInt(1))))
+}
+
+// errMACDPeriods is the error representing a MACD fast period not lower than the slow one.
+var errMACDPeriods = errors.New("MACD fast period must be lower than slow period")
+
+// MACD computes the Moving Average Convergence Divergence of the close prices.
+//
+//     The MACD line is EMA(fastPeriod) - EMA(slowPeriod), the signal line is the
+//     EMA(signalPeriod) of the MACD line and the histogram is MACD - signal.
+func MACD(candles []environment.CandleStick, fastPeriod int, slowPeriod int, signalPeriod int) (macd []decimal.Decimal, signal []decimal.Decimal, histogram []decimal.Decimal, err error) {
+	if fastPeriod < 1 || signalPeriod < 1 {
+		return nil, nil, nil, ErrInvalidPeriod
+	}
+	if fastPeriod >= slowPeriod {
+		return nil, nil, nil, errMACDPeriods
+	}
+	if err := checkPeriod(len(candles), slowPeriod+signalPeriod-1); err != nil {
+		return nil, nil, nil, err
+	}
+
+	closes := Closes(candles)
+	fast := ema(closes, 0, fastPeriod)
+	slow := ema(closes, 0, slowPeriod)
+
+	macd = zeros(len(candles))
+	for i := slowPeriod - 1; i < len(candles); i++ {
+		macd[i] = fast[i].Sub(slow[i])
+	}
+
+	signal = ema(macd, slowPeriod-1, signalPeriod)
+	histogram = zeros(len(candles))
+	for i := slowPeriod + signalPeriod - 2; i < len(candles); i++ {
+		histogram[i] = macd[i].Sub(signal[i])
+	}
+	return macd, signal, histogram, nil
+}
+
+// Stochastic computes the Stochastic Oscillator: %K over kPeriod candles and %D as the SMA(dPeriod) of %K.
+//
+//     When the highest high equals the lowest low of the period, %K is 50.
+func Stochastic(candles []environment.CandleStick, kPeriod int, dPeriod int) (k []decimal.Decimal, d []decimal.Decimal, err error) {
+	if kPeriod < 1 || dPeriod < 1 {
+		return nil, nil, ErrInvalidPeriod
+	}
+	if err := checkPeriod(len(candles), kPeriod+dPeriod-1); err != nil {
+		return nil, nil, err
+	}
+
+	k = zeros(len(candles))
+	for i := kPeriod - 1; i < len(candles); i++ {
+		highest, lowest := candles[i].High, candles[i].Low
+		for j := i - kPeriod + 1; j < i; j++ {
+			highest = decimal.Max(highest, candles[j].High)
+			lowest = decimal.Min(lowest, candles[j].Low)
+		}
+		k[i] = stochasticValue(candles[i].Close, highest, lowest)
+	}
+
+	d = sma(k, kPeriod-1, dPeriod)
+	return k, d, nil
+}
+
+// stochasticValue computes %K from the close and the range of the period.
+func stochasticValue(close decimal.Decimal, highest decimal.Decimal, lowest decimal.Decimal) decimal.Decimal {
+	if highest.Equal(lowest) {
+		return decimal.NewFromInt(50)
+	}
+	return close.Sub(lowest).Div(highest.Sub(lowest)).Mul(hundred)
+}
@@ -0,0 +1,256 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// Indicator represents an indicator computed incrementally on a series of values (e.g. last prices).
+//
+//     Updates are O(1) and safe for concurrent use.
+type Indicator interface {
+	Update(value decimal.Decimal) // Adds a new value to the series.
+	Value() decimal.Decimal       // Gets the current value of the indicator, zero during warm-up.
+	Ready() bool                  // Returns true when the warm-up period is over.
+}
+
+// CandleIndicator represents an indicator computed incrementally on a series of closed candles.
+//
+//     Updates are O(1) and safe for concurrent use.
+type CandleIndicator interface {
+	UpdateCandle(candle environment.CandleStick) // Adds a new closed candle to the series.
+	Value() decimal.Decimal                      // Gets the current value of the indicator, zero during warm-up.
+	Ready() bool                                 // Returns true when the warm-up period is over.
+}
+
+// window is a fixed size ring buffer of the last values of a series.
+type window struct {
+	values []decimal.Decimal
+	next   int
+	count  int
+}
+
+// newWindow creates a window of the specified size, which must be at least 1.
+func newWindow(size int) *window {
+	return &window{values: make([]decimal.Decimal, size)}
+}
+
+// push adds a value to the window, returning the value it replaces (if full).
+func (w *window) push(value decimal.Decimal) (decimal.Decimal, bool) {
+	old, full := w.values[w.next], w.count == len(w.values)
+	w.values[w.next] = value
+	w.next = (w.next + 1) % len(w.values)
+	if !full {
+		w.count++
+	}
+	return old, full
+}
+
+func (w *window) full() bool {
+	return w.count == len(w.values)
+}
+
+// SMAStream is the streaming version of SMA.
+type SMAStream struct {
+	mutex  sync.RWMutex
+	period decimal.Decimal
+	window *window
+	sum    decimal.Decimal
+}
+
+// NewSMAStream creates a new streaming Simple Moving Average over period values.
+func NewSMAStream(period int) (*SMAStream, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	return &SMAStream{
+		period: decimal.NewFromInt(int64(period)),
+		window: newWindow(period),
+		sum:    decimal.Zero,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *SMAStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	s.update(value)
+	s.mutex.Unlock()
+}
+
+// update adds a new value to the series, returning the value going out of the window (if any).
+func (s *SMAStream) update(value decimal.Decimal) (decimal.Decimal, bool) {
+	s.sum = s.sum.Add(value)
+	old, full := s.window.push(value)
+	if full {
+		s.sum = s.sum.Sub(old)
+	}
+	return old, full
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *SMAStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+// Value gets the current value of the indicator.
+func (s *SMAStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value()
+}
+
+func (s *SMAStream) value() decimal.Decimal {
+	if !s.window.full() {
+		return decimal.Zero
+	}
+	return s.sum.Div(s.period)
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *SMAStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.window.full()
+}
+
+// EMAStream is the streaming version of EMA.
+type EMAStream struct {
+	mutex  sync.RWMutex
+	period int
+	k      decimal.Decimal
+	count  int
+	sum    decimal.Decimal
+	value  decimal.Decimal
+}
+
+// NewEMAStream creates a new streaming Exponential Moving Average over period values.
+func NewEMAStream(period int) (*EMAStream, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	return &EMAStream{
+		period: period,
+		k:      two.Div(decimal.NewFromInt(int64(period + 1))),
+		sum:    decimal.Zero,
+		value:  decimal.Zero,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *EMAStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	s.update(value)
+	s.mutex.Unlock()
+}
+
+func (s *EMAStream) update(value decimal.Decimal) {
+	s.count++
+	if s.count < s.period {
+		s.sum = s.sum.Add(value)
+	} else if s.count == s.period {
+		s.value = s.sum.Add(value).Div(decimal.NewFromInt(int64(s.period)))
+	} else {
+		s.value = value.Sub(s.value).Mul(s.k).Add(s.value)
+	}
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *EMAStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+// Value gets the current value of the indicator.
+func (s *EMAStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *EMAStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ready()
+}
+
+func (s *EMAStream) ready() bool {
+	return s.count >= s.period
+}
+
+// WMAStream is the streaming version of WMA.
+type WMAStream struct {
+	mutex     sync.RWMutex
+	weights   decimal.Decimal
+	period    decimal.Decimal
+	window    *window
+	total     decimal.Decimal // sum of the values in the window.
+	numerator decimal.Decimal // weighted sum of the values in the window.
+}
+
+// NewWMAStream creates a new streaming Weighted Moving Average over period values.
+func NewWMAStream(period int) (*WMAStream, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	return &WMAStream{
+		weights:   decimal.NewFromInt(int64(period * (period + 1) / 2)),
+		period:    decimal.NewFromInt(int64(period)),
+		window:    newWindow(period),
+		total:     decimal.Zero,
+		numerator: decimal.Zero,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *WMAStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if old, full := s.window.push(value); full {
+		// every value loses one weight, the oldest one goes out.
+		s.numerator = s.numerator.Add(value.Mul(s.period)).Sub(s.total)
+		s.total = s.total.Add(value).Sub(old)
+	} else {
+		s.numerator = s.numerator.Add(value.Mul(decimal.NewFromInt(int64(s.window.count))))
+		s.total = s.total.Add(value)
+	}
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *WMAStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+// Value gets the current value of the indicator.
+func (s *WMAStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !s.window.full() {
+		return decimal.Zero
+	}
+	return s.numerator.Div(s.weights)
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *WMAStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.window.full()
+}
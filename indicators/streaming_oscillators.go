@@ -0,0 +1,285 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// RSIStream is the streaming version of RSI.
+type RSIStream struct {
+	mutex    sync.RWMutex
+	period   int
+	length   decimal.Decimal
+	count    int
+	previous decimal.Decimal
+	avgGain  decimal.Decimal
+	avgLoss  decimal.Decimal
+}
+
+// NewRSIStream creates a new streaming Relative Strength Index over period values.
+func NewRSIStream(period int) (*RSIStream, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	return &RSIStream{
+		period:  period,
+		length:  decimal.NewFromInt(int64(period)),
+		avgGain: decimal.Zero,
+		avgLoss: decimal.Zero,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *RSIStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	previous := s.previous
+	s.previous = value
+	if s.count == 1 {
+		return
+	}
+
+	gain, loss := decimal.Zero, decimal.Zero
+	change := value.Sub(previous)
+	if change.IsPositive() {
+		gain = change
+	} else {
+		loss = change.Neg()
+	}
+
+	changes := s.count - 1
+	if changes < s.period {
+		s.avgGain = s.avgGain.Add(gain)
+		s.avgLoss = s.avgLoss.Add(loss)
+	} else if changes == s.period {
+		s.avgGain = s.avgGain.Add(gain).Div(s.length)
+		s.avgLoss = s.avgLoss.Add(loss).Div(s.length)
+	} else {
+		previousWeight := s.length.Sub(decimal.NewFromInt(1))
+		s.avgGain = s.avgGain.Mul(previousWeight).Add(gain).Div(s.length)
+		s.avgLoss = s.avgLoss.Mul(previousWeight).Add(loss).Div(s.length)
+	}
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *RSIStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+// Value gets the current value of the indicator.
+func (s *RSIStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !s.ready() {
+		return decimal.Zero
+	}
+	return rsiValue(s.avgGain, s.avgLoss)
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *RSIStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ready()
+}
+
+func (s *RSIStream) ready() bool {
+	return s.count > s.period
+}
+
+// MACDStream is the streaming version of MACD, its Value is the MACD line.
+type MACDStream struct {
+	mutex  sync.RWMutex
+	fast   *EMAStream
+	slow   *EMAStream
+	signal *EMAStream
+}
+
+// NewMACDStream creates a new streaming Moving Average Convergence Divergence.
+func NewMACDStream(fastPeriod int, slowPeriod int, signalPeriod int) (*MACDStream, error) {
+	if fastPeriod < 1 || signalPeriod < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	if fastPeriod >= slowPeriod {
+		return nil, errMACDPeriods
+	}
+
+	fast, _ := NewEMAStream(fastPeriod)
+	slow, _ := NewEMAStream(slowPeriod)
+	signal, _ := NewEMAStream(signalPeriod)
+	return &MACDStream{
+		fast:   fast,
+		slow:   slow,
+		signal: signal,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *MACDStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.fast.update(value)
+	s.slow.update(value)
+	if s.slow.ready() {
+		s.signal.update(s.macd())
+	}
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *MACDStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+func (s *MACDStream) macd() decimal.Decimal {
+	if !s.slow.ready() {
+		return decimal.Zero
+	}
+	return s.fast.value.Sub(s.slow.value)
+}
+
+// Value gets the current value of the MACD line.
+func (s *MACDStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.macd()
+}
+
+// Signal gets the current value of the signal line.
+func (s *MACDStream) Signal() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.signal.value
+}
+
+// Histogram gets the current value of MACD - signal.
+func (s *MACDStream) Histogram() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !s.signal.ready() {
+		return decimal.Zero
+	}
+	return s.macd().Sub(s.signal.value)
+}
+
+// Ready returns true when the warm-up period of the signal line is over.
+func (s *MACDStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.signal.ready()
+}
+
+// StochasticStream is the streaming version of Stochastic, its Value is %K.
+type StochasticStream struct {
+	mutex   sync.RWMutex
+	kPeriod int
+	count   int
+	highs   *extremes
+	lows    *extremes
+	k       decimal.Decimal
+	d       *SMAStream
+}
+
+// NewStochasticStream creates a new streaming Stochastic Oscillator.
+func NewStochasticStream(kPeriod int, dPeriod int) (*StochasticStream, error) {
+	if kPeriod < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	d, err := NewSMAStream(dPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return &StochasticStream{
+		kPeriod: kPeriod,
+		highs:   newExtremes(kPeriod, 1),
+		lows:    newExtremes(kPeriod, -1),
+		k:       decimal.Zero,
+		d:       d,
+	}, nil
+}
+
+// UpdateCandle adds a new closed candle to the series.
+func (s *StochasticStream) UpdateCandle(candle environment.CandleStick) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	s.highs.push(s.count, candle.High)
+	s.lows.push(s.count, candle.Low)
+	if s.count < s.kPeriod {
+		return
+	}
+
+	s.k = stochasticValue(candle.Close, s.highs.get(), s.lows.get())
+	s.d.update(s.k)
+}
+
+// Value gets the current value of %K.
+func (s *StochasticStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.k
+}
+
+// D gets the current value of %D.
+func (s *StochasticStream) D() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.d.value()
+}
+
+// Ready returns true when the warm-up period of %D is over.
+func (s *StochasticStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.d.window.full()
+}
+
+// extremes keeps the maximum (sign 1) or minimum (sign -1) of a sliding window
+// using a monotonic queue, in amortized O(1).
+type extremes struct {
+	size    int
+	sign    int
+	indexes []int
+	values  []decimal.Decimal
+}
+
+func newExtremes(size int, sign int) *extremes {
+	return &extremes{size: size, sign: sign}
+}
+
+func (e *extremes) push(index int, value decimal.Decimal) {
+	for len(e.values) > 0 && e.values[len(e.values)-1].Cmp(value)*e.sign <= 0 {
+		e.indexes = e.indexes[:len(e.indexes)-1]
+		e.values = e.values[:len(e.values)-1]
+	}
+	e.indexes = append(e.indexes, index)
+	e.values = append(e.values, value)
+	for e.indexes[0] <= index-e.size {
+		e.indexes = e.indexes[1:]
+		e.values = e.values[1:]
+	}
+}
+
+func (e *extremes) get() decimal.Decimal {
+	return e.values[0]
+}
@@ -0,0 +1,159 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// BollingerStream is the streaming version of BollingerBands, its Value is the middle band.
+type BollingerStream struct {
+	mutex      sync.RWMutex
+	middle     *SMAStream
+	squares    decimal.Decimal // sum of the squares of the values in the window.
+	multiplier decimal.Decimal
+}
+
+// NewBollingerStream creates new streaming Bollinger Bands over period values.
+func NewBollingerStream(period int, multiplier decimal.Decimal) (*BollingerStream, error) {
+	middle, err := NewSMAStream(period)
+	if err != nil {
+		return nil, err
+	}
+	return &BollingerStream{
+		middle:     middle,
+		squares:    decimal.Zero,
+		multiplier: multiplier,
+	}, nil
+}
+
+// Update adds a new value to the series.
+func (s *BollingerStream) Update(value decimal.Decimal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.squares = s.squares.Add(value.Mul(value))
+	if old, full := s.middle.update(value); full {
+		s.squares = s.squares.Sub(old.Mul(old))
+	}
+}
+
+// UpdateCandle adds the close of a new closed candle to the series.
+func (s *BollingerStream) UpdateCandle(candle environment.CandleStick) {
+	s.Update(candle.Close)
+}
+
+// width gets the distance of the bands from the middle one.
+func (s *BollingerStream) width() decimal.Decimal {
+	if !s.middle.window.full() {
+		return decimal.Zero
+	}
+	mean := s.middle.value()
+	variance := s.squares.Div(s.middle.period).Sub(mean.Mul(mean))
+	return sqrt(variance).Mul(s.multiplier)
+}
+
+// Value gets the current value of the middle band.
+func (s *BollingerStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.middle.value()
+}
+
+// Upper gets the current value of the upper band.
+func (s *BollingerStream) Upper() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.middle.value().Add(s.width())
+}
+
+// Lower gets the current value of the lower band.
+func (s *BollingerStream) Lower() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.middle.value().Sub(s.width())
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *BollingerStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.middle.window.full()
+}
+
+// ATRStream is the streaming version of ATR.
+type ATRStream struct {
+	mutex     sync.RWMutex
+	period    int
+	length    decimal.Decimal
+	count     int
+	prevClose decimal.Decimal
+	sum       decimal.Decimal
+	value     decimal.Decimal
+}
+
+// NewATRStream creates a new streaming Average True Range over period candles.
+func NewATRStream(period int) (*ATRStream, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	return &ATRStream{
+		period: period,
+		length: decimal.NewFromInt(int64(period)),
+		sum:    decimal.Zero,
+		value:  decimal.Zero,
+	}, nil
+}
+
+// UpdateCandle adds a new closed candle to the series.
+func (s *ATRStream) UpdateCandle(candle environment.CandleStick) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	prevClose := s.prevClose
+	s.prevClose = candle.Close
+	if s.count == 1 {
+		return
+	}
+
+	tr := TrueRange(candle, prevClose)
+	ranges := s.count - 1
+	if ranges < s.period {
+		s.sum = s.sum.Add(tr)
+	} else if ranges == s.period {
+		s.value = s.sum.Add(tr).Div(s.length)
+	} else {
+		s.value = s.value.Mul(s.length.Sub(decimal.NewFromInt(1))).Add(tr).Div(s.length)
+	}
+}
+
+// Value gets the current value of the indicator.
+func (s *ATRStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value
+}
+
+// Ready returns true when the warm-up period is over.
+func (s *ATRStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.count > s.period
+}
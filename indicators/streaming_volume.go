@@ -0,0 +1,115 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"sync"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// OBVStream is the streaming version of OBV.
+type OBVStream struct {
+	mutex     sync.RWMutex
+	count     int
+	prevClose decimal.Decimal
+	value     decimal.Decimal
+}
+
+// NewOBVStream creates a new streaming On Balance Volume.
+func NewOBVStream() *OBVStream {
+	return &OBVStream{value: decimal.Zero}
+}
+
+// UpdateCandle adds a new closed candle to the series.
+func (s *OBVStream) UpdateCandle(candle environment.CandleStick) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	if s.count > 1 {
+		switch candle.Close.Cmp(s.prevClose) {
+		case 1:
+			s.value = s.value.Add(candle.Volume)
+		case -1:
+			s.value = s.value.Sub(candle.Volume)
+		}
+	}
+	s.prevClose = candle.Close
+}
+
+// Value gets the current value of the indicator.
+func (s *OBVStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value
+}
+
+// Ready returns true when at least a candle has been added.
+func (s *OBVStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.count > 0
+}
+
+// VWAPStream is the streaming version of VWAP.
+type VWAPStream struct {
+	mutex       sync.RWMutex
+	count       int
+	totalValue  decimal.Decimal
+	totalVolume decimal.Decimal
+	value       decimal.Decimal
+}
+
+// NewVWAPStream creates a new streaming Volume Weighted Average Price.
+func NewVWAPStream() *VWAPStream {
+	return &VWAPStream{
+		totalValue:  decimal.Zero,
+		totalVolume: decimal.Zero,
+		value:       decimal.Zero,
+	}
+}
+
+// UpdateCandle adds a new closed candle to the series.
+func (s *VWAPStream) UpdateCandle(candle environment.CandleStick) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.count++
+	typical := TypicalPrice(candle)
+	s.totalValue = s.totalValue.Add(typical.Mul(candle.Volume))
+	s.totalVolume = s.totalVolume.Add(candle.Volume)
+	if s.totalVolume.IsZero() {
+		s.value = typical
+	} else {
+		s.value = s.totalValue.Div(s.totalVolume)
+	}
+}
+
+// Value gets the current value of the indicator.
+func (s *VWAPStream) Value() decimal.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.value
+}
+
+// Ready returns true when at least a candle has been added.
+func (s *VWAPStream) Ready() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.count > 0
+}
@@ -0,0 +1,82 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// BollingerBands computes the Bollinger Bands of the close prices: the middle band is the SMA over
+// period candles, the upper and lower bands are at multiplier standard deviations from it.
+func BollingerBands(candles []environment.CandleStick, period int, multiplier decimal.Decimal) (middle []decimal.Decimal, upper []decimal.Decimal, lower []decimal.Decimal, err error) {
+	if err := checkPeriod(len(candles), period); err != nil {
+		return nil, nil, nil, err
+	}
+
+	closes := Closes(candles)
+	middle = sma(closes, 0, period)
+	upper = zeros(len(candles))
+	lower = zeros(len(candles))
+	length := decimal.NewFromInt(int64(period))
+	for i := period - 1; i < len(candles); i++ {
+		variance := decimal.Zero
+		for j := i - period + 1; j <= i; j++ {
+			deviation := closes[j].Sub(middle[i])
+			variance = variance.Add(deviation.Mul(deviation))
+		}
+		width := sqrt(variance.Div(length)).Mul(multiplier)
+		upper[i] = middle[i].Add(width)
+		lower[i] = middle[i].Sub(width)
+	}
+	return middle, upper, lower, nil
+}
+
+// TrueRange computes the true range of a candle, given the close of the previous one.
+func TrueRange(candle environment.CandleStick, previousClose decimal.Decimal) decimal.Decimal {
+	return decimal.Max(
+		candle.High.Sub(candle.Low),
+		candle.High.Sub(previousClose).Abs(),
+		candle.Low.Sub(previousClose).Abs(),
+	)
+}
+
+// ATR computes the Average True Range over period candles, using Wilder's smoothing.
+//
+//     The first value is at index period, as the true range needs the previous close.
+func ATR(candles []environment.CandleStick, period int) ([]decimal.Decimal, error) {
+	if period < 1 {
+		return nil, ErrInvalidPeriod
+	}
+	if err := checkPeriod(len(candles), period+1); err != nil {
+		return nil, err
+	}
+
+	ret := zeros(len(candles))
+	length := decimal.NewFromInt(int64(period))
+	sum := decimal.Zero
+	for i := 1; i < len(candles); i++ {
+		tr := TrueRange(candles[i], candles[i-1].Close)
+		if i < period {
+			sum = sum.Add(tr)
+		} else if i == period {
+			ret[i] = sum.Add(tr).Div(length)
+		} else {
+			ret[i] = ret[i-1].Mul(length.Sub(decimal.NewFromInt(1))).Add(tr).Div(length)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,56 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package indicators
+
+import (
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/shopspring/decimal"
+)
+
+// OBV computes the On Balance Volume, starting from zero at the first candle.
+func OBV(candles []environment.CandleStick) []decimal.Decimal {
+	ret := zeros(len(candles))
+	for i := 1; i < len(candles); i++ {
+		switch candles[i].Close.Cmp(candles[i-1].Close) {
+		case 1:
+			ret[i] = ret[i-1].Add(candles[i].Volume)
+		case -1:
+			ret[i] = ret[i-1].Sub(candles[i].Volume)
+		default:
+			ret[i] = ret[i-1]
+		}
+	}
+	return ret
+}
+
+// VWAP computes the cumulative Volume Weighted Average Price of the typical prices, from the first candle.
+//
+//     While the cumulated volume is zero the typical price is used.
+func VWAP(candles []environment.CandleStick) []decimal.Decimal {
+	ret := zeros(len(candles))
+	totalValue, totalVolume := decimal.Zero, decimal.Zero
+	for i, candle := range candles {
+		typical := TypicalPrice(candle)
+		totalValue = totalValue.Add(typical.Mul(candle.Volume))
+		totalVolume = totalVolume.Add(candle.Volume)
+		if totalVolume.IsZero() {
+			ret[i] = typical
+		} else {
+			ret[i] = totalValue.Div(totalVolume)
+		}
+	}
+	return ret
+}
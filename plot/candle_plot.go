@@ -18,6 +18,7 @@ package plot
 import (
 	"image/color"
 	"math"
+	"time"
 
 	"github.com/openacid/slim/polyfit"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
@@ -100,7 +101,7 @@ func (sticks *CandleSticks) Plot(c draw.Canvas, plt *plot.Plot) {
 		}
 		// Transform the data
 		// to the corresponding drawing coordinate.
-		x := trX(float64(i)) //TODO compute stick time
+		x := trX(candleX(sticks.candles, i))
 		high, _ := candle.High.Float64()
 		yh := trY(high)
 		low, _ := candle.Low.Float64()
@@ -111,20 +112,41 @@ func (sticks *CandleSticks) Plot(c draw.Canvas, plt *plot.Plot) {
 		yminoc := trY(minoc)
 
 		// top stick
-		line := c.ClipLinesY([]vg.Point{{x, yh}, {x, ymaxoc}})
+		line := c.ClipLinesY([]vg.Point{{X: x, Y: yh}, {X: x, Y: ymaxoc}})
 		c.StrokeLines(lineStyle, line...)
 
 		// bottom stick
-		line = c.ClipLinesY([]vg.Point{{x, yl}, {x, yminoc}})
+		line = c.ClipLinesY([]vg.Point{{X: x, Y: yl}, {X: x, Y: yminoc}})
 		c.StrokeLines(lineStyle, line...)
 
 		// body
-		poly := c.ClipPolygonY([]vg.Point{{x - sticks.CandleWidth/2, ymaxoc}, {x + sticks.CandleWidth/2, ymaxoc}, {x + sticks.CandleWidth/2, yminoc}, {x - sticks.CandleWidth/2, yminoc}, {x - sticks.CandleWidth/2, ymaxoc}})
+		poly := c.ClipPolygonY([]vg.Point{
+			{X: x - sticks.CandleWidth/2, Y: ymaxoc},
+			{X: x + sticks.CandleWidth/2, Y: ymaxoc},
+			{X: x + sticks.CandleWidth/2, Y: yminoc},
+			{X: x - sticks.CandleWidth/2, Y: yminoc},
+			{X: x - sticks.CandleWidth/2, Y: ymaxoc},
+		})
 		c.FillPolygon(fillColor, poly)
 		c.StrokeLines(lineStyle, poly)
 	}
 }
 
+// candleX gets the position of a candle on the X axis: the middle of its period
+// in unix seconds if the candle has timestamps, its index otherwise.
+func candleX(candles []environment.CandleStick, i int) float64 {
+	candle := candles[i]
+	if candle.OpenTime.IsZero() {
+		return float64(i)
+	}
+
+	middle := candle.OpenTime
+	if candle.CloseTime.After(candle.OpenTime) {
+		middle = middle.Add(candle.CloseTime.Sub(candle.OpenTime) / 2)
+	}
+	return float64(middle.UnixNano()) / float64(time.Second)
+}
+
 // DataRange implements the DataRange method
 // of the plot.DataRanger interface.
 func (sticks *CandleSticks) DataRange() (xMin, xMax, yMin, yMax float64) {
@@ -133,8 +155,9 @@ func (sticks *CandleSticks) DataRange() (xMin, xMax, yMin, yMax float64) {
 	yMin = math.Inf(1)
 	yMax = math.Inf(-1)
 	for i, candle := range sticks.candles {
-		xMin = math.Min(xMin, float64(i)) //TODO compute stick time
-		xMax = math.Max(xMax, float64(i))
+		x := candleX(sticks.candles, i)
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
 		low, _ := candle.Low.Float64()
 		yMin = math.Min(yMin, low)
 		high, _ := candle.High.Float64()
@@ -178,6 +178,9 @@ func (csc CandleStickChart) ExportPng(fileName string) error {
 		return err
 	}
 	p.Add(candleSticks)
+	if len(csc.CandleSticks) > 0 && !csc.CandleSticks[0].OpenTime.IsZero() {
+		p.X.Tick.Marker = pl.TimeTicks{Format: "2006-01-02\n15:04"}
+	}
 
 	// Draw support/resistance prices
 	support := csc.GetSupportPrices(0.01)
@@ -185,7 +188,7 @@ func (csc CandleStickChart) ExportPng(fileName string) error {
 	for i := 0; i < len(support); i++ {
 		if support[i].Weight.GreaterThanOrEqual(decimal.NewFromInt(3)) {
 			value, _ := support[i].Value.Float64()
-			sline, err := plotter.NewLine(HorizontalLine(len(csc.CandleSticks), value))
+			sline, err := plotter.NewLine(csc.onTimeAxis(HorizontalLine(len(csc.CandleSticks), value)))
 			if err != nil {
 				return err
 			}
@@ -213,7 +216,7 @@ func (csc CandleStickChart) ExportPng(fileName string) error {
 		cpts[i].Y, _ = c.Y.Float64()
 		// }
 	}
-	criticalLine, points, err := plotter.NewLinePoints(cpts)
+	criticalLine, points, err := plotter.NewLinePoints(csc.onTimeAxis(cpts))
 	if err != nil {
 		return err
 	}
@@ -230,8 +233,8 @@ func (csc CandleStickChart) ExportPng(fileName string) error {
 	// plotutil.AddLinePoints(p, mpts)
 
 	plotutil.AddLines(p,
-		"Trend Line", csc.GetTrendLine(),
-		"Elliottt Wave", csc.GetElliottWaveModel())
+		"Trend Line", csc.onTimeAxis(csc.GetTrendLine()),
+		"Elliottt Wave", csc.onTimeAxis(csc.GetElliottWaveModel()))
 
 	err = p.Save(1024, 768, fileName)
 	if err != nil {
@@ -284,6 +287,18 @@ func (csc CandleStickChart) GetElliottWaveModel() plotter.XYs {
 	return pts
 }
 
+// onTimeAxis moves points having candle indexes as X to the position of the candles on the time axis.
+func (csc CandleStickChart) onTimeAxis(pts plotter.XYs) plotter.XYs {
+	ret := make(plotter.XYs, len(pts))
+	for i, pt := range pts {
+		ret[i] = pt
+		if index := int(pt.X); index >= 0 && index < len(csc.CandleSticks) {
+			ret[i].X = candleX(csc.CandleSticks, index)
+		}
+	}
+	return ret
+}
+
 func HorizontalLine(n int, h float64) plotter.XYs {
 	pts := make(plotter.XYs, n)
 	for i := range pts {
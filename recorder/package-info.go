@@ -0,0 +1,27 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+//Package recorder contains the tools to record the market data got from the exchange feeds.
+//
+//     Events are written to rotating files named events-YYYYMMDDTHHMMSSZ.jsonl (the start of the
+//     rotation period, UTC) in JSON lines format, one event per line:
+//
+//     {"time":"<RFC3339 receive time>","exchange":"binance","market":"ETH-BTC","type":"summary","summary":{...}}
+//     {"time":"...","exchange":"binance","market":"ETH-BTC","type":"orderbook","orderbook":{"asks":[...],"bids":[...]}}
+//     {"time":"...","exchange":"binance","market":"ETH-BTC","type":"trade","trade":{"id":"1","price":"0.03","quantity":"2","side":1,"timestamp":"..."}}
+//
+//     Order book events contain the whole book after the update, the side of trades is the one
+//     of the taker (1 for a buy, 0 for a sell).
+package recorder
@@ -0,0 +1,108 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LoadEvents loads the events recorded in a file or in all the files of a directory, keeping only
+// the ones received in the [from, to) time range (a zero time means no bound), sorted by time.
+func LoadEvents(path string, from time.Time, to time.Time) ([]Event, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = paths[:0]
+		for _, info := range infos {
+			if !info.IsDir() && strings.HasPrefix(info.Name(), "events-") && filepath.Ext(info.Name()) == ".jsonl" {
+				paths = append(paths, filepath.Join(path, info.Name()))
+			}
+		}
+	}
+
+	var ret []Event
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		events, err := ReadEvents(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+
+		for _, event := range events {
+			if (from.IsZero() || !event.Time.Before(from)) && (to.IsZero() || event.Time.Before(to)) {
+				ret = append(ret, event)
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Time.Before(ret[j].Time)
+	})
+	return ret, nil
+}
+
+// ReadEvents reads recorded events in JSON lines format.
+//
+//     A partially written last line (e.g. after a crash) is ignored.
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var ret []Event
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(strings.TrimSpace(string(data))) > 0 {
+				var event Event
+				if json.Unmarshal(data, &event) == nil {
+					ret = append(ret, event)
+				}
+			}
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		ret = append(ret, event)
+	}
+}
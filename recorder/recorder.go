@@ -0,0 +1,192 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package recorder
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/clock"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/sirupsen/logrus"
+)
+
+// EventType represents the kind of market data of an event.
+type EventType string
+
+const (
+	// SummaryEvent represents a market summary update.
+	SummaryEvent EventType = "summary"
+	// OrderBookEvent represents an order book update.
+	OrderBookEvent EventType = "orderbook"
+	// TradeEvent represents a public trade.
+	TradeEvent EventType = "trade"
+)
+
+// Event represents a market data update got from an exchange feed.
+type Event struct {
+	Time      time.Time                  `json:"time"`                // Represents the time the update has been received.
+	Exchange  string                     `json:"exchange"`            // Represents the name of the exchange.
+	Market    string                     `json:"market"`              // Represents the canonical name of the market (e.g. ETH-BTC).
+	Type      EventType                  `json:"type"`                // Represents the kind of update.
+	Summary   *environment.MarketSummary `json:"summary,omitempty"`   // Set for summary events.
+	OrderBook *environment.OrderBook     `json:"orderbook,omitempty"` // Set for order book events.
+	Trade     *environment.Trade         `json:"trade,omitempty"`     // Set for trade events.
+}
+
+// fileNameFormat is the format of the start of the rotation period in the names of the files.
+const fileNameFormat = "20060102T150405Z"
+
+// Recorder writes market data events to files rotated every fixed period, safe for concurrent use.
+type Recorder struct {
+	dir      string
+	rotation time.Duration
+
+	mutex   *sync.Mutex
+	clock   clock.Clock // Clock timestamping the events received by the listeners.
+	file    *os.File
+	encoder *json.Encoder
+	period  time.Time // Start of the rotation period of the current file.
+}
+
+// NewRecorder creates a new recorder writing to the specified directory, creating it if needed,
+// starting a new file every rotation period.
+func NewRecorder(dir string, rotation time.Duration) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if rotation <= 0 {
+		rotation = time.Hour
+	}
+	return &Recorder{
+		dir:      dir,
+		rotation: rotation,
+		mutex:    &sync.Mutex{},
+		clock:    clock.Real{},
+	}, nil
+}
+
+// SetClock sets the clock timestamping the events received by the listeners, nil restores the wall clock.
+func (recorder *Recorder) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real{}
+	}
+	recorder.mutex.Lock()
+	recorder.clock = c
+	recorder.mutex.Unlock()
+}
+
+// now gets the current time of the clock of the recorder.
+func (recorder *Recorder) now() time.Time {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return recorder.clock.Now()
+}
+
+// Record writes an event, rotating the file if its period is over.
+func (recorder *Recorder) Record(event Event) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	period := event.Time.UTC().Truncate(recorder.rotation)
+	if recorder.file == nil || !period.Equal(recorder.period) {
+		if err := recorder.rotate(period); err != nil {
+			return err
+		}
+	}
+	return recorder.encoder.Encode(event)
+}
+
+// rotate closes the current file and opens the one of the specified period.
+func (recorder *Recorder) rotate(period time.Time) error {
+	if err := recorder.closeFile(); err != nil {
+		return err
+	}
+
+	name := filepath.Join(recorder.dir, "events-"+period.Format(fileNameFormat)+".jsonl")
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	recorder.file = file
+	recorder.encoder = json.NewEncoder(file)
+	recorder.period = period
+	return nil
+}
+
+// closeFile closes the current file, if any.
+func (recorder *Recorder) closeFile() error {
+	if recorder.file == nil {
+		return nil
+	}
+	err := recorder.file.Close()
+	recorder.file = nil
+	recorder.encoder = nil
+	return err
+}
+
+// Close closes the recorder, events recorded afterwards open a new file.
+func (recorder *Recorder) Close() error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.closeFile()
+}
+
+// Listener gets a feed listener recording the updates of the markets of an exchange.
+//
+//     Markets are recorded by name, so the listener must be added to markets used only with that exchange.
+func (recorder *Recorder) Listener(exchange string) exchanges.FeedListener {
+	return exchangeListener{
+		recorder: recorder,
+		exchange: exchange,
+	}
+}
+
+// exchangeListener is a feed listener recording the updates of the markets of an exchange.
+type exchangeListener struct {
+	recorder *Recorder
+	exchange string
+}
+
+// record records an event of a market, logging errors as listeners cannot return them.
+func (listener exchangeListener) record(market *environment.Market, event Event) {
+	event.Time = listener.recorder.now()
+	event.Exchange = listener.exchange
+	event.Market = market.Name
+	if err := listener.recorder.Record(event); err != nil {
+		logrus.Errorf("Cannot record %s event of %s on %s: %s", event.Type, market.Name, listener.exchange, err)
+	}
+}
+
+// OnSummary records a market summary update.
+func (listener exchangeListener) OnSummary(market *environment.Market, summary environment.MarketSummary) {
+	listener.record(market, Event{Type: SummaryEvent, Summary: &summary})
+}
+
+// OnOrderBook records an order book update.
+func (listener exchangeListener) OnOrderBook(market *environment.Market, book environment.OrderBook) {
+	listener.record(market, Event{Type: OrderBookEvent, OrderBook: &book})
+}
+
+// OnTrade records a public trade.
+func (listener exchangeListener) OnTrade(market *environment.Market, trade environment.Trade) {
+	listener.record(market, Event{Type: TradeEvent, Trade: &trade})
+}
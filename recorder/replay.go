@@ -0,0 +1,384 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package recorder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/clock"
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/shopspring/decimal"
+)
+
+// errOrdersNotSupported is returned by the order related functions of ReplayWrapper,
+// orders must be routed through an ExchangeWrapperSimulator.
+var errOrdersNotSupported = fmt.Errorf("%w: orders on recorded data, use an ExchangeWrapperSimulator", exchanges.ErrNotSupported)
+
+// ReplayWrapper is an ExchangeWrapper serving the market data recorded from an exchange,
+// exposing only the events received up to the current time of a virtual clock.
+type ReplayWrapper struct {
+	exchange string
+	events   []Event
+	next     int         // Index of the next event to apply.
+	now      time.Time   // Current time of the virtual clock.
+	speed    float64     // Speed of the virtual clock compared to real time, zero for as fast as possible.
+	realTime clock.Clock // Clock waited on when sleeping at a non zero speed.
+
+	markets    map[string]*environment.Market // Markets of all the recorded events, by name.
+	summaries  map[string]*environment.MarketSummary
+	orderbooks map[string]*environment.OrderBook
+	ticks      map[string][]environment.CandleStick // Trades (or summaries if no trade is recorded) as single tick candles.
+	hasTrades  map[string]bool
+
+	makerFee float64 // Fee of maker trades, as a fraction of the total.
+	takerFee float64 // Fee of taker trades, as a fraction of the total.
+}
+
+// NewReplayWrapper creates a new wrapper serving the events recorded from the specified exchange,
+// with the virtual clock at the time of the first event.
+//
+//     Events must be sorted by time, the ones of other exchanges are ignored.
+func NewReplayWrapper(exchange string, events []Event) *ReplayWrapper {
+	wrapper := &ReplayWrapper{
+		exchange:   exchange,
+		realTime:   clock.Real{},
+		markets:    make(map[string]*environment.Market),
+		summaries:  make(map[string]*environment.MarketSummary),
+		orderbooks: make(map[string]*environment.OrderBook),
+		ticks:      make(map[string][]environment.CandleStick),
+		hasTrades:  make(map[string]bool),
+	}
+	for _, event := range events {
+		if event.Exchange != exchange {
+			continue
+		}
+		wrapper.events = append(wrapper.events, event)
+
+		if _, exists := wrapper.markets[event.Market]; !exists {
+			market := &environment.Market{
+				Name:          event.Market,
+				ExchangeNames: map[string]string{exchange: event.Market},
+			}
+			if baseCurrency, marketCurrency, err := environment.ParseMarketName(event.Market); err == nil {
+				market.BaseCurrency = baseCurrency
+				market.MarketCurrency = marketCurrency
+			}
+			wrapper.markets[event.Market] = market
+		}
+	}
+
+	if len(wrapper.events) > 0 {
+		wrapper.advanceTo(wrapper.events[0].Time)
+	}
+	return wrapper
+}
+
+// Name gets the name of the recorded exchange.
+func (wrapper *ReplayWrapper) Name() string {
+	return wrapper.exchange
+}
+
+// String returns a string representation of the object.
+func (wrapper *ReplayWrapper) String() string {
+	return "replay of " + wrapper.exchange
+}
+
+// Capabilities gets the features supported by the exchange: orders must be routed through an ExchangeWrapperSimulator.
+func (wrapper *ReplayWrapper) Capabilities() exchanges.Capabilities {
+	return exchanges.Capabilities{
+		Candles:   true,
+		OrderBook: true,
+	}
+}
+
+// SetSpeed sets the speed of the virtual clock when sleeping: 1 for real time, N for N times faster,
+// 0 for as fast as possible (the default).
+func (wrapper *ReplayWrapper) SetSpeed(speed float64) {
+	wrapper.speed = speed
+}
+
+// SetRealTimeClock sets the clock waited on when sleeping at a non zero speed, nil restores the wall clock.
+func (wrapper *ReplayWrapper) SetRealTimeClock(c clock.Clock) {
+	if c == nil {
+		c = clock.Real{}
+	}
+	wrapper.realTime = c
+}
+
+// Now gets the current time of the virtual clock.
+func (wrapper *ReplayWrapper) Now() time.Time {
+	return wrapper.now
+}
+
+// Done returns true if all the recorded events have been applied.
+func (wrapper *ReplayWrapper) Done() bool {
+	return wrapper.next >= len(wrapper.events)
+}
+
+// Advance moves the virtual clock to the next recorded event, without sleeping,
+// returns false when the events are over.
+func (wrapper *ReplayWrapper) Advance() bool {
+	if wrapper.Done() {
+		return false
+	}
+	wrapper.advanceTo(wrapper.events[wrapper.next].Time)
+	return true
+}
+
+// Sleep moves the virtual clock forward by the specified duration, applying the events received
+// in the meantime and sleeping according to the speed of the clock.
+func (wrapper *ReplayWrapper) Sleep(d time.Duration) {
+	if wrapper.speed > 0 {
+		wrapper.realTime.Sleep(time.Duration(float64(d) / wrapper.speed))
+	}
+	wrapper.advanceTo(wrapper.now.Add(d))
+}
+
+// After moves the virtual clock as Sleep does and returns a channel holding the new time.
+func (wrapper *ReplayWrapper) After(d time.Duration) <-chan time.Time {
+	wrapper.Sleep(d)
+	ret := make(chan time.Time, 1)
+	ret <- wrapper.now
+	return ret
+}
+
+// advanceTo moves the virtual clock to the specified time, applying the events received up to it.
+func (wrapper *ReplayWrapper) advanceTo(t time.Time) {
+	for ; wrapper.next < len(wrapper.events) && !wrapper.events[wrapper.next].Time.After(t); wrapper.next++ {
+		wrapper.apply(wrapper.events[wrapper.next])
+	}
+	if t.After(wrapper.now) {
+		wrapper.now = t
+	}
+}
+
+// apply updates the market data served by the wrapper with an event.
+func (wrapper *ReplayWrapper) apply(event Event) {
+	switch event.Type {
+	case SummaryEvent:
+		if event.Summary == nil {
+			return
+		}
+		wrapper.summaries[event.Market] = event.Summary
+		if !wrapper.hasTrades[event.Market] && event.Summary.Last.IsPositive() {
+			wrapper.addTick(event.Market, event.Time, event.Summary.Last, decimal.Zero)
+		}
+	case OrderBookEvent:
+		if event.OrderBook != nil {
+			wrapper.orderbooks[event.Market] = event.OrderBook
+		}
+	case TradeEvent:
+		if event.Trade == nil {
+			return
+		}
+		if !wrapper.hasTrades[event.Market] {
+			wrapper.hasTrades[event.Market] = true
+			wrapper.ticks[event.Market] = nil // trades replace summaries as the source of candles.
+		}
+		tradeTime := event.Trade.Timestamp
+		if tradeTime.IsZero() {
+			tradeTime = event.Time
+		}
+		wrapper.addTick(event.Market, tradeTime, event.Trade.Price, event.Trade.Quantity)
+	}
+}
+
+// addTick adds a price to the source of the candles of a market.
+func (wrapper *ReplayWrapper) addTick(market string, t time.Time, price decimal.Decimal, volume decimal.Decimal) {
+	tradeCount := int64(0)
+	if volume.IsPositive() {
+		tradeCount = 1
+	}
+	wrapper.ticks[market] = append(wrapper.ticks[market], environment.CandleStick{
+		High:       price,
+		Open:       price,
+		Close:      price,
+		Low:        price,
+		Volume:     volume,
+		OpenTime:   t,
+		CloseTime:  t,
+		TradeCount: tradeCount,
+	})
+}
+
+// GetMarkets gets the recorded markets, sorted by name.
+func (wrapper *ReplayWrapper) GetMarkets() ([]*environment.Market, error) {
+	ret := make([]*environment.Market, 0, len(wrapper.markets))
+	for _, market := range wrapper.markets {
+		ret = append(ret, market)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret, nil
+}
+
+// GetListPriceChangeStats is not supported on recorded data.
+func (wrapper *ReplayWrapper) GetListPriceChangeStats() (environment.ListPriceChangeStats, error) {
+	return nil, fmt.Errorf("%w: price change stats on recorded data", exchanges.ErrNotSupported)
+}
+
+// GetCandles gets the candles built from the trades recorded up to the current virtual time,
+// or from the last prices of the summaries if no trade has been recorded.
+func (wrapper *ReplayWrapper) GetCandles(market *environment.Market, interval environment.Interval) ([]environment.CandleStick, error) {
+	ticks, exists := wrapper.ticks[market.Name]
+	if !exists {
+		return nil, errors.New("No candle data yet")
+	}
+	return environment.ResampleCandles(ticks, interval)
+}
+
+// GetCandlesRange gets the candles opening in the [from, to) time range, up to the current virtual time.
+func (wrapper *ReplayWrapper) GetCandlesRange(market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	candles, err := wrapper.GetCandles(market, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.CandleStick, 0, len(candles))
+	for _, candle := range candles {
+		if !candle.OpenTime.Before(from) && candle.OpenTime.Before(to) {
+			ret = append(ret, candle)
+		}
+	}
+	return ret, nil
+}
+
+// GetTradingRules gets the constraints on the orders of a market: there are none on recorded data.
+func (wrapper *ReplayWrapper) GetTradingRules(market *environment.Market) (environment.TradingRules, error) {
+	return environment.TradingRules{}, nil
+}
+
+// GetMarketSummary gets the last market summary recorded up to the current virtual time.
+func (wrapper *ReplayWrapper) GetMarketSummary(market *environment.Market) (*environment.MarketSummary, error) {
+	summary, exists := wrapper.summaries[market.Name]
+	if !exists {
+		return nil, errors.New("Summary not loaded")
+	}
+	ret := *summary
+	return &ret, nil
+}
+
+// GetOrderBook gets the last order book recorded up to the current virtual time.
+func (wrapper *ReplayWrapper) GetOrderBook(market *environment.Market) (*environment.OrderBook, error) {
+	book, exists := wrapper.orderbooks[market.Name]
+	if !exists {
+		return nil, fmt.Errorf("No order book recorded for market %s", market.Name)
+	}
+	return &environment.OrderBook{
+		Asks: append([]environment.Order(nil), book.Asks...),
+		Bids: append([]environment.Order(nil), book.Bids...),
+	}, nil
+}
+
+// GetRecentTrades is not supported on recorded data.
+func (wrapper *ReplayWrapper) GetRecentTrades(market *environment.Market) ([]environment.Trade, error) {
+	return nil, fmt.Errorf("%w: public trades on recorded data", exchanges.ErrNotSupported)
+}
+
+// BuyLimit is not supported on recorded data.
+func (wrapper *ReplayWrapper) BuyLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// SellLimit is not supported on recorded data.
+func (wrapper *ReplayWrapper) SellLimit(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// BuyMarket is not supported on recorded data.
+func (wrapper *ReplayWrapper) BuyMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// SellMarket is not supported on recorded data.
+func (wrapper *ReplayWrapper) SellMarket(market *environment.Market, amount decimal.Decimal) (string, error) {
+	return "", errOrdersNotSupported
+}
+
+// CancelOrder is not supported on recorded data.
+func (wrapper *ReplayWrapper) CancelOrder(market *environment.Market, orderID string) error {
+	return errOrdersNotSupported
+}
+
+// GetOrder is not supported on recorded data.
+func (wrapper *ReplayWrapper) GetOrder(market *environment.Market, orderID string) (*environment.OrderInfo, error) {
+	return nil, errOrdersNotSupported
+}
+
+// GetOpenOrders is not supported on recorded data.
+func (wrapper *ReplayWrapper) GetOpenOrders(market *environment.Market) ([]*environment.OrderInfo, error) {
+	return nil, errOrdersNotSupported
+}
+
+// SetFees sets the trading fees of maker and taker trades, as fractions of the total (e.g. 0.001 for 0.1%).
+func (wrapper *ReplayWrapper) SetFees(makerFee float64, takerFee float64) {
+	wrapper.makerFee = makerFee
+	wrapper.takerFee = takerFee
+}
+
+// CalculateTradingFees calculates the trading fees for an order on a specified market.
+func (wrapper *ReplayWrapper) CalculateTradingFees(market *environment.Market, amount decimal.Decimal, limit decimal.Decimal, orderType exchanges.TradeType) decimal.Decimal {
+	var feePercentage float64
+	if orderType == exchanges.MakerTrade {
+		feePercentage = wrapper.makerFee
+	} else if orderType == exchanges.TakerTrade {
+		feePercentage = wrapper.takerFee
+	} else {
+		panic("Unknown trade type")
+	}
+
+	return amount.Mul(limit).Mul(decimal.NewFromFloat(feePercentage))
+}
+
+// CalculateWithdrawFees calculates the withdrawal fees on a specified market.
+func (wrapper *ReplayWrapper) CalculateWithdrawFees(market *environment.Market, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, fmt.Errorf("%w: withdraw fees on recorded data", exchanges.ErrNotSupported)
+}
+
+// GetBalance is not supported on recorded data.
+func (wrapper *ReplayWrapper) GetBalance(symbol string) (*decimal.Decimal, error) {
+	return nil, fmt.Errorf("%w: balances on recorded data", exchanges.ErrNotSupported)
+}
+
+// GetDepositAddress gets the deposit address for the specified coin on the exchange.
+func (wrapper *ReplayWrapper) GetDepositAddress(coinTicker string) (string, bool) {
+	return "", false
+}
+
+// FeedConnect connects to the feed of the exchange.
+func (wrapper *ReplayWrapper) FeedConnect(markets []*environment.Market) error {
+	return exchanges.ErrWebsocketNotSupported
+}
+
+// FeedDisconnect disconnects from the feed of the exchange.
+func (wrapper *ReplayWrapper) FeedDisconnect() error {
+	return exchanges.ErrWebsocketNotSupported
+}
+
+// SubscribeTrades is not supported on recorded data.
+func (wrapper *ReplayWrapper) SubscribeTrades(market *environment.Market) (*exchanges.TradeSubscription, error) {
+	return nil, exchanges.ErrWebsocketNotSupported
+}
+
+// Withdraw is not supported on recorded data.
+func (wrapper *ReplayWrapper) Withdraw(destinationAddress string, coinTicker string, amount decimal.Decimal) error {
+	return fmt.Errorf("%w: withdrawals on recorded data", exchanges.ErrNotSupported)
+}
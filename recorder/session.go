@@ -0,0 +1,72 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package recorder
+
+import (
+	"context"
+	"errors"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+	"github.com/saniales/golang-crypto-trading-bot/strategies"
+	"github.com/shopspring/decimal"
+)
+
+// Run re-runs an interval strategy against the recorded session: OnUpdate is called once every
+// Interval of virtual time, sleeping according to the speed of the clock, until the events are over.
+//
+//     Orders are routed through an ExchangeWrapperSimulator with the specified balances, which is
+//     returned to inspect orders and balances. As in IntervalStrategy.Apply, the run stops at the first error.
+func (wrapper *ReplayWrapper) Run(strategy strategies.IntervalStrategy, markets []*environment.Market, balances map[string]decimal.Decimal) (*exchanges.ExchangeWrapperSimulator, error) {
+	model := strategy.Model
+	if model.OnUpdate == nil {
+		return nil, errors.New("OnUpdate func cannot be empty")
+	}
+	if strategy.Interval <= 0 {
+		return nil, errors.New("Cannot replay a strategy without interval")
+	}
+
+	simulator := exchanges.NewExchangeWrapperSimulator(wrapper, balances)
+	simulator.SetClock(wrapper)
+	wrappers := []exchanges.ExchangeWrapper{simulator}
+
+	handleError := func(err error) {
+		if err != nil && model.OnError != nil {
+			model.OnError(err)
+		}
+	}
+
+	var err error
+	if model.Setup != nil {
+		err = model.Setup(context.Background(), wrappers, markets)
+		handleError(err)
+	}
+
+	for err == nil {
+		err = model.OnUpdate(context.Background(), wrappers, markets)
+		handleError(err)
+		if wrapper.Done() {
+			break
+		}
+		wrapper.Sleep(strategy.Interval)
+	}
+
+	if model.TearDown != nil {
+		handleError(model.TearDown(context.Background(), wrappers, markets))
+	}
+
+	return simulator, err
+}
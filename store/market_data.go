@@ -0,0 +1,164 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/saniales/golang-crypto-trading-bot/environment"
+	"github.com/saniales/golang-crypto-trading-bot/exchanges"
+)
+
+// SummarySnapshot represents a market summary saved at a point in time.
+type SummarySnapshot struct {
+	Time    time.Time                 // Represents the time the summary was got.
+	Summary environment.MarketSummary // Represents the market summary.
+}
+
+// OrderBookSnapshot represents an order book saved at a point in time.
+type OrderBookSnapshot struct {
+	Time      time.Time             // Represents the time the order book was got.
+	OrderBook environment.OrderBook // Represents the order book.
+}
+
+// candlesSeries gets the name of the data series containing the candles of an interval.
+func candlesSeries(interval environment.Interval) string {
+	return "candles_" + interval.String()
+}
+
+// summariesSeries is the name of the data series containing the market summaries.
+const summariesSeries = "summaries"
+
+// orderBooksSeries is the name of the data series containing the order book snapshots.
+const orderBooksSeries = "orderbooks"
+
+// AppendCandles appends candles of a market to the store, replacing the ones with the same open time.
+//
+//     Candles must have the open time.
+func (s *Store) AppendCandles(exchange string, market *environment.Market, interval environment.Interval, candles []environment.CandleStick) error {
+	records := make([]record, len(candles))
+	for i, candle := range candles {
+		data, err := json.Marshal(candle)
+		if err != nil {
+			return err
+		}
+		records[i] = record{Time: candle.OpenTime, Data: data}
+	}
+	return s.appendRecords(s.seriesDir(exchange, market.Name, candlesSeries(interval)), records)
+}
+
+// Candles gets the stored candles of a market opening in the [from, to) time range (a zero time means no bound).
+func (s *Store) Candles(exchange string, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	records, err := s.readRecords(s.seriesDir(exchange, market.Name, candlesSeries(interval)), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]environment.CandleStick, len(records))
+	for i, r := range records {
+		if err := json.Unmarshal(r.Data, &ret[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// AppendSummary appends a market summary got at the specified time to the store.
+func (s *Store) AppendSummary(exchange string, market *environment.Market, t time.Time, summary environment.MarketSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	return s.appendRecords(s.seriesDir(exchange, market.Name, summariesSeries), []record{{Time: t, Data: data}})
+}
+
+// Summaries gets the stored summaries of a market got in the [from, to) time range (a zero time means no bound).
+func (s *Store) Summaries(exchange string, market *environment.Market, from time.Time, to time.Time) ([]SummarySnapshot, error) {
+	records, err := s.readRecords(s.seriesDir(exchange, market.Name, summariesSeries), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]SummarySnapshot, len(records))
+	for i, r := range records {
+		ret[i].Time = r.Time
+		if err := json.Unmarshal(r.Data, &ret[i].Summary); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// AppendOrderBook appends an order book snapshot got at the specified time to the store.
+func (s *Store) AppendOrderBook(exchange string, market *environment.Market, t time.Time, book environment.OrderBook) error {
+	data, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+	return s.appendRecords(s.seriesDir(exchange, market.Name, orderBooksSeries), []record{{Time: t, Data: data}})
+}
+
+// OrderBooks gets the stored order book snapshots of a market got in the [from, to) time range (a zero time means no bound).
+func (s *Store) OrderBooks(exchange string, market *environment.Market, from time.Time, to time.Time) ([]OrderBookSnapshot, error) {
+	records, err := s.readRecords(s.seriesDir(exchange, market.Name, orderBooksSeries), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]OrderBookSnapshot, len(records))
+	for i, r := range records {
+		ret[i].Time = r.Time
+		if err := json.Unmarshal(r.Data, &ret[i].OrderBook); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// GetCandlesRange gets the candles of a market opening in the [from, to) time range from the store,
+// downloading them from the exchange and saving them if the store does not cover the range.
+func (s *Store) GetCandlesRange(wrapper exchanges.ExchangeWrapper, market *environment.Market, interval environment.Interval, from time.Time, to time.Time) ([]environment.CandleStick, error) {
+	ret, err := s.Candles(wrapper.Name(), market, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if covers(ret, interval, from, to) {
+		return ret, nil
+	}
+
+	ret, err = wrapper.GetCandlesRange(market, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AppendCandles(wrapper.Name(), market, interval, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// covers returns true if a candle series contains the first and the last candle of the [from, to) time range,
+// and the last one is closed.
+func covers(candles []environment.CandleStick, interval environment.Interval, from time.Time, to time.Time) bool {
+	if len(candles) == 0 {
+		return false
+	}
+
+	step := interval.Duration()
+	first, last := candles[0], candles[len(candles)-1]
+	return first.OpenTime.Before(from.Add(step)) && !last.OpenTime.Add(step).Before(to) &&
+		!last.CloseTime.IsZero() && last.CloseTime.Before(time.Now())
+}
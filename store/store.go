@@ -0,0 +1,294 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// partitionFormat is the format of the time of the files a data series is split into.
+const partitionFormat = "2006-01"
+
+// partitionExtension is the extension of the files a data series is split into.
+const partitionExtension = ".jsonl"
+
+// Store is an on-disk store for market data, safe for concurrent use.
+type Store struct {
+	dir   string
+	mutex *sync.RWMutex
+}
+
+// record represents a timestamped value of a data series, as saved on disk.
+type record struct {
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Open opens the store contained in the specified directory, creating it if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{
+		dir:   dir,
+		mutex: &sync.RWMutex{},
+	}, nil
+}
+
+// Dir gets the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// seriesDir gets the directory of a data series.
+func (s *Store) seriesDir(exchange string, market string, series string) string {
+	return filepath.Join(s.dir, url.PathEscape(exchange), url.PathEscape(market), url.PathEscape(series))
+}
+
+// appendRecords appends records to a data series.
+func (s *Store) appendRecords(dir string, records []record) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	partitions := make(map[string][]record)
+	for _, r := range records {
+		name := r.Time.UTC().Format(partitionFormat) + partitionExtension
+		partitions[name] = append(partitions[name], r)
+	}
+
+	for name, partition := range partitions {
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+		err = trimPartialLine(file)
+		if err == nil {
+			err = writeRecords(file, partition)
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRecords reads the records of a data series in the [from, to) time range (a zero time means no bound),
+// sorted by time and keeping only the last appended record for each time.
+func (s *Store) readRecords(dir string, from time.Time, to time.Time) ([]record, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names, err := partitionNames(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []record
+	for _, name := range names {
+		month, err := time.Parse(partitionFormat, strings.TrimSuffix(name, partitionExtension))
+		if err != nil {
+			continue // not a partition
+		}
+		if !from.IsZero() && !month.AddDate(0, 1, 0).After(from) || !to.IsZero() && !month.Before(to) {
+			continue
+		}
+
+		records, err := readPartition(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if (from.IsZero() || !r.Time.Before(from)) && (to.IsZero() || r.Time.Before(to)) {
+				ret = append(ret, r)
+			}
+		}
+	}
+
+	return uniqueRecords(ret), nil
+}
+
+// Compact rewrites all the data series of the store sorted by time and without duplicates,
+// keeping the last appended record for each time.
+func (s *Store) Compact() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != partitionExtension {
+			return nil
+		}
+		return compactPartition(path)
+	})
+}
+
+// compactPartition rewrites a partition file sorted and without duplicates, replacing it atomically.
+func compactPartition(path string) error {
+	records, err := readPartition(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".compact-")
+	if err != nil {
+		return err
+	}
+	err = writeRecords(tmp, uniqueRecords(records))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// partitionNames gets the names of the partition files of a data series, sorted by time.
+func partitionNames(dir string) ([]string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() && filepath.Ext(info.Name()) == partitionExtension {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readPartition reads all the records of a partition file.
+//
+//     Only the last line may fail to decode, being partially written (e.g. after a crash):
+//     a corrupted line followed by other ones is an error.
+func readPartition(path string) ([]record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ret []record
+	var decodeErr error // Error decoding the last line read, which is tolerated only if no line follows.
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			// a partially written last line (e.g. after a crash) is skipped, and removed by the next append.
+			decodeErr = fmt.Errorf("corrupted record at line %d of %s: %s", lineNumber, path, err)
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret, scanner.Err()
+}
+
+// trimPartialLine truncates a partition file to its last complete line, removing the partially written one
+// left by a crash (if any), so that the records appended next start on a new line.
+func trimPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := info.Size()
+	buffer := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := end - int64(len(buffer))
+		if start < 0 {
+			start = 0
+		}
+		n, err := file.ReadAt(buffer[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if i := bytes.LastIndexByte(buffer[:n], '\n'); i >= 0 {
+			if start+int64(i)+1 == size {
+				return nil
+			}
+			return file.Truncate(start + int64(i) + 1)
+		}
+		end = start
+	}
+	return file.Truncate(0)
+}
+
+// writeRecords writes records in JSON lines format.
+func writeRecords(file *os.File, records []record) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// uniqueRecords sorts records by time, keeping only the last one for each time.
+func uniqueRecords(records []record) []record {
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	ret := make([]record, 0, len(records))
+	for _, r := range records {
+		last := len(ret) - 1
+		if last >= 0 && ret[last].Time.Equal(r.Time) {
+			ret[last] = r
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
@@ -0,0 +1,107 @@
+// Copyright © 2021 Nguyen Dang Khoa <khoa.nd.thcn@gmail.com>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	firstLine  = `{"time":"2021-03-01T00:00:00Z","data":1}` + "\n"
+	secondLine = `{"time":"2021-03-01T00:01:00Z","data":2}` + "\n"
+	brokenLine = `{"time":"2021-03-01T00:02:00Z","da`
+)
+
+// writePartition writes the content of a partition file in a temporary directory, returning its path.
+func writePartition(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "2021-03"+partitionExtension)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadPartition(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int // Number of records read, -1 for an error.
+	}{
+		{"empty", "", 0},
+		{"complete lines", firstLine + secondLine, 2},
+		{"empty lines", firstLine + "\n" + secondLine + "\n", 2},
+		{"partial last line", firstLine + secondLine + brokenLine, 2},
+		{"corrupted last line", firstLine + brokenLine + "\n", 1},
+		{"corrupted last line before empty lines", firstLine + brokenLine + "\n\n", 1},
+		{"corrupted line in the middle", firstLine + brokenLine + "\n" + secondLine, -1},
+		{"corrupted first line", brokenLine + "\n" + firstLine, -1},
+	}
+
+	for _, test := range tests {
+		records, err := readPartition(writePartition(t, test.content))
+		switch {
+		case test.want < 0 && err == nil:
+			t.Errorf("%s: got %d records, want an error", test.name, len(records))
+		case test.want >= 0 && err != nil:
+			t.Errorf("%s: got error %s, want %d records", test.name, err, test.want)
+		case test.want >= 0 && len(records) != test.want:
+			t.Errorf("%s: got %d records, want %d", test.name, len(records), test.want)
+		}
+	}
+}
+
+func TestTrimPartialLine(t *testing.T) {
+	longLine := `{"time":"2021-03-01T00:03:00Z","data":"` + strings.Repeat("x", 5000) + `"}` + "\n"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"empty", "", ""},
+		{"complete lines", firstLine + secondLine, firstLine + secondLine},
+		{"partial last line", firstLine + secondLine + brokenLine, firstLine + secondLine},
+		{"partial only line", brokenLine, ""},
+		{"partial line after a line longer than the buffer", longLine + brokenLine, longLine},
+		{"partial line longer than the buffer", firstLine + longLine[:len(longLine)-1], firstLine},
+	}
+
+	for _, test := range tests {
+		path := writePartition(t, test.content)
+		file, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = trimPartialLine(file)
+		file.Close()
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
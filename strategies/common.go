@@ -16,6 +16,7 @@
 package strategies
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -28,14 +29,16 @@ var appliedTactics []Tactic
 
 // Strategy represents a generic strategy.
 type Strategy interface {
-	Name() string                                             // Name returns the name of the strategy.
-	Apply([]exchanges.ExchangeWrapper, []*environment.Market) // Apply applies the strategy when called, using the specified wrapper.
+	Name() string                                                              // Name returns the name of the strategy.
+	Apply(context.Context, []exchanges.ExchangeWrapper, []*environment.Market) // Apply applies the strategy when called, using the specified wrapper, until the context is done.
 }
 
 // StrategyFunc represents a standard function binded to a strategy model execution.
 //
 //     Can define a Setup, TearDown and Update behaviour.
-type StrategyFunc func([]exchanges.ExchangeWrapper, []*environment.Market) error
+//     The context is done when the bot is shutting down, TearDown gets a context which is not,
+//     to be able to clean up (e.g. cancel orders) using the wrappers.
+type StrategyFunc func(context.Context, []exchanges.ExchangeWrapper, []*environment.Market) error
 
 //StrategyModel represents a strategy model used by strategies.
 type StrategyModel struct {
@@ -52,9 +55,9 @@ type Tactic struct {
 	Strategy Strategy
 }
 
-// Execute executes effectively a tactic.
-func (t *Tactic) Execute(wrappers []exchanges.ExchangeWrapper) {
-	t.Strategy.Apply(wrappers, t.Markets)
+// Execute executes effectively a tactic, until the context is done.
+func (t *Tactic) Execute(ctx context.Context, wrappers []exchanges.ExchangeWrapper) {
+	t.Strategy.Apply(ctx, wrappers, t.Markets)
 }
 
 func init() {
@@ -66,6 +69,12 @@ func AddCustomStrategy(s Strategy) {
 	available[s.Name()] = s
 }
 
+// GetStrategy gets an available strategy by its name.
+func GetStrategy(strategyName string) (Strategy, bool) {
+	s, exists := available[strategyName]
+	return s, exists
+}
+
 // MatchWithMarkets matches a strategy with the markets.
 func MatchWithMarkets(strategyName string, markets []*environment.Market) error {
 	s, exists := available[strategyName]
@@ -80,13 +89,15 @@ func MatchWithMarkets(strategyName string, markets []*environment.Market) error
 }
 
 // ApplyAllStrategies applies all matched strategies concurrently.
-func ApplyAllStrategies(wrappers []exchanges.ExchangeWrapper) {
+//
+//     When the context is done the strategies stop, it returns after all of them have been torn down.
+func ApplyAllStrategies(ctx context.Context, wrappers []exchanges.ExchangeWrapper) {
 	var wg sync.WaitGroup
 	wg.Add(len(appliedTactics))
 	for _, t := range appliedTactics {
 		go func(wrappers []exchanges.ExchangeWrapper, t Tactic, wg *sync.WaitGroup) {
 			defer wg.Done()
-			t.Execute(wrappers)
+			t.Execute(ctx, wrappers)
 		}(wrappers, t, &wg)
 	}
 	wg.Wait()
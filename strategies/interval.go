@@ -16,9 +16,11 @@
 package strategies
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"github.com/saniales/golang-crypto-trading-bot/clock"
 	"github.com/saniales/golang-crypto-trading-bot/environment"
 	"github.com/saniales/golang-crypto-trading-bot/exchanges"
 )
@@ -27,6 +29,8 @@ import (
 type IntervalStrategy struct {
 	Model    StrategyModel
 	Interval time.Duration
+	Clock    clock.Clock     // The clock used to wait between updates, the wall clock if nil.
+	Trigger  <-chan struct{} // If set, updates the strategy on every value received instead of every Interval (e.g. exchanges.CandleBuilder.Trigger).
 }
 
 // Name returns the name of the strategy.
@@ -39,8 +43,18 @@ func (is IntervalStrategy) String() string {
 	return is.Name()
 }
 
-// Apply executes Cyclically the On Update, basing on provided interval.
-func (is IntervalStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) {
+// getClock gets the clock used by the strategy.
+func (is IntervalStrategy) getClock() clock.Clock {
+	if is.Clock == nil {
+		return clock.Real{}
+	}
+	return is.Clock
+}
+
+// Apply executes Cyclically the On Update, basing on provided interval or trigger.
+//
+//     The loop stops at the first error or when the context is done, then TearDown is called.
+func (is IntervalStrategy) Apply(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) {
 	var err error
 
 	hasSetupFunc := is.Model.Setup != nil
@@ -49,7 +63,7 @@ func (is IntervalStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets [
 	hasErrorFunc := is.Model.OnError != nil
 
 	if hasSetupFunc {
-		err = is.Model.Setup(wrappers, markets)
+		err = is.Model.Setup(ctx, wrappers, markets)
 		if err != nil && hasErrorFunc {
 			is.Model.OnError(err)
 		}
@@ -63,15 +77,24 @@ func (is IntervalStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets [
 			panic(_err)
 		}
 	}
-	for err == nil {
-		err = is.Model.OnUpdate(wrappers, markets)
+	for err == nil && ctx.Err() == nil {
+		err = is.Model.OnUpdate(ctx, wrappers, markets)
 		if err != nil && hasErrorFunc {
 			is.Model.OnError(err)
 		}
-		time.Sleep(is.Interval)
+
+		var wait <-chan time.Time
+		if is.Trigger == nil {
+			wait = is.getClock().After(is.Interval)
+		}
+		select {
+		case <-ctx.Done():
+		case <-wait:
+		case <-is.Trigger:
+		}
 	}
 	if hasTearDownFunc {
-		err = is.Model.TearDown(wrappers, markets)
+		err = is.Model.TearDown(context.Background(), wrappers, markets)
 		if err != nil && hasErrorFunc {
 			is.Model.OnError(err)
 		}
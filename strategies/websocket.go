@@ -1,6 +1,7 @@
 package strategies
 
 import (
+	"context"
 	"errors"
 
 	"github.com/saniales/golang-crypto-trading-bot/environment"
@@ -24,8 +25,8 @@ func (wss WebsocketStrategy) String() string {
 	return wss.Name()
 }
 
-// Apply executes Cyclically the On Update, basing on provided interval.
-func (wss WebsocketStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) {
+// Apply sets up the strategy, which is updated by the feeds, and tears it down when the context is done.
+func (wss WebsocketStrategy) Apply(ctx context.Context, wrappers []exchanges.ExchangeWrapper, markets []*environment.Market) {
 	var err error
 
 	hasSetupFunc := wss.Model.Setup != nil
@@ -34,7 +35,7 @@ func (wss WebsocketStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets
 	hasErrorFunc := wss.Model.OnError != nil
 
 	if hasSetupFunc {
-		err = wss.Model.Setup(wrappers, markets)
+		err = wss.Model.Setup(ctx, wrappers, markets)
 		if err != nil && hasErrorFunc {
 			wss.Model.OnError(err)
 		}
@@ -50,8 +51,12 @@ func (wss WebsocketStrategy) Apply(wrappers []exchanges.ExchangeWrapper, markets
 		}
 	}
 
+	if err == nil {
+		<-ctx.Done()
+	}
+
 	if hasTearDownFunc {
-		err = wss.Model.TearDown(wrappers, markets)
+		err = wss.Model.TearDown(context.Background(), wrappers, markets)
 		if err != nil && hasErrorFunc {
 			wss.Model.OnError(err)
 		}